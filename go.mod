@@ -10,6 +10,7 @@ require (
 	github.com/microsoft/go-winio v0.4.12
 	golang.org/x/crypto v0.37.0
 	golang.org/x/sys v0.35.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require github.com/gorilla/websocket v1.5.3 // indirect
@@ -29,6 +29,7 @@ import (
 	"github.com/SteamServerUI/SteamServerUI/v7/src/core/loader"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/setup"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/gallery"
 )
 
 //go:embed SSUI/onboard_bundled
@@ -38,6 +39,7 @@ func main() {
 	var wg sync.WaitGroup
 	logger.ConfigureConsole()
 	loader.ParseFlags()
+	loader.HandleRunfileToolFlags()
 	loader.HandleSanityCheckFlag()
 	loader.SanityCheck(&wg)
 	wg.Wait()
@@ -45,6 +47,7 @@ func main() {
 	loader.InitVirtFS(v1uiFS)
 	logger.Install.Info("Starting setup...")
 	loader.ReloadConfig() // Load the config file before starting the setup process
+	loader.HandleBundleFlags()
 	loader.HandleFlags(&wg)
 	wg.Wait()
 	setup.Install(&wg)
@@ -55,6 +58,7 @@ func main() {
 	logger.Main.Debug("Initializing after start tasks...")
 	loader.AfterStartComplete(&wg)
 	wg.Wait()
+	gallery.StartRunfileUpdateChecker()
 	logger.Main.Debug("Starting socket server...")
 	socketapi.StartSocketServer(&wg)
 	logger.Main.Debug("Starting webserver...")
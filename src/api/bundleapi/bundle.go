@@ -0,0 +1,145 @@
+package bundleapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/loader"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/bundlemgr"
+)
+
+// maxBundleUploadSize is generous because a bundle can carry a world backup
+const maxBundleUploadSize = 8 << 30
+
+type response struct {
+	Data  interface{} `json:"data,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// ExportHandler handles GET and POST /api/v2/export and responds with the bundle as a download.
+// GET exports without secrets, POST takes {"passphrase": "...", "include_world": true}
+func ExportHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Passphrase   string `json:"passphrase,omitempty"`
+		IncludeWorld bool   `json:"include_world,omitempty"`
+	}
+	switch r.Method {
+	case http.MethodGet:
+		req.IncludeWorld = strings.ToLower(r.URL.Query().Get("include_world")) == "true"
+	case http.MethodPost:
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				sendResponse(w, http.StatusBadRequest, response{Error: "invalid JSON, check your request"})
+				return
+			}
+		}
+	default:
+		sendResponse(w, http.StatusMethodNotAllowed, response{Error: "only GET and POST requests are allowed"})
+		return
+	}
+
+	// the bundle is built in a temporary file first, so a failed export is reported as an error instead of a broken download
+	tmp, err := os.CreateTemp("", "ssui-export-*.zip")
+	if err != nil {
+		sendResponse(w, http.StatusInternalServerError, response{Error: "failed to create temporary file"})
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	user := security.UsernameFromRequest(r)
+	manifest, err := bundlemgr.Export(tmp, bundlemgr.ExportOptions{Passphrase: req.Passphrase, IncludeWorld: req.IncludeWorld, User: user})
+	if err != nil {
+		logger.Core.Error("Instance export failed: " + err.Error())
+		sendResponse(w, http.StatusInternalServerError, response{Error: err.Error()})
+		return
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		sendResponse(w, http.StatusInternalServerError, response{Error: "failed to read the bundle"})
+		return
+	}
+	logger.Core.Info(fmt.Sprintf("%s exported the instance, %d files, secrets included: %t", user, len(manifest.Files), manifest.Secrets != nil))
+
+	filename := fmt.Sprintf("ssui-%s-%s.zip", manifest.RunfileIdentifier, manifest.CreatedAt.Format("2006-01-02_15-04-05"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	http.ServeContent(w, r, filename, manifest.CreatedAt, tmp)
+}
+
+// ImportHandler handles POST /api/v2/import, a multipart form with the bundle as "file" and the optional
+// fields "passphrase", "include_world" and "dry_run". Dry runs only validate the bundle.
+func ImportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendResponse(w, http.StatusMethodNotAllowed, response{Error: "only POST requests are allowed"})
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBundleUploadSize)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		sendResponse(w, http.StatusBadRequest, response{Error: "invalid upload: " + err.Error()})
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		sendResponse(w, http.StatusBadRequest, response{Error: "the bundle must be uploaded as \"file\""})
+		return
+	}
+	defer file.Close()
+
+	tmp, err := os.CreateTemp("", "ssui-import-*.zip")
+	if err != nil {
+		sendResponse(w, http.StatusInternalServerError, response{Error: "failed to create temporary file"})
+		return
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, file)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		sendResponse(w, http.StatusInternalServerError, response{Error: "failed to store the upload"})
+		return
+	}
+
+	user := security.UsernameFromRequest(r)
+	opts := bundlemgr.ImportOptions{
+		Passphrase:   r.FormValue("passphrase"),
+		IncludeWorld: strings.ToLower(r.FormValue("include_world")) == "true",
+		DryRun:       strings.ToLower(r.FormValue("dry_run")) == "true",
+		User:         user,
+	}
+	result, err := bundlemgr.Import(tmp.Name(), opts)
+	if err != nil {
+		logger.Core.Error("Instance import failed: " + err.Error())
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, bundlemgr.ErrWrongPassphrase) {
+			status = http.StatusBadRequest
+		}
+		if result != nil {
+			// files were already written, the response tells where the previous ones are
+			sendResponse(w, http.StatusInternalServerError, response{Data: result, Error: err.Error()})
+			return
+		}
+		sendResponse(w, status, response{Error: err.Error()})
+		return
+	}
+	if !opts.DryRun {
+		loader.ReloadBackend()
+	}
+	sendResponse(w, http.StatusOK, response{Data: result})
+}
+
+func sendResponse(w http.ResponseWriter, status int, resp response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Core.Error("Failed to encode response: " + err.Error())
+	}
+}
@@ -0,0 +1,252 @@
+package gamefilesapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/filemgr"
+)
+
+// maxChunkSize limits a single upload request, larger files are sent in several chunks
+const maxChunkSize = 64 << 20
+
+type GameFilesResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+type pathRequest struct {
+	Path        string `json:"path"`
+	To          string `json:"to,omitempty"`          // Used for rename
+	Destination string `json:"destination,omitempty"` // Used for extract, defaults to the archive's directory
+}
+
+type uploadStartRequest struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	Overwrite bool   `json:"overwrite"`
+}
+
+// HandleList lists a directory of the game directory, GET ?path=
+func HandleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondGameFilesError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	entries, err := filemgr.List(r.URL.Query().Get("path"))
+	if err != nil {
+		respondGameFilesError(w, err.Error(), errorStatus(err))
+		return
+	}
+	respondGameFilesSuccess(w, "Directory listed", entries)
+}
+
+// HandleDownload sends a file, with range support, or a directory as zip archive, GET ?path=
+func HandleDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondGameFilesError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	requested := r.URL.Query().Get("path")
+	entry, fullPath, err := filemgr.Stat(requested)
+	if err != nil {
+		respondGameFilesError(w, err.Error(), errorStatus(err))
+		return
+	}
+	name := entry.Name
+	if entry.IsDir {
+		if entry.Path == "." {
+			name = "gameserver"
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".zip"))
+		if err := filemgr.WriteZip(w, requested); err != nil {
+			// the headers are sent already, the client sees a truncated archive
+			logger.Web.Error("API: Zip download of " + requested + " failed: " + err.Error())
+		}
+		return
+	}
+	file, err := os.Open(fullPath)
+	if err != nil {
+		respondGameFilesError(w, "Failed to open file: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(entry.Path)))
+	http.ServeContent(w, r, name, entry.ModTime, file)
+}
+
+// HandleUpload starts (POST), inspects (GET ?id=) or cancels (DELETE ?id=) a resumable upload
+func HandleUpload(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req uploadStartRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Path == "" {
+			respondGameFilesError(w, "Invalid request body, path and size are required", http.StatusBadRequest)
+			return
+		}
+		upload, err := filemgr.StartUpload(req.Path, req.Size, req.Overwrite, security.UsernameFromRequest(r))
+		if err != nil {
+			respondGameFilesError(w, err.Error(), errorStatus(err))
+			return
+		}
+		respondGameFilesSuccess(w, "Upload started", upload)
+
+	case http.MethodGet:
+		upload, err := filemgr.GetUpload(r.URL.Query().Get("id"))
+		if err != nil {
+			respondGameFilesError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		respondGameFilesSuccess(w, "Upload status", upload)
+
+	case http.MethodDelete:
+		if err := filemgr.CancelUpload(r.URL.Query().Get("id")); err != nil {
+			respondGameFilesError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		respondGameFilesSuccess(w, "Upload cancelled", nil)
+
+	default:
+		respondGameFilesError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleUploadChunk appends the request body to an upload, PUT ?id=&offset=
+func HandleUploadChunk(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodPost {
+		respondGameFilesError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	if err != nil {
+		respondGameFilesError(w, "offset query parameter is required", http.StatusBadRequest)
+		return
+	}
+	body := http.MaxBytesReader(w, r.Body, maxChunkSize)
+	upload, err := filemgr.WriteChunk(r.URL.Query().Get("id"), offset, body)
+	var mismatch filemgr.ErrOffsetMismatch
+	switch {
+	case errors.As(err, &mismatch):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(GameFilesResponse{Success: false, Message: err.Error(), Data: upload})
+	case err != nil && upload == nil:
+		respondGameFilesError(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(errorStatus(err))
+		json.NewEncoder(w).Encode(GameFilesResponse{Success: false, Message: err.Error(), Data: upload})
+	case upload.Done:
+		respondGameFilesSuccess(w, "Upload complete", upload)
+	default:
+		respondGameFilesSuccess(w, "Chunk received", upload)
+	}
+}
+
+func decodePathRequest(w http.ResponseWriter, r *http.Request) (pathRequest, bool) {
+	var req pathRequest
+	if r.Method != http.MethodPost {
+		respondGameFilesError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return req, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Path == "" {
+		respondGameFilesError(w, "Invalid request body, path is required", http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
+
+// HandleRename moves a file or directory within the game directory
+func HandleRename(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodePathRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := filemgr.Rename(req.Path, req.To); err != nil {
+		respondGameFilesError(w, err.Error(), errorStatus(err))
+		return
+	}
+	logger.Web.Info(fmt.Sprintf("API: %s renamed %s to %s in the game directory", security.UsernameFromRequest(r), req.Path, req.To))
+	respondGameFilesSuccess(w, "Renamed", nil)
+}
+
+// HandleDelete removes a file or directory from the game directory
+func HandleDelete(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodePathRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := filemgr.Delete(req.Path); err != nil {
+		respondGameFilesError(w, err.Error(), errorStatus(err))
+		return
+	}
+	logger.Web.Info(fmt.Sprintf("API: %s deleted %s from the game directory", security.UsernameFromRequest(r), req.Path))
+	respondGameFilesSuccess(w, "Deleted", nil)
+}
+
+// HandleMkdir creates a directory in the game directory
+func HandleMkdir(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodePathRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := filemgr.Mkdir(req.Path); err != nil {
+		respondGameFilesError(w, err.Error(), errorStatus(err))
+		return
+	}
+	respondGameFilesSuccess(w, "Directory created", nil)
+}
+
+// HandleExtract unpacks an archive of the game directory
+func HandleExtract(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodePathRequest(w, r)
+	if !ok {
+		return
+	}
+	destination := req.Destination
+	if destination == "" {
+		destination = path.Dir(strings.ReplaceAll(req.Path, "\\", "/"))
+	}
+	result, err := filemgr.Extract(req.Path, destination)
+	if err != nil {
+		respondGameFilesError(w, err.Error(), errorStatus(err))
+		return
+	}
+	logger.Web.Info(fmt.Sprintf("API: %s extracted %s to %s (%d files)", security.UsernameFromRequest(r), req.Path, destination, result.Files))
+	respondGameFilesSuccess(w, "Archive extracted", result)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, filemgr.ErrOutsideRoot):
+		return http.StatusForbidden
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "already exists"):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func respondGameFilesSuccess(w http.ResponseWriter, message string, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(GameFilesResponse{Success: true, Message: message, Data: data})
+}
+
+func respondGameFilesError(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(GameFilesResponse{Success: false, Message: message})
+}
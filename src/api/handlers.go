@@ -3,9 +3,11 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/SteamServerUI/SteamServerUI/v7/src/core/loader"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/detectionmgr"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/gamemgr"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/steamcmd"
 )
@@ -16,6 +18,10 @@ func GetGameServerRunState(w http.ResponseWriter, r *http.Request) {
 		"isRunning": runState,
 		"uuid":      gamemgr.GameServerUUID.String(),
 	}
+	if state, since := detectionmgr.GetServerState(detectionmgr.GetDetector()); state != "" {
+		response["lastServerEvent"] = state
+		response["lastServerEventAt"] = since.Format(time.RFC3339)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		http.Error(w, "Failed to respond with Game Server status", http.StatusInternalServerError)
@@ -23,6 +29,19 @@ func GetGameServerRunState(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// GetServerCommandPreview returns the command the next start would run and whether it differs from the running server's
+func GetServerCommandPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET requests are allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(gamemgr.GetCommandPreview()); err != nil {
+		http.Error(w, "Failed to respond with the server command", http.StatusInternalServerError)
+		return
+	}
+}
+
 func HandleRunSteamCMD(w http.ResponseWriter, r *http.Request) {
 
 	// Only allow GET requests
@@ -0,0 +1,131 @@
+package playersapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/accessmgr"
+)
+
+type BanRequest struct {
+	SteamID   string     `json:"steamID"`
+	Username  string     `json:"username"`
+	Reason    string     `json:"reason"`
+	Duration  string     `json:"duration,omitempty"`  // go style duration like 24h, empty for permanent
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` // alternative to Duration
+}
+
+type EntryRequest struct {
+	SteamID  string `json:"steamID"`
+	Username string `json:"username"`
+	Note     string `json:"note"`
+}
+
+// HandleBans lists (GET), adds (POST) or lifts (DELETE ?steamid=) bans
+func HandleBans(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		respondPlayersSuccess(w, "Bans retrieved", accessmgr.GetBans())
+
+	case http.MethodPost:
+		var req BanRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondPlayersError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var duration time.Duration
+		if req.Duration != "" {
+			parsed, err := time.ParseDuration(req.Duration)
+			if err != nil || parsed <= 0 {
+				respondPlayersError(w, "duration must be a positive go style duration like 24h", http.StatusBadRequest)
+				return
+			}
+			duration = parsed
+		} else if req.ExpiresAt != nil {
+			duration = time.Until(*req.ExpiresAt)
+			if duration <= 0 {
+				respondPlayersError(w, "expiresAt must be in the future", http.StatusBadRequest)
+				return
+			}
+		}
+
+		issuer := security.UsernameFromRequest(r)
+		ban, err := accessmgr.BanPlayer(req.SteamID, req.Username, req.Reason, issuer, duration)
+		if err != nil {
+			logger.Web.Error("API: Failed to ban player: " + err.Error())
+			respondPlayersError(w, "Failed to ban player: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		respondPlayersSuccess(w, "Player banned", ban)
+
+	case http.MethodDelete:
+		steamID := r.URL.Query().Get("steamid")
+		if steamID == "" {
+			respondPlayersError(w, "Missing steamid query parameter", http.StatusBadRequest)
+			return
+		}
+		if err := accessmgr.UnbanPlayer(steamID); err != nil {
+			respondPlayersError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		respondPlayersSuccess(w, "Player unbanned", nil)
+
+	default:
+		respondPlayersError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleWhitelist lists (GET), adds (POST) or removes (DELETE ?steamid=) whitelisted players
+func HandleWhitelist(w http.ResponseWriter, r *http.Request) {
+	handleAccessEntries(w, r, accessmgr.ListWhitelist)
+}
+
+// HandleAdmins lists (GET), adds (POST) or removes (DELETE ?steamid=) admins
+func HandleAdmins(w http.ResponseWriter, r *http.Request) {
+	handleAccessEntries(w, r, accessmgr.ListAdmins)
+}
+
+func handleAccessEntries(w http.ResponseWriter, r *http.Request, list string) {
+	switch r.Method {
+	case http.MethodGet:
+		entries, err := accessmgr.GetEntries(list)
+		if err != nil {
+			respondPlayersError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		respondPlayersSuccess(w, list+" retrieved", entries)
+
+	case http.MethodPost:
+		var req EntryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondPlayersError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		entry, err := accessmgr.AddEntry(list, req.SteamID, req.Username, req.Note, security.UsernameFromRequest(r))
+		if err != nil {
+			logger.Web.Error("API: Failed to update " + list + ": " + err.Error())
+			respondPlayersError(w, "Failed to update "+list+": "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		respondPlayersSuccess(w, "Player added to "+list, entry)
+
+	case http.MethodDelete:
+		steamID := r.URL.Query().Get("steamid")
+		if steamID == "" {
+			respondPlayersError(w, "Missing steamid query parameter", http.StatusBadRequest)
+			return
+		}
+		if err := accessmgr.RemoveEntry(list, steamID); err != nil {
+			respondPlayersError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		respondPlayersSuccess(w, "Player removed from "+list, nil)
+
+	default:
+		respondPlayersError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,90 @@
+package playersapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/playermgr"
+)
+
+type PlayersResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// HandlePlayerHistory lists all known players with first seen, last seen, playtime and session count
+func HandlePlayerHistory(w http.ResponseWriter, r *http.Request) {
+	logger.Web.Debug("API: Player history requested")
+
+	if r.Method != http.MethodGet {
+		respondPlayersError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	respondPlayersSuccess(w, "Player history retrieved", playermgr.GetPlayerSummaries())
+}
+
+// HandlePlayerDetails returns a single player's record including the session list (?steamid=)
+func HandlePlayerDetails(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondPlayersError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	steamID := r.URL.Query().Get("steamid")
+	if steamID == "" {
+		respondPlayersError(w, "Missing steamid query parameter", http.StatusBadRequest)
+		return
+	}
+
+	record, ok := playermgr.GetPlayerRecord(steamID)
+	if !ok {
+		respondPlayersError(w, "Player not found", http.StatusNotFound)
+		return
+	}
+	respondPlayersSuccess(w, "Player retrieved", record)
+}
+
+// HandlePlayerPeaks returns the peak concurrent players per day (?days=, defaults to 30, 0 for all)
+func HandlePlayerPeaks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondPlayersError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	days := 30
+	if daysParam := r.URL.Query().Get("days"); daysParam != "" {
+		parsed, err := strconv.Atoi(daysParam)
+		if err != nil || parsed < 0 {
+			respondPlayersError(w, "days must be a non-negative number", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	respondPlayersSuccess(w, "Daily peaks retrieved", playermgr.GetDailyPeaks(days))
+}
+
+func respondPlayersSuccess(w http.ResponseWriter, message string, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(PlayersResponse{
+		Success: true,
+		Message: message,
+		Data:    data,
+	})
+}
+
+func respondPlayersError(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	json.NewEncoder(w).Encode(PlayersResponse{
+		Success: false,
+		Message: message,
+	})
+}
@@ -2,6 +2,7 @@ package pluginsapi
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -57,7 +58,11 @@ func PluginSelectHandler(w http.ResponseWriter, r *http.Request) {
 
 	if err := gallery.SavePluginToDisk(req.Name, redownload); err != nil {
 		logger.Plugin.Error("Failed to save plugin " + req.Name + ": " + err.Error())
-		sendResponse(w, http.StatusConflict, response{Error: err.Error()})
+		status := http.StatusConflict
+		if errors.Is(err, gallery.ErrVerificationFailed) {
+			status = http.StatusUnprocessableEntity
+		}
+		sendResponse(w, status, response{Error: err.Error()})
 		return
 	}
 
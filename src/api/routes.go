@@ -6,14 +6,18 @@ import (
 	"path/filepath"
 
 	"github.com/SteamServerUI/SteamServerUI/v7/src/api/backupapi"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/api/bundleapi"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/api/gamefilesapi"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/api/httpauth"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/api/legacyapi"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/api/pages"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/api/playersapi"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/api/pluginsapi"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/api/runfileapi"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/api/settingsapi"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/api/sscmapi"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/api/sseapi"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/api/steamapi"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/api/sysinfoapi"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/detectionmgr"
@@ -65,6 +69,7 @@ func SetupAPIRoutes() (*http.ServeMux, *http.ServeMux) {
 	protectedMux.HandleFunc("/logs/warn", sseapi.GetWarnLogOutput)
 	protectedMux.HandleFunc("/logs/error", sseapi.GetErrorLogOutput)
 	protectedMux.HandleFunc("/logs/backend", sseapi.GetBackendLogOutput)
+	protectedMux.HandleFunc("/steamcmd/progress", sseapi.GetSteamCMDProgressOutput)
 
 	// Server Control
 	protectedMux.HandleFunc("/start", legacyapi.StartServer)
@@ -72,16 +77,36 @@ func SetupAPIRoutes() (*http.ServeMux, *http.ServeMux) {
 	protectedMux.HandleFunc("/api/v2/server/start", legacyapi.StartServer) // TODO: should return json & get their own functions
 	protectedMux.HandleFunc("/api/v2/server/stop", legacyapi.StopServer)   // TODO: should return json & get their own functions
 	protectedMux.HandleFunc("/api/v2/server/status", GetGameServerRunState)
+	protectedMux.HandleFunc("/api/v2/server/command", GetServerCommandPreview)
 	protectedMux.HandleFunc("/api/v2/server/status/connectedplayers", legacyapi.HandleConnectedPlayersList)
 
+	// --- PLAYER HISTORY ---
+	protectedMux.HandleFunc("/api/v2/players/history", playersapi.HandlePlayerHistory)
+	protectedMux.HandleFunc("/api/v2/players/history/player", playersapi.HandlePlayerDetails)
+	protectedMux.HandleFunc("/api/v2/players/stats/peaks", playersapi.HandlePlayerPeaks)
+
+	// --- PLAYER ACCESS CONTROL ---
+	protectedMux.HandleFunc("/api/v2/players/bans", playersapi.HandleBans)
+	protectedMux.HandleFunc("/api/v2/players/whitelist", playersapi.HandleWhitelist)
+	protectedMux.HandleFunc("/api/v2/players/admins", playersapi.HandleAdmins)
+
 	// Configuration
 	protectedMux.HandleFunc("/api/v2/SSCM/run", sscmapi.HandleCommand)           // Command execution via SSCM (needs to be enable, config.IsSSCMEnabled)
 	protectedMux.HandleFunc("/api/v2/SSCM/enabled", sscmapi.HandleIsSSCMEnabled) // Check if SSCM is enabled
 	protectedMux.HandleFunc("/api/v2/steamcmd/run", HandleRunSteamCMD)           // Run SteamCMD
+	protectedMux.HandleFunc("/api/v2/steamcmd/status", steamapi.SteamCMDStatusHandler)
+	protectedMux.HandleFunc("/api/v2/steam/credentials", steamapi.CredentialsHandler)
+	protectedMux.HandleFunc("/api/v2/steam/guard", steamapi.SteamGuardHandler)
+	protectedMux.HandleFunc("/api/v2/workshop", steamapi.WorkshopHandler)
+	protectedMux.HandleFunc("/api/v2/workshop/items", steamapi.WorkshopItemsHandler)
+	protectedMux.HandleFunc("/api/v2/workshop/update", steamapi.WorkshopUpdateHandler)
+	protectedMux.HandleFunc("/api/v2/workshop/settings", steamapi.WorkshopSettingsHandler)
 
 	// Custom Detections
 	protectedMux.HandleFunc("/api/v2/custom-detections", detectionmgr.HandleCustomDetection)
 	protectedMux.HandleFunc("/api/v2/custom-detections/delete/", detectionmgr.HandleDeleteCustomDetection)
+	protectedMux.HandleFunc("/api/v2/custom-detections/test", detectionmgr.HandleTestCustomDetection)
+	protectedMux.HandleFunc("/api/v2/detection/exceptions", detectionmgr.HandleExceptionGroups)
 	// Authentication
 	protectedMux.HandleFunc("/changeuser", pages.ServeTwoBoxFormTemplate)
 	protectedMux.HandleFunc("/api/v2/auth/adduser", httpauth.RegisterUserHandler)        // user registration and change password
@@ -103,6 +128,14 @@ func SetupAPIRoutes() (*http.ServeMux, *http.ServeMux) {
 	protectedMux.HandleFunc("/api/v2/runfile/save", runfileapi.HandleRunfileSave)
 	protectedMux.HandleFunc("/api/v2/runfile/hardreset", runfileapi.HandleSetRunfileGame)
 	protectedMux.HandleFunc("/api/v2/runfile/meta", runfileapi.HandleRunfileGetMeta)
+	protectedMux.HandleFunc("/api/v2/runfile/schema", runfileapi.HandleRunfileSchema)
+	protectedMux.HandleFunc("/api/v2/runfile/presets", runfileapi.HandleRunfilePresets)
+	protectedMux.HandleFunc("/api/v2/runfile/presets/diff", runfileapi.HandleRunfilePresetDiff)
+	protectedMux.HandleFunc("/api/v2/runfile/presets/apply", runfileapi.HandleRunfilePresetApply)
+	protectedMux.HandleFunc("/api/v2/runfile/presets/schedule", runfileapi.HandleRunfilePresetSchedule)
+	protectedMux.HandleFunc("/api/v2/runfile/templates", runfileapi.HandleRunfileTemplates)
+	protectedMux.HandleFunc("/api/v2/runfile/templates/render", runfileapi.HandleRunfileTemplatesRender)
+	protectedMux.HandleFunc("/api/v2/runfile/templates/preview", runfileapi.HandleRunfileTemplatePreview)
 	// --- LOADER ---
 	protectedMux.HandleFunc("/api/v2/loader/reloadrunfile", runfileapi.HandleReloadRunfile)
 	// --- SETTINGS ---
@@ -116,6 +149,11 @@ func SetupAPIRoutes() (*http.ServeMux, *http.ServeMux) {
 	// --- RUNFILE GALLERY ---
 	protectedMux.HandleFunc("/api/v2/gallery", runfileapi.GalleryHandler)
 	protectedMux.HandleFunc("/api/v2/gallery/select", runfileapi.GallerySelectHandler)
+	protectedMux.HandleFunc("/api/v2/gallery/update/preview", runfileapi.GalleryUpdatePreviewHandler)
+	protectedMux.HandleFunc("/api/v2/gallery/update/status", runfileapi.GalleryUpdateStatusHandler)
+	protectedMux.HandleFunc("/api/v2/gallery/update/apply", runfileapi.GalleryUpgradeHandler)
+	protectedMux.HandleFunc("/api/v2/gallery/sources", runfileapi.GallerySourcesHandler)
+	protectedMux.HandleFunc("/api/v2/gallery/keys", runfileapi.GalleryKeysHandler)
 
 	// --- PLUGIN GALLERY ---
 	protectedMux.HandleFunc("/api/v2/plugingallery", pluginsapi.PluginGalleryHandler)
@@ -125,18 +163,38 @@ func SetupAPIRoutes() (*http.ServeMux, *http.ServeMux) {
 	protectedMux.HandleFunc("/api/v2/files", runfileapi.GetFileList)
 	protectedMux.HandleFunc("/api/v2/files/get", runfileapi.GetFile)
 	protectedMux.HandleFunc("/api/v2/files/save", runfileapi.SaveFile)
+	protectedMux.HandleFunc("/api/v2/files/keys", runfileapi.GetFileKeys)
+	protectedMux.HandleFunc("/api/v2/files/key/get", runfileapi.GetFileKey)
+	protectedMux.HandleFunc("/api/v2/files/key/set", runfileapi.SetFileKey)
+	protectedMux.HandleFunc("/api/v2/files/revisions", runfileapi.GetFileRevisions)
+	protectedMux.HandleFunc("/api/v2/files/revisions/diff", runfileapi.DiffFileRevisions)
+	protectedMux.HandleFunc("/api/v2/files/revisions/restore", runfileapi.RestoreFileRevision)
 
 	// --- PLUGINS ---
 	protectedMux.HandleFunc("/api/v2/plugins/list/apiroutes", pluginsapi.HandleListPluginAPIRoutes)
 	protectedMux.HandleFunc("/api/v2/plugins/list/names", pluginsapi.HandleListPluginNames)
 	protectedMux.HandleFunc("/api/v2/plugins/stop", pluginsapi.HandleStopPlugin)
 
+	// --- INSTANCE BUNDLES ---
+	protectedMux.HandleFunc("/api/v2/export", bundleapi.ExportHandler)
+	protectedMux.HandleFunc("/api/v2/import", bundleapi.ImportHandler)
+
 	// --- BACKUP ---
 	protectedMux.HandleFunc("/api/v2/backup/create", backupapi.HandleBackupCreate)
 	protectedMux.HandleFunc("/api/v2/backup/list", backupapi.HandleBackupList)
 	protectedMux.HandleFunc("/api/v2/backup/restore", backupapi.HandleBackupRestore)
 	protectedMux.HandleFunc("/api/v2/backup/status", backupapi.HandleBackupStatus)
 
+	// --- GAME FILES ---
+	protectedMux.HandleFunc("/api/v2/gamefiles/list", gamefilesapi.HandleList)
+	protectedMux.HandleFunc("/api/v2/gamefiles/download", gamefilesapi.HandleDownload)
+	protectedMux.HandleFunc("/api/v2/gamefiles/upload", gamefilesapi.HandleUpload)
+	protectedMux.HandleFunc("/api/v2/gamefiles/upload/chunk", gamefilesapi.HandleUploadChunk)
+	protectedMux.HandleFunc("/api/v2/gamefiles/rename", gamefilesapi.HandleRename)
+	protectedMux.HandleFunc("/api/v2/gamefiles/delete", gamefilesapi.HandleDelete)
+	protectedMux.HandleFunc("/api/v2/gamefiles/mkdir", gamefilesapi.HandleMkdir)
+	protectedMux.HandleFunc("/api/v2/gamefiles/extract", gamefilesapi.HandleExtract)
+
 	return mux, protectedMux
 }
 
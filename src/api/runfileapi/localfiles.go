@@ -2,6 +2,7 @@ package runfileapi
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,7 +10,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/configfile"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/runfile"
 )
 
@@ -19,6 +22,21 @@ type FileRequest struct {
 	Content  string `json:"content,omitempty"` // Used for save operations
 }
 
+// FileKeyRequest represents the JSON body for key-level file operations
+type FileKeyRequest struct {
+	Filename string `json:"filename"`
+	Key      string `json:"key,omitempty"`
+	Value    string `json:"value,omitempty"` // Used for set operations
+}
+
+// FileRevisionRequest represents the JSON body for revision operations, revision 0 stands for the current file
+type FileRevisionRequest struct {
+	Filename string `json:"filename"`
+	From     int    `json:"from,omitempty"`     // Used for diffs
+	To       int    `json:"to,omitempty"`       // Used for diffs
+	Revision int    `json:"revision,omitempty"` // Used for restores
+}
+
 // FileResponse represents the JSON response structure
 type FileResponse struct {
 	Success bool   `json:"success"`
@@ -127,6 +145,11 @@ func GetFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Keep a revision of changes made outside of SSUI
+	if err := runfile.CaptureFileChanges(req.Filename); err != nil {
+		logger.Runfile.Warn(err.Error())
+	}
+
 	// Read file contents
 	content, err := os.ReadFile(targetFile.Filepath)
 	if err != nil {
@@ -201,6 +224,12 @@ func SaveFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Refuse content the gameserver couldn't parse
+	if err := runfile.ValidateFileContent(filename, content); err != nil {
+		sendFileError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Stat the file
 	fileInfo, err := os.Stat(targetFile.Filepath)
 	if err != nil && !os.IsNotExist(err) {
@@ -216,17 +245,22 @@ func SaveFile(w http.ResponseWriter, r *http.Request) {
 
 	// Write file with retries
 	const maxRetries = 3
-	for attempt := 1; attempt <= maxRetries; attempt++ {
-		if err := os.WriteFile(targetFile.Filepath, content, 0644); err != nil {
+	write := func() error {
+		for attempt := 1; ; attempt++ {
+			err := os.WriteFile(targetFile.Filepath, content, 0644)
+			if err == nil {
+				return nil
+			}
 			logger.Runfile.Warn(fmt.Sprintf("failed to write file %s: attempt=%d, error=%v", filename, attempt, err))
 			if attempt == maxRetries {
-				sendFileError(w, http.StatusInternalServerError, fmt.Sprintf("failed to write file %s after %d attempts: %v", filename, maxRetries, err))
-				return
+				return fmt.Errorf("failed to write file %s after %d attempts: %v", filename, maxRetries, err)
 			}
 			time.Sleep(100 * time.Millisecond)
-			continue
 		}
-		break
+	}
+	if err := runfile.WriteFileRevision(filename, content, security.UsernameFromRequest(r), runfile.RevisionSourceEditor, "", write); err != nil {
+		sendFileError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
 	sendFileResponse(w, http.StatusOK, FileResponse{
@@ -235,6 +269,146 @@ func SaveFile(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func decodeFileKeyRequest(w http.ResponseWriter, r *http.Request, needsKey bool) (FileKeyRequest, bool) {
+	var req FileKeyRequest
+	if r.Method != http.MethodPost {
+		sendFileError(w, http.StatusMethodNotAllowed, "only POST requests are allowed")
+		return req, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendFileError(w, http.StatusBadRequest, fmt.Sprintf("failed to parse request JSON body: %v", err))
+		return req, false
+	}
+	if req.Filename == "" || (needsKey && req.Key == "") {
+		sendFileError(w, http.StatusBadRequest, "filename and key are required")
+		return req, false
+	}
+	return req, true
+}
+
+func fileKeyErrorStatus(err error) int {
+	var notFound configfile.ErrKeyNotFound
+	if errors.As(err, &notFound) || strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	if strings.Contains(err.Error(), "forbidden") {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
+
+// GetFileKeys handles POST requests listing every value of a json, ini, xml or yaml file by key path
+func GetFileKeys(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeFileKeyRequest(w, r, false)
+	if !ok {
+		return
+	}
+	entries, err := runfile.GetFileEntries(req.Filename)
+	if err != nil {
+		sendFileError(w, fileKeyErrorStatus(err), err.Error())
+		return
+	}
+	sendFileResponse(w, http.StatusOK, FileResponse{Success: true, Data: entries})
+}
+
+// GetFileKey handles POST requests to read a single value of a file
+func GetFileKey(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeFileKeyRequest(w, r, true)
+	if !ok {
+		return
+	}
+	entry, err := runfile.GetFileValue(req.Filename, req.Key)
+	if err != nil {
+		sendFileError(w, fileKeyErrorStatus(err), err.Error())
+		return
+	}
+	sendFileResponse(w, http.StatusOK, FileResponse{Success: true, Data: entry})
+}
+
+// SetFileKey handles POST requests to change a single existing value of a file, the value keeps the type of the old one
+func SetFileKey(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeFileKeyRequest(w, r, true)
+	if !ok {
+		return
+	}
+	previous, err := runfile.SetFileValue(req.Filename, req.Key, req.Value, security.UsernameFromRequest(r))
+	if err != nil {
+		sendFileError(w, fileKeyErrorStatus(err), err.Error())
+		return
+	}
+	sendFileResponse(w, http.StatusOK, FileResponse{
+		Success: true,
+		Message: fmt.Sprintf("%s in %s changed from %q to %q", req.Key, req.Filename, previous.Value, req.Value),
+	})
+}
+
+func decodeFileRevisionRequest(w http.ResponseWriter, r *http.Request) (FileRevisionRequest, bool) {
+	var req FileRevisionRequest
+	if r.Method != http.MethodPost {
+		sendFileError(w, http.StatusMethodNotAllowed, "only POST requests are allowed")
+		return req, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendFileError(w, http.StatusBadRequest, fmt.Sprintf("failed to parse request JSON body: %v", err))
+		return req, false
+	}
+	if req.Filename == "" {
+		sendFileError(w, http.StatusBadRequest, "filename is required")
+		return req, false
+	}
+	return req, true
+}
+
+// GetFileRevisions handles POST requests listing the stored revisions of a file, newest first
+func GetFileRevisions(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeFileRevisionRequest(w, r)
+	if !ok {
+		return
+	}
+	revisions, err := runfile.ListFileRevisions(req.Filename)
+	if err != nil {
+		sendFileError(w, fileKeyErrorStatus(err), err.Error())
+		return
+	}
+	sendFileResponse(w, http.StatusOK, FileResponse{Success: true, Data: revisions})
+}
+
+// DiffFileRevisions handles POST requests for a unified diff between two revisions of a file
+func DiffFileRevisions(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeFileRevisionRequest(w, r)
+	if !ok {
+		return
+	}
+	diff, err := runfile.DiffFileRevisions(req.Filename, req.From, req.To)
+	if err != nil {
+		sendFileError(w, fileKeyErrorStatus(err), err.Error())
+		return
+	}
+	sendFileResponse(w, http.StatusOK, FileResponse{Success: true, Data: diff})
+}
+
+// RestoreFileRevision handles POST requests to write an older revision back to a file
+func RestoreFileRevision(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeFileRevisionRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Revision <= 0 {
+		sendFileError(w, http.StatusBadRequest, "revision is required")
+		return
+	}
+	revision, err := runfile.RestoreFileRevision(req.Filename, req.Revision, security.UsernameFromRequest(r))
+	if err != nil {
+		sendFileError(w, fileKeyErrorStatus(err), err.Error())
+		return
+	}
+	sendFileResponse(w, http.StatusOK, FileResponse{
+		Success: true,
+		Message: fmt.Sprintf("file %s restored to revision %d", req.Filename, req.Revision),
+		Data:    revision,
+	})
+}
+
 // sendFileResponse sends a JSON response
 func sendFileResponse(w http.ResponseWriter, status int, resp FileResponse) {
 	w.Header().Set("Content-Type", "application/json")
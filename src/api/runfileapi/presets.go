@@ -0,0 +1,159 @@
+package runfileapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/runfile"
+)
+
+type presetRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	At          string `json:"at,omitempty"` // RFC3339 time for schedules, empty for the next server start
+}
+
+func decodePresetRequest(w http.ResponseWriter, r *http.Request) (presetRequest, bool) {
+	var req presetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, nil, "invalid request body")
+		return req, false
+	}
+	if req.Name == "" {
+		writeJSONResponse(w, http.StatusBadRequest, nil, "name is required")
+		return req, false
+	}
+	return req, true
+}
+
+// HandleRunfilePresets lists (GET), creates from the current values (POST) or deletes (DELETE ?name=) presets
+func HandleRunfilePresets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		presets, schedule, err := runfile.ListPresets()
+		if err != nil {
+			writeJSONResponse(w, http.StatusInternalServerError, nil, err.Error())
+			return
+		}
+		// values are only exposed through the diff, where secrets are masked
+		type presetSummary struct {
+			Name        string    `json:"name"`
+			Description string    `json:"description,omitempty"`
+			CreatedAt   time.Time `json:"created_at"`
+			Values      int       `json:"values"`
+		}
+		summaries := make([]presetSummary, 0, len(presets))
+		for _, preset := range presets {
+			summaries = append(summaries, presetSummary{preset.Name, preset.Description, preset.CreatedAt, len(preset.Values)})
+		}
+		writeJSONResponse(w, http.StatusOK, map[string]interface{}{"presets": summaries, "schedule": schedule}, "")
+
+	case http.MethodPost:
+		req, ok := decodePresetRequest(w, r)
+		if !ok {
+			return
+		}
+		preset, err := runfile.CreatePreset(req.Name, req.Description)
+		if err != nil {
+			logger.Runfile.Error(fmt.Sprintf("failed to create preset %s: %v", req.Name, err))
+			writeJSONResponse(w, http.StatusBadRequest, nil, err.Error())
+			return
+		}
+		writeJSONResponse(w, http.StatusOK, map[string]interface{}{"name": preset.Name, "created_at": preset.CreatedAt}, "")
+
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			writeJSONResponse(w, http.StatusBadRequest, nil, "missing name query parameter")
+			return
+		}
+		if err := runfile.DeletePreset(name); err != nil {
+			writeJSONResponse(w, http.StatusNotFound, nil, err.Error())
+			return
+		}
+		writeJSONResponse(w, http.StatusOK, "preset deleted", "")
+
+	default:
+		writeJSONResponse(w, http.StatusMethodNotAllowed, nil, "method not allowed")
+	}
+}
+
+// HandleRunfilePresetDiff handles POST /api/v2/runfile/presets/diff
+func HandleRunfilePresetDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONResponse(w, http.StatusMethodNotAllowed, nil, "only POST requests are allowed")
+		return
+	}
+	req, ok := decodePresetRequest(w, r)
+	if !ok {
+		return
+	}
+	diffs, err := runfile.DiffPreset(req.Name)
+	if err != nil {
+		writeJSONResponse(w, http.StatusNotFound, nil, err.Error())
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, diffs, "")
+}
+
+// HandleRunfilePresetApply handles POST /api/v2/runfile/presets/apply, the values take effect on the next server start
+func HandleRunfilePresetApply(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONResponse(w, http.StatusMethodNotAllowed, nil, "only POST requests are allowed")
+		return
+	}
+	req, ok := decodePresetRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := runfile.ApplyPreset(req.Name); err != nil {
+		logger.Runfile.Error(fmt.Sprintf("failed to apply preset %s: %v", req.Name, err))
+		writeJSONResponse(w, http.StatusBadRequest, nil, fmt.Sprintf("failed to apply preset: %v", err))
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, "preset applied, restart the server for it to take effect", "")
+}
+
+// HandleRunfilePresetSchedule schedules (POST) a preset for the first server start after "at", or removes its schedules (DELETE ?name=)
+func HandleRunfilePresetSchedule(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		req, ok := decodePresetRequest(w, r)
+		if !ok {
+			return
+		}
+		var at time.Time
+		if req.At != "" {
+			parsed, err := time.Parse(time.RFC3339, req.At)
+			if err != nil {
+				writeJSONResponse(w, http.StatusBadRequest, nil, "at must be an RFC3339 time like 2025-06-01T18:00:00+02:00")
+				return
+			}
+			at = parsed
+		}
+		entry, err := runfile.SchedulePreset(req.Name, at)
+		if err != nil {
+			writeJSONResponse(w, http.StatusNotFound, nil, err.Error())
+			return
+		}
+		writeJSONResponse(w, http.StatusOK, entry, "")
+
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			writeJSONResponse(w, http.StatusBadRequest, nil, "missing name query parameter")
+			return
+		}
+		if err := runfile.UnschedulePreset(name); err != nil {
+			writeJSONResponse(w, http.StatusNotFound, nil, err.Error())
+			return
+		}
+		writeJSONResponse(w, http.StatusOK, "schedule removed", "")
+
+	default:
+		writeJSONResponse(w, http.StatusMethodNotAllowed, nil, "method not allowed")
+	}
+}
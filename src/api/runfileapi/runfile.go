@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
 	"strings"
 
 	"github.com/SteamServerUI/SteamServerUI/v7/src/core/loader"
@@ -13,20 +14,32 @@ import (
 
 // APIGameArg is a DTO for GameArg, including RuntimeValue and all fields
 type APIGameArg struct {
-	Flag          string `json:"flag"`
-	Value         string `json:"value"`
-	RuntimeValue  string `json:"runtime_value"`
-	Required      bool   `json:"required"`
-	RequiresValue bool   `json:"requires_value"`
-	Description   string `json:"description"`
-	Type          string `json:"type"`
-	Special       string `json:"special,omitempty"`
-	UILabel       string `json:"ui_label"`
-	UIGroup       string `json:"ui_group"`
-	Weight        int    `json:"weight"`
-	Min           int    `json:"min,omitempty"`
-	Max           int    `json:"max,omitempty"`
-	Disabled      bool   `json:"disabled"`
+	Flag          string   `json:"flag"`
+	Value         string   `json:"value"`
+	RuntimeValue  string   `json:"runtime_value"`
+	Required      bool     `json:"required"`
+	RequiresValue bool     `json:"requires_value"`
+	Description   string   `json:"description"`
+	Type          string   `json:"type"`
+	Special       string   `json:"special,omitempty"`
+	UILabel       string   `json:"ui_label"`
+	UIGroup       string   `json:"ui_group"`
+	Weight        int      `json:"weight"`
+	Min           float64  `json:"min,omitempty"`
+	Max           float64  `json:"max,omitempty"`
+	Options       []string `json:"options,omitempty"`        // enum args
+	ResolvedValue string   `json:"resolved_value,omitempty"` // value with ${...} placeholders resolved
+	ResolveError  string   `json:"resolve_error,omitempty"`  // why the placeholders could not be resolved
+	ResolvedPath  string   `json:"resolved_path,omitempty"`  // path args
+	PathExists    *bool    `json:"path_exists,omitempty"`    // path args
+	IsSet         bool     `json:"is_set,omitempty"`         // secret args, whose values are never returned
+	NeedsReentry  bool     `json:"needs_reentry,omitempty"`  // secret args that couldn't be decrypted and were cleared
+	DependsOn     []string `json:"depends_on,omitempty"`
+	ConflictsWith []string `json:"conflicts_with,omitempty"`
+	EnabledWhen   string   `json:"enabled_when,omitempty"`
+	Active        bool     `json:"active"`                    // false if depends_on or enabled_when are not met
+	InactiveCause string   `json:"inactive_reason,omitempty"` // why the arg is inactive
+	Disabled      bool     `json:"disabled"`
 }
 
 // APIMeta mirrors runfile.Meta for API responses
@@ -52,8 +65,8 @@ type apiResponse struct {
 }
 
 // toAPIGameArg converts runfile.GameArg to APIGameArg
-func toAPIGameArg(arg runfile.GameArg) APIGameArg {
-	return APIGameArg{
+func toAPIGameArg(arg runfile.GameArg, state runfile.ArgState) APIGameArg {
+	apiArg := APIGameArg{
 		Flag:          arg.Flag,
 		Value:         arg.Value,
 		RuntimeValue:  arg.RuntimeValue,
@@ -67,8 +80,42 @@ func toAPIGameArg(arg runfile.GameArg) APIGameArg {
 		Weight:        arg.Weight,
 		Min:           arg.Min,
 		Max:           arg.Max,
+		Options:       arg.Options,
 		Disabled:      arg.Disabled,
+		DependsOn:     arg.DependsOn,
+		ConflictsWith: arg.ConflictsWith,
+		EnabledWhen:   arg.EnabledWhen,
+		Active:        state.Active && !arg.Disabled,
+		InactiveCause: state.Reason,
+	}
+	if arg.IsSecret() {
+		apiArg.Value = ""
+		apiArg.RuntimeValue = ""
+		apiArg.IsSet = arg.RuntimeValue != ""
+		apiArg.NeedsReentry = arg.NeedsReentry
+	}
+	value := arg.RuntimeValue
+	// values that include a secret arg are never returned resolved, secrets can be set but not read
+	hidden := arg.IsSecret()
+	if runfile.HasPlaceholders(arg.RuntimeValue) && arg.Flag != "" {
+		hidden = hidden || runfile.ArgUsesSecret(arg.Flag)
+		resolved, err := runfile.ResolveArgValue(arg.Flag)
+		if err != nil {
+			apiArg.ResolveError = err.Error()
+		} else {
+			value = resolved
+			if !hidden && !runfile.ArgUsesEnv(arg.Flag) {
+				apiArg.ResolvedValue = resolved
+			}
+		}
+	}
+	if arg.Type == runfile.ArgTypePath && value != "" && !hidden {
+		apiArg.ResolvedPath = runfile.ResolveArgPath(value)
+		_, err := os.Stat(apiArg.ResolvedPath)
+		exists := err == nil
+		apiArg.PathExists = &exists
 	}
+	return apiArg
 }
 
 // writeJSONResponse writes a JSON response with the given status code
@@ -81,6 +128,22 @@ func writeJSONResponse(w http.ResponseWriter, status int, data interface{}, errM
 	}
 }
 
+// HandleRunfileSchema handles GET /api/v2/runfile/schema and serves the raw JSON Schema for runfiles
+func HandleRunfileSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONResponse(w, http.StatusMethodNotAllowed, nil, "method not allowed")
+		return
+	}
+	schema, err := runfile.GenerateJSONSchema()
+	if err != nil {
+		logger.Runfile.Error(fmt.Sprintf("failed to generate runfile schema: %v", err))
+		writeJSONResponse(w, http.StatusInternalServerError, nil, "failed to generate runfile schema")
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(schema)
+}
+
 // HandleRunfileGroups handles GET /api/v2/runfile/groups
 func HandleRunfileGroups(w http.ResponseWriter, r *http.Request) {
 	logger.Runfile.Debug("GET /api/v2/runfile/groups")
@@ -127,9 +190,14 @@ func HandleRunfileArgs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Convert to APIGameArg
+	states := runfile.GetArgStates()
 	apiArgs := make([]APIGameArg, len(args))
 	for i, arg := range args {
-		apiArgs[i] = toAPIGameArg(arg)
+		state, ok := states[arg.Flag]
+		if !ok {
+			state.Active = true // args without a flag can't be referenced and have no conditions
+		}
+		apiArgs[i] = toAPIGameArg(arg, state)
 	}
 	writeJSONResponse(w, http.StatusOK, apiArgs, "")
 }
@@ -159,6 +227,12 @@ func HandleRunfileGetArg(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if arg, err := runfile.GetSingleArg(req.Flag); err == nil && arg.IsSecret() {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(Response{Status: "failed", Error: "secret arguments can be set but not read"})
+		return
+	}
+
 	value := runfile.CurrentRunfile.GetArgValue(req.Flag)
 	if value == "" {
 		json.NewEncoder(w).Encode(Response{Status: "failed", Error: "arg not found"})
@@ -179,8 +253,9 @@ func HandleRunfileArgUpdate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Flag  string `json:"flag"`
-		Value string `json:"value"`
+		Flag     string  `json:"flag"`
+		Value    *string `json:"value"`
+		Disabled *bool   `json:"disabled,omitempty"` // stored as a local override, like the value
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		logger.Runfile.Error(fmt.Sprintf("invalid request body: %v", err))
@@ -194,14 +269,36 @@ func HandleRunfileArgUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := runfile.SetArgValue(req.Flag, req.Value); err != nil {
+	if req.Value == nil && req.Disabled == nil {
+		writeJSONResponse(w, http.StatusBadRequest, nil, "value or disabled is required")
+		return
+	}
+
+	if req.Disabled != nil {
+		if err := runfile.SetArgDisabled(req.Flag, *req.Disabled); err != nil {
+			logger.Runfile.Error(fmt.Sprintf("failed to set arg %s disabled: %v", req.Flag, err))
+			writeJSONResponse(w, http.StatusBadRequest, nil, fmt.Sprintf("failed to set arg: %v", err))
+			return
+		}
+		logger.Runfile.Info(fmt.Sprintf("set arg %s disabled to %t", req.Flag, *req.Disabled))
+	}
+	if req.Value == nil {
+		writeJSONResponse(w, http.StatusOK, map[string]interface{}{"flag": req.Flag, "disabled": *req.Disabled}, "")
+		return
+	}
+
+	if err := runfile.SetArgValue(req.Flag, *req.Value); err != nil {
 		logger.Runfile.Error(fmt.Sprintf("failed to set arg %s: %v", req.Flag, err))
 		writeJSONResponse(w, http.StatusBadRequest, nil, fmt.Sprintf("failed to set arg: %v", err))
 		return
 	}
 
-	logger.Runfile.Info(fmt.Sprintf("updated arg %s to %s", req.Flag, req.Value))
-	writeJSONResponse(w, http.StatusOK, map[string]string{"flag": req.Flag, "value": req.Value}, "")
+	value := *req.Value
+	if arg, err := runfile.GetSingleArg(req.Flag); err == nil && arg.IsSecret() {
+		value = logger.RedactedValue
+	}
+	logger.Runfile.Info(fmt.Sprintf("updated arg %s to %s", req.Flag, value))
+	writeJSONResponse(w, http.StatusOK, map[string]string{"flag": req.Flag, "value": value}, "")
 }
 
 // HandleRunfileSave handles POST /api/v2/runfile/save
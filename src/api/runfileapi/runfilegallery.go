@@ -2,11 +2,15 @@ package runfileapi
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/core/loader"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/gallery"
 )
@@ -36,8 +40,10 @@ func GalleryHandler(w http.ResponseWriter, r *http.Request) {
 func GallerySelectHandler(w http.ResponseWriter, r *http.Request) {
 
 	var req struct {
-		Identifier string `json:"identifier"`
-		Redownload bool   `json:"redownload,omitempty"`
+		Identifier   string   `json:"identifier"`
+		Source       string   `json:"source,omitempty"` // gallery source to install from, defaults to the one the gallery lists
+		Redownload   bool     `json:"redownload,omitempty"`
+		TakeUpstream []string `json:"take_upstream,omitempty"` // conflicting overrides to drop in favour of the new upstream value
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		logger.Runfile.Error("Invalid request body: " + err.Error())
@@ -56,15 +62,175 @@ func GallerySelectHandler(w http.ResponseWriter, r *http.Request) {
 		redownload = true
 	}
 
-	if err := gallery.SaveRunfileToDisk(req.Identifier, redownload); err != nil {
+	plan, err := gallery.SaveRunfileToDisk(req.Identifier, req.Source, redownload, req.TakeUpstream)
+	if err != nil {
 		logger.Runfile.Error("Failed to save runfile " + req.Identifier + ": " + err.Error())
-		sendResponse(w, http.StatusConflict, response{Error: err.Error()})
+		status := http.StatusConflict
+		if errors.Is(err, gallery.ErrVerificationFailed) {
+			status = http.StatusUnprocessableEntity
+		}
+		sendResponse(w, status, response{Error: err.Error()})
 		return
 	}
 
 	logger.Runfile.Debug("Successfully saved runfile " + req.Identifier)
 	loader.ReloadBackend()
-	sendResponse(w, http.StatusOK, response{Data: "Runfile " + req.Identifier + " saved"})
+	sendResponse(w, http.StatusOK, response{Data: map[string]interface{}{
+		"message": "Runfile " + req.Identifier + " saved",
+		"update":  plan,
+	}})
+}
+
+// GalleryUpdatePreviewHandler handles POST /api/v2/gallery/update/preview
+// It shows what a redownload would change and which upstream changes conflict with local overrides
+func GalleryUpdatePreviewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendResponse(w, http.StatusMethodNotAllowed, response{Error: "only POST requests are allowed"})
+		return
+	}
+
+	var req struct {
+		Identifier string `json:"identifier"`
+		Source     string `json:"source,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Runfile.Error("Invalid request body: " + err.Error())
+		sendResponse(w, http.StatusBadRequest, response{Error: "invalid JSON, check your request"})
+		return
+	}
+	if req.Identifier == "" {
+		sendResponse(w, http.StatusBadRequest, response{Error: "identifier is required"})
+		return
+	}
+
+	plan, err := gallery.PreviewRunfileUpdate(req.Identifier, req.Source)
+	if err != nil {
+		logger.Runfile.Error("Failed to preview runfile update " + req.Identifier + ": " + err.Error())
+		status := http.StatusBadGateway
+		if errors.Is(err, gallery.ErrVerificationFailed) {
+			status = http.StatusUnprocessableEntity
+		}
+		sendResponse(w, status, response{Error: err.Error()})
+		return
+	}
+	sendResponse(w, http.StatusOK, response{Data: plan})
+}
+
+// GalleryUpdateStatusHandler handles GET /api/v2/gallery/update/status
+// It tells whether the gallery has a newer version of the installed runfile, ?refresh=true refetches the manifests first
+func GalleryUpdateStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendResponse(w, http.StatusMethodNotAllowed, response{Error: "only GET requests are allowed"})
+		return
+	}
+	refresh := strings.ToLower(r.URL.Query().Get("refresh")) == "true"
+	status, err := gallery.CheckRunfileUpdate(refresh)
+	if err != nil {
+		sendResponse(w, http.StatusServiceUnavailable, response{Error: err.Error()})
+		return
+	}
+	sendResponse(w, http.StatusOK, response{Data: status})
+}
+
+// GalleryUpgradeHandler handles POST /api/v2/gallery/update/apply
+// It upgrades the installed runfile to the gallery version, keeping local values and backing up the previous runfile
+func GalleryUpgradeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendResponse(w, http.StatusMethodNotAllowed, response{Error: "only POST requests are allowed"})
+		return
+	}
+	var req struct {
+		TakeUpstream []string `json:"take_upstream,omitempty"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendResponse(w, http.StatusBadRequest, response{Error: "invalid JSON, check your request"})
+			return
+		}
+	}
+
+	plan, status, err := gallery.UpgradeRunfile(req.TakeUpstream)
+	if err != nil {
+		logger.Runfile.Error("Runfile upgrade failed: " + err.Error())
+		httpStatus := http.StatusConflict
+		if errors.Is(err, gallery.ErrVerificationFailed) {
+			httpStatus = http.StatusUnprocessableEntity
+		}
+		sendResponse(w, httpStatus, response{Error: err.Error()})
+		return
+	}
+
+	logger.Runfile.Info(fmt.Sprintf("%s upgraded runfile %s from %s to %s", security.UsernameFromRequest(r), status.Identifier, status.InstalledVersion, status.AvailableVersion))
+	loader.ReloadBackend()
+	sendResponse(w, http.StatusOK, response{Data: map[string]interface{}{
+		"message":          "Runfile " + status.Identifier + " upgraded to " + status.AvailableVersion + ", restart the gameserver to use it",
+		"update":           plan,
+		"changelog":        status.Changelog,
+		"restart_required": true,
+	}})
+}
+
+// GallerySourcesHandler handles GET and POST /api/v2/gallery/sources
+// GET lists the configured sources with the result of their last fetch, POST replaces them and refetches the gallery
+func GallerySourcesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		sendResponse(w, http.StatusOK, response{Data: gallery.GetGallerySourceStatus()})
+
+	case http.MethodPost:
+		var req struct {
+			Sources []config.GallerySource `json:"sources"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.Runfile.Error("Invalid request body: " + err.Error())
+			sendResponse(w, http.StatusBadRequest, response{Error: "invalid JSON, check your request"})
+			return
+		}
+		if err := config.SetRunfileGallerySources(req.Sources); err != nil {
+			sendResponse(w, http.StatusBadRequest, response{Error: err.Error()})
+			return
+		}
+		logger.Runfile.Info(fmt.Sprintf("%s changed the runfile gallery sources to %d sources", security.UsernameFromRequest(r), len(req.Sources)))
+		// failing sources are reported in the status, so the error is only logged
+		if _, err := gallery.GetRunfileGallery(true); err != nil {
+			logger.Runfile.Warn("Gallery refresh after changing sources failed: " + err.Error())
+		}
+		sendResponse(w, http.StatusOK, response{Data: gallery.GetGallerySourceStatus()})
+
+	default:
+		sendResponse(w, http.StatusMethodNotAllowed, response{Error: "only GET and POST requests are allowed"})
+	}
+}
+
+// GalleryKeysHandler handles GET and POST /api/v2/gallery/keys
+// GET lists the trusted publisher keys, POST replaces them with a map of publisher id to base64 ed25519 public key
+func GalleryKeysHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		sendResponse(w, http.StatusOK, response{Data: map[string]interface{}{
+			"mode": config.GetGalleryVerificationMode(),
+			"keys": config.GetTrustedPublisherKeys(),
+		}})
+
+	case http.MethodPost:
+		var req struct {
+			Keys map[string]string `json:"keys"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.Runfile.Error("Invalid request body: " + err.Error())
+			sendResponse(w, http.StatusBadRequest, response{Error: "invalid JSON, check your request"})
+			return
+		}
+		if err := config.SetTrustedPublisherKeys(req.Keys); err != nil {
+			sendResponse(w, http.StatusBadRequest, response{Error: err.Error()})
+			return
+		}
+		logger.Runfile.Info(fmt.Sprintf("%s changed the trusted gallery publishers to %d keys", security.UsernameFromRequest(r), len(req.Keys)))
+		sendResponse(w, http.StatusOK, response{Data: config.GetTrustedPublisherKeys()})
+
+	default:
+		sendResponse(w, http.StatusMethodNotAllowed, response{Error: "only GET and POST requests are allowed"})
+	}
 }
 
 // sendResponse writes a JSON response with the given status code
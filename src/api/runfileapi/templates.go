@@ -0,0 +1,59 @@
+package runfileapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/runfile"
+)
+
+// HandleRunfileTemplates handles GET /api/v2/runfile/templates, the status of every config template
+func HandleRunfileTemplates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONResponse(w, http.StatusMethodNotAllowed, nil, "only GET requests are allowed")
+		return
+	}
+	if runfile.CurrentRunfile == nil {
+		writeJSONResponse(w, http.StatusServiceUnavailable, nil, "runfile not loaded")
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, runfile.GetTemplateStatus(), "")
+}
+
+// HandleRunfileTemplatesRender handles POST /api/v2/runfile/templates/render, which renders the templates without a server start
+func HandleRunfileTemplatesRender(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONResponse(w, http.StatusMethodNotAllowed, nil, "only POST requests are allowed")
+		return
+	}
+	statuses, err := runfile.RenderTemplates()
+	if err != nil {
+		logger.Runfile.Error("failed to render config templates: " + err.Error())
+		writeJSONResponse(w, http.StatusBadRequest, statuses, fmt.Sprintf("failed to render templates: %v", err))
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, statuses, "")
+}
+
+// HandleRunfileTemplatePreview handles POST /api/v2/runfile/templates/preview with {"target": "..."}
+func HandleRunfileTemplatePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONResponse(w, http.StatusMethodNotAllowed, nil, "only POST requests are allowed")
+		return
+	}
+	var req struct {
+		Target string `json:"target"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Target == "" {
+		writeJSONResponse(w, http.StatusBadRequest, nil, "target is required")
+		return
+	}
+	content, err := runfile.PreviewTemplate(req.Target)
+	if err != nil {
+		writeJSONResponse(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, map[string]string{"target": req.Target, "content": content}, "")
+}
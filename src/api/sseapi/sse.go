@@ -36,6 +36,10 @@ func GetBackendLogOutput(w http.ResponseWriter, r *http.Request) {
 	StartBackendLogStream()(w, r)
 }
 
+func GetSteamCMDProgressOutput(w http.ResponseWriter, r *http.Request) {
+	StartSteamCMDProgressStream()(w, r)
+}
+
 // StartConsoleStream creates an HTTP handler for console log SSE streaming
 func StartConsoleStream() http.HandlerFunc {
 	return ssestream.ConsoleStreamManager.CreateStreamHandler("Console")
@@ -65,3 +69,8 @@ func StartErrorLogStream() http.HandlerFunc {
 func StartBackendLogStream() http.HandlerFunc {
 	return ssestream.BackendLogStreamManager.CreateStreamHandler("Full Backend Log")
 }
+
+// StartSteamCMDProgressStream creates an HTTP handler for SteamCMD progress SSE streaming
+func StartSteamCMDProgressStream() http.HandlerFunc {
+	return ssestream.SteamCMDProgressManager.CreateStreamHandler("SteamCMD Progress")
+}
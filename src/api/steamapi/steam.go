@@ -0,0 +1,119 @@
+package steamapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamcmd"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/runfile"
+)
+
+type response struct {
+	Data  interface{} `json:"data,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// CredentialsHandler handles GET, POST and DELETE /api/v2/steam/credentials.
+// GET never returns the password, POST takes {"username": "...", "password": "..."} and keeps the
+// stored password if none is given for the same username, DELETE removes the account.
+func CredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		username, password := config.GetSteamCredentials()
+		sendResponse(w, http.StatusOK, response{Data: map[string]interface{}{
+			"username":       username,
+			"password_set":   password != "",
+			"login_required": runfile.CurrentRunfile != nil && runfile.CurrentRunfile.SteamLoginRequired,
+		}})
+	case http.MethodPost:
+		var req struct {
+			Username string `json:"username"`
+			Password string `json:"password,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendResponse(w, http.StatusBadRequest, response{Error: "invalid JSON, check your request"})
+			return
+		}
+		if req.Username == "" {
+			sendResponse(w, http.StatusBadRequest, response{Error: "username is required, use DELETE to remove the account"})
+			return
+		}
+		currentUser, stored := config.GetSteamCredentials()
+		if req.Password != "" {
+			encrypted, err := security.EncryptSecret(req.Password)
+			if err != nil {
+				sendResponse(w, http.StatusInternalServerError, response{Error: "failed to encrypt the password"})
+				return
+			}
+			stored = encrypted
+		} else if req.Username != currentUser {
+			stored = ""
+		}
+		if err := config.SetSteamCredentials(req.Username, stored); err != nil {
+			sendResponse(w, http.StatusBadRequest, response{Error: err.Error()})
+			return
+		}
+		logger.Install.Info(security.UsernameFromRequest(r) + " set the Steam account to " + req.Username)
+		sendResponse(w, http.StatusOK, response{Data: map[string]interface{}{"username": req.Username, "password_set": stored != ""}})
+	case http.MethodDelete:
+		if err := config.SetSteamCredentials("", ""); err != nil {
+			sendResponse(w, http.StatusInternalServerError, response{Error: err.Error()})
+			return
+		}
+		logger.Install.Info(security.UsernameFromRequest(r) + " removed the Steam account")
+		sendResponse(w, http.StatusOK, response{Data: map[string]interface{}{"username": "", "password_set": false}})
+	default:
+		sendResponse(w, http.StatusMethodNotAllowed, response{Error: "only GET, POST and DELETE requests are allowed"})
+	}
+}
+
+// SteamGuardHandler handles GET and POST /api/v2/steam/guard.
+// GET returns the pending Steam Guard prompt or null, POST takes {"code": "..."} and passes it to SteamCMD.
+func SteamGuardHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		sendResponse(w, http.StatusOK, response{Data: map[string]interface{}{"pending": steamcmd.PendingSteamGuard()}})
+	case http.MethodPost:
+		var req struct {
+			Code string `json:"code"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendResponse(w, http.StatusBadRequest, response{Error: "invalid JSON, check your request"})
+			return
+		}
+		if err := steamcmd.SubmitSteamGuardCode(req.Code); err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, steamcmd.ErrNoPendingSteamGuard) {
+				status = http.StatusConflict
+			}
+			sendResponse(w, status, response{Error: err.Error()})
+			return
+		}
+		logger.Install.Info(security.UsernameFromRequest(r) + " submitted a Steam Guard code")
+		sendResponse(w, http.StatusOK, response{Data: map[string]interface{}{"message": "Steam Guard code passed to SteamCMD"}})
+	default:
+		sendResponse(w, http.StatusMethodNotAllowed, response{Error: "only GET and POST requests are allowed"})
+	}
+}
+
+// SteamCMDStatusHandler handles GET /api/v2/steamcmd/status, the progress of the running or the last SteamCMD run.
+// Live updates are streamed on /steamcmd/progress.
+func SteamCMDStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendResponse(w, http.StatusMethodNotAllowed, response{Error: "only GET requests are allowed"})
+		return
+	}
+	sendResponse(w, http.StatusOK, response{Data: map[string]interface{}{"progress": steamcmd.GetProgress()}})
+}
+
+func sendResponse(w http.ResponseWriter, status int, resp response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Install.Error("Failed to encode response: " + err.Error())
+	}
+}
@@ -0,0 +1,119 @@
+package steamapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamcmd"
+)
+
+type workshopItemsRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// WorkshopHandler handles GET /api/v2/workshop, the Workshop items of the instance with their installed versions and sizes
+func WorkshopHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendResponse(w, http.StatusMethodNotAllowed, response{Error: "only GET requests are allowed"})
+		return
+	}
+	sendWorkshopStatus(w)
+}
+
+// WorkshopItemsHandler handles POST and DELETE /api/v2/workshop/items with {"ids": ["..."]}.
+// POST adds items, they are downloaded by the next update. DELETE removes them with their files.
+func WorkshopItemsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		sendResponse(w, http.StatusMethodNotAllowed, response{Error: "only POST and DELETE requests are allowed"})
+		return
+	}
+	var req workshopItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.IDs) == 0 {
+		sendResponse(w, http.StatusBadRequest, response{Error: "invalid JSON, expected {\"ids\": [...]}"})
+		return
+	}
+	user := security.UsernameFromRequest(r)
+	var err error
+	if r.Method == http.MethodPost {
+		err = steamcmd.AddWorkshopItems(req.IDs, user)
+	} else {
+		err = steamcmd.RemoveWorkshopItems(req.IDs)
+	}
+	if err != nil {
+		sendWorkshopError(w, err)
+		return
+	}
+	logger.Install.Info(user + " changed the Workshop items (" + r.Method + ")")
+	sendWorkshopStatus(w)
+}
+
+// WorkshopUpdateHandler handles POST /api/v2/workshop/update, optionally with {"ids": ["..."]} to update only some items.
+// It blocks until SteamCMD is done.
+func WorkshopUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendResponse(w, http.StatusMethodNotAllowed, response{Error: "only POST requests are allowed"})
+		return
+	}
+	var req workshopItemsRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendResponse(w, http.StatusBadRequest, response{Error: "invalid JSON, check your request"})
+			return
+		}
+	}
+	logger.Install.Info(security.UsernameFromRequest(r) + " started a Workshop update")
+	status, err := steamcmd.UpdateWorkshopItems(req.IDs)
+	if err != nil {
+		if status != nil {
+			// partial update, the status shows which items made it
+			sendResponse(w, http.StatusMultiStatus, response{Data: status, Error: err.Error()})
+			return
+		}
+		sendWorkshopError(w, err)
+		return
+	}
+	sendResponse(w, http.StatusOK, response{Data: status})
+}
+
+// WorkshopSettingsHandler handles POST /api/v2/workshop/settings with {"update_before_restart": true}
+func WorkshopSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendResponse(w, http.StatusMethodNotAllowed, response{Error: "only POST requests are allowed"})
+		return
+	}
+	var req struct {
+		UpdateBeforeRestart *bool `json:"update_before_restart"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UpdateBeforeRestart == nil {
+		sendResponse(w, http.StatusBadRequest, response{Error: "invalid JSON, expected {\"update_before_restart\": true|false}"})
+		return
+	}
+	if err := steamcmd.SetWorkshopUpdateBeforeRestart(*req.UpdateBeforeRestart); err != nil {
+		sendWorkshopError(w, err)
+		return
+	}
+	sendWorkshopStatus(w)
+}
+
+func sendWorkshopStatus(w http.ResponseWriter) {
+	status, err := steamcmd.GetWorkshopStatus()
+	if err != nil {
+		sendWorkshopError(w, err)
+		return
+	}
+	sendResponse(w, http.StatusOK, response{Data: status})
+}
+
+func sendWorkshopError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, steamcmd.ErrWorkshopUnsupported):
+		status = http.StatusNotFound
+	case errors.Is(err, steamcmd.ErrInvalidWorkshopRequest):
+		status = http.StatusBadRequest
+	}
+	sendResponse(w, status, response{Error: err.Error()})
+}
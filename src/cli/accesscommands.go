@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/accessmgr"
+)
+
+// player access control commands, see accessmgr
+func init() {
+	RegisterCommand("ban", banCommand, "bansteamid")
+	RegisterCommand("tempban", tempBanCommand, "tban")
+	RegisterCommand("unban", unbanCommand, "unbansteamid")
+	RegisterCommand("listbans", listBansCommand, "bans", "lb")
+	RegisterCommand("whitelist", func(args []string) error { return accessListCommand(accessmgr.ListWhitelist, args) }, "wl")
+	RegisterCommand("admins", func(args []string) error { return accessListCommand(accessmgr.ListAdmins, args) }, "adm")
+}
+
+// ban <steamid> [reason...]
+func banCommand(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: ban <steamid> [reason...]")
+	}
+	_, err := accessmgr.BanPlayer(args[0], "", strings.Join(args[1:], " "), "cli", 0)
+	return err
+}
+
+// tempban <steamid> <duration> [reason...]
+func tempBanCommand(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: tempban <steamid> <duration like 24h> [reason...]")
+	}
+	duration, err := time.ParseDuration(args[1])
+	if err != nil || duration <= 0 {
+		return fmt.Errorf("invalid duration %q, use go style durations like 30m or 24h", args[1])
+	}
+	_, err = accessmgr.BanPlayer(args[0], "", strings.Join(args[2:], " "), "cli", duration)
+	return err
+}
+
+// unban <steamid>
+func unbanCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: unban <steamid>")
+	}
+	return accessmgr.UnbanPlayer(args[0])
+}
+
+func listBansCommand(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("command does not accept arguments")
+	}
+	bans := accessmgr.GetBans()
+	if len(bans) == 0 {
+		logger.Core.Info("No players are banned")
+		return nil
+	}
+	for _, ban := range bans {
+		expiry := "permanent"
+		if ban.ExpiresAt != nil {
+			expiry = "until " + ban.ExpiresAt.Format(time.RFC3339)
+		}
+		logger.Core.Info(fmt.Sprintf("- %s %s (%s, by %s) %s", ban.SteamID, ban.Username, expiry, ban.IssuedBy, ban.Reason))
+	}
+	return nil
+}
+
+// whitelist|admins <add|remove|list> [steamid] [note...]
+func accessListCommand(list string, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: %s <add|remove|list> [steamid] [note...]", list)
+	}
+	switch args[0] {
+	case "list":
+		entries, err := accessmgr.GetEntries(list)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			logger.Core.Info("The " + list + " list is empty")
+			return nil
+		}
+		for _, entry := range entries {
+			logger.Core.Info(fmt.Sprintf("- %s %s (by %s) %s", entry.SteamID, entry.Username, entry.AddedBy, entry.Note))
+		}
+		return nil
+	case "add":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: %s add <steamid> [note...]", list)
+		}
+		_, err := accessmgr.AddEntry(list, args[1], "", strings.Join(args[2:], " "), "cli")
+		return err
+	case "remove":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s remove <steamid>", list)
+		}
+		return accessmgr.RemoveEntry(list, args[1])
+	default:
+		return fmt.Errorf("unknown subcommand %q, use add, remove or list", args[0])
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -262,10 +261,13 @@ func supportPackage() {
 		if err != nil || i.IsDir() {
 			return nil
 		}
-		f, _ := os.Open(p)
-		defer f.Close()
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
 		w, _ := zw.Create(strings.TrimPrefix(p, "./"))
-		io.Copy(w, f)
+		// lines logged before a secret was registered may still contain it
+		w.Write([]byte(logger.Redact(string(data))))
 		return nil
 	})
 
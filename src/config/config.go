@@ -27,6 +27,8 @@ type JsonConfig struct {
 	// Gameserver Settings
 	RunfileIdentifier string `json:"RunfileIdentifier"`
 	GameBranch        string `json:"gameBranch"`
+	SteamUsername     string `json:"SteamUsername"`
+	SteamPassword     string `json:"SteamPassword"`
 
 	// Logging and debug settings
 	Debug              *bool    `json:"Debug"`
@@ -52,12 +54,16 @@ type JsonConfig struct {
 	BackendName              string            `json:"BackendName"`
 	BackendEndpointPort      string            `json:"BackendEndpointPort"`
 	RegisteredPlugins        map[string]string `json:"RegisteredPlugins"`
+	RunfileGallerySources    []GallerySource   `json:"RunfileGallerySources"`
+	GalleryVerificationMode  string            `json:"GalleryVerificationMode"`
+	TrustedPublisherKeys     map[string]string `json:"TrustedPublisherKeys"`
 
 	// Update Settings
-	IsUpdateEnabled            *bool `json:"IsUpdateEnabled"`
-	AllowPrereleaseUpdates     *bool `json:"AllowPrereleaseUpdates"`
-	AllowMajorUpdates          *bool `json:"AllowMajorUpdates"`
-	AllowAutoGameServerUpdates *bool `json:"AllowAutoGameServerUpdates"`
+	IsUpdateEnabled             *bool `json:"IsUpdateEnabled"`
+	AllowPrereleaseUpdates      *bool `json:"AllowPrereleaseUpdates"`
+	AllowMajorUpdates           *bool `json:"AllowMajorUpdates"`
+	AllowAutoGameServerUpdates  *bool `json:"AllowAutoGameServerUpdates"`
+	IsRunfileUpdateCheckEnabled *bool `json:"IsRunfileUpdateCheckEnabled"`
 
 	// Discord Settings
 	DiscordToken            string `json:"discordToken"`
@@ -71,6 +77,12 @@ type JsonConfig struct {
 	IsDiscordEnabled        *bool  `json:"isDiscordEnabled"`
 	ErrorChannelID          string `json:"errorChannelID"`
 
+	// Detection Settings
+	ExceptionStartPattern        string        `json:"ExceptionStartPattern"`
+	ExceptionContinuationPattern string        `json:"ExceptionContinuationPattern"`
+	ExceptionFlushTimeout        time.Duration `json:"ExceptionFlushTimeout"`
+	ExceptionAlertCooldown       time.Duration `json:"ExceptionAlertCooldown"`
+
 	// Backup Settings
 	BackupsStoreDir      string        `json:"BackupsStoreDir"`
 	BackupLoopActive     *bool         `json:"BackupLoopActive"`
@@ -127,6 +139,9 @@ func applyConfig(cfg *JsonConfig) {
 
 	ErrorChannelID = getString(cfg.ErrorChannelID, "ERROR_CHANNEL_ID", "")
 	GameBranch = getString(cfg.GameBranch, "GAME_BRANCH", "public")
+	SteamUsername = getString(cfg.SteamUsername, "STEAM_USERNAME", "")
+	SteamPassword = getString(cfg.SteamPassword, "STEAM_PASSWORD", "")
+	steamPasswordFromEnv = cfg.SteamPassword == "" && SteamPassword != ""
 
 	LanguageSetting = getString(cfg.LanguageSetting, "LANGUAGE_SETTING", "en-US")
 	BackendName = getString(cfg.BackendName, "SSUI_IDENTIFIER", "")
@@ -134,6 +149,14 @@ func applyConfig(cfg *JsonConfig) {
 
 	Users = getUsers(cfg.Users, "SSUI_USERS", map[string]string{})
 	RegisteredPlugins = getPlugins(cfg.RegisteredPlugins, "SSUI_REGISTERED_PLUGINS", map[string]string{})
+	RunfileGallerySources = getGallerySources(cfg.RunfileGallerySources, "RUNFILE_GALLERY_SOURCES", []GallerySource{{Name: "official", Location: DefaultRunfileGalleryURL}})
+	GalleryVerificationMode = getString(cfg.GalleryVerificationMode, "GALLERY_VERIFICATION_MODE", "verify")
+	if GalleryVerificationMode != "verify" && GalleryVerificationMode != "require" {
+		// the logger isn't available in config, and a typo must not weaken verification
+		fmt.Printf("ERROR: unknown GalleryVerificationMode %q, it must be verify or require. Using require until it is fixed.\n", GalleryVerificationMode)
+		GalleryVerificationMode = "require"
+	}
+	TrustedPublisherKeys = getPublisherKeys(cfg.TrustedPublisherKeys, "TRUSTED_PUBLISHER_KEYS", map[string]string{})
 
 	authEnabledVal := getBool(cfg.AuthEnabled, "SSUI_AUTH_ENABLED", false)
 	AuthEnabled = authEnabledVal
@@ -172,6 +195,10 @@ func applyConfig(cfg *JsonConfig) {
 	AllowAutoGameServerUpdates = allowAutoGameServerUpdatesVal
 	cfg.AllowAutoGameServerUpdates = &allowAutoGameServerUpdatesVal
 
+	isRunfileUpdateCheckEnabledVal := getBool(cfg.IsRunfileUpdateCheckEnabled, "IS_RUNFILE_UPDATE_CHECK_ENABLED", true)
+	IsRunfileUpdateCheckEnabled = isRunfileUpdateCheckEnabledVal
+	cfg.IsRunfileUpdateCheckEnabled = &isRunfileUpdateCheckEnabledVal
+
 	SubsystemFilters = getStringSlice(cfg.SubsystemFilters, "SUBSYSTEM_FILTERS", []string{})
 	AutoRestartServerTimer = getString(cfg.AutoRestartServerTimer, "AUTO_RESTART_SERVER_TIMER", "0")
 
@@ -195,6 +222,12 @@ func applyConfig(cfg *JsonConfig) {
 	AutoStartServerOnStartup = autoStartServerOnStartupVal
 	cfg.AutoStartServerOnStartup = &autoStartServerOnStartupVal
 
+	// Detection Settings
+	ExceptionStartPattern = getString(cfg.ExceptionStartPattern, "EXCEPTION_START_PATTERN", `^\s*>?\s*\d{2}:\d{2}:\d{2}:.*(Exception|StackTrace)`)
+	ExceptionContinuationPattern = getString(cfg.ExceptionContinuationPattern, "EXCEPTION_CONTINUATION_PATTERN", `^\s+at |^\s*\(wrapper |^\s*--- |^\s*Rethrow as |^\s*[\w.+<>]+:[\w.<>]+\s*\(.*\)`)
+	ExceptionFlushTimeout = getDuration(cfg.ExceptionFlushTimeout, "EXCEPTION_FLUSH_TIMEOUT", 2*time.Second)
+	ExceptionAlertCooldown = getDuration(cfg.ExceptionAlertCooldown, "EXCEPTION_ALERT_COOLDOWN", 10*time.Minute)
+
 	// Backup Manager v3 Settings
 	BackupsStoreDir = getString(cfg.BackupsStoreDir, "STORED_BACKUPS_DIR", SSUIFolder+"backups/storedBackups")
 	BackupLoopInterval = getDuration(cfg.BackupLoopInterval, "BACKUP_LOOP_INTERVAL", 0*time.Hour)
@@ -214,49 +247,67 @@ func applyConfig(cfg *JsonConfig) {
 // buildCurrentJsonConfig constructs JsonConfig from current runtime state
 func buildCurrentJsonConfig() JsonConfig {
 	return JsonConfig{
-		DiscordToken:               DiscordToken,
-		ControlChannelID:           ControlChannelID,
-		StatusChannelID:            StatusChannelID,
-		ConnectionListChannelID:    ConnectionListChannelID,
-		LogChannelID:               LogChannelID,
-		SaveChannelID:              SaveChannelID,
-		ControlPanelChannelID:      ControlPanelChannelID,
-		DiscordCharBufferSize:      DiscordCharBufferSize,
-		IsDiscordEnabled:           &IsDiscordEnabled,
-		ErrorChannelID:             ErrorChannelID,
-		GameBranch:                 GameBranch,
-		Users:                      Users,
-		AuthEnabled:                &AuthEnabled,
-		JwtKey:                     JwtKey,
-		AuthTokenLifetime:          AuthTokenLifetime,
-		Debug:                      &IsDebugMode,
-		CreateSSUILogFile:          &CreateSSUILogFile,
-		LogLevel:                   LogLevel,
-		LogClutterToConsole:        &LogClutterToConsole,
-		GameLogFromLogFile:         &GameLogFromLogFile,
-		SubsystemFilters:           SubsystemFilters,
-		IsUpdateEnabled:            &IsUpdateEnabled,
-		IsSSCMEnabled:              &IsSSCMEnabled,
-		IsBepInExEnabled:           &IsBepInExEnabled,
-		AutoRestartServerTimer:     AutoRestartServerTimer,
-		AllowPrereleaseUpdates:     &AllowPrereleaseUpdates,
-		AllowMajorUpdates:          &AllowMajorUpdates,
-		AllowAutoGameServerUpdates: &AllowAutoGameServerUpdates,
-		IsSSUICLIConsoleEnabled:    &IsSSUICLIConsoleEnabled,
-		LanguageSetting:            LanguageSetting,
-		AutoStartServerOnStartup:   &AutoStartServerOnStartup,
-		BackendName:                BackendName,
-		BackendEndpointPort:        BackendEndpointPort,
-		RunfileIdentifier:          RunfileIdentifier,
-		RegisteredPlugins:          RegisteredPlugins,
-		BackupsStoreDir:            BackupsStoreDir,
-		BackupLoopInterval:         BackupLoopInterval,
-		BackupMode:                 BackupMode,
-		BackupMaxFileSize:          BackupMaxFileSize,
-		BackupUseCompression:       &BackupUseCompression,
-		BackupKeepSnapshot:         &BackupKeepSnapshot,
-		BackupLoopActive:           &BackupLoopActive,
+		DiscordToken:                 DiscordToken,
+		ControlChannelID:             ControlChannelID,
+		StatusChannelID:              StatusChannelID,
+		ConnectionListChannelID:      ConnectionListChannelID,
+		LogChannelID:                 LogChannelID,
+		SaveChannelID:                SaveChannelID,
+		ControlPanelChannelID:        ControlPanelChannelID,
+		DiscordCharBufferSize:        DiscordCharBufferSize,
+		IsDiscordEnabled:             &IsDiscordEnabled,
+		ErrorChannelID:               ErrorChannelID,
+		GameBranch:                   GameBranch,
+		SteamUsername:                SteamUsername,
+		SteamPassword:                persistedSteamPassword(),
+		Users:                        Users,
+		AuthEnabled:                  &AuthEnabled,
+		JwtKey:                       JwtKey,
+		AuthTokenLifetime:            AuthTokenLifetime,
+		Debug:                        &IsDebugMode,
+		CreateSSUILogFile:            &CreateSSUILogFile,
+		LogLevel:                     LogLevel,
+		LogClutterToConsole:          &LogClutterToConsole,
+		GameLogFromLogFile:           &GameLogFromLogFile,
+		SubsystemFilters:             SubsystemFilters,
+		IsUpdateEnabled:              &IsUpdateEnabled,
+		IsSSCMEnabled:                &IsSSCMEnabled,
+		IsBepInExEnabled:             &IsBepInExEnabled,
+		AutoRestartServerTimer:       AutoRestartServerTimer,
+		AllowPrereleaseUpdates:       &AllowPrereleaseUpdates,
+		AllowMajorUpdates:            &AllowMajorUpdates,
+		AllowAutoGameServerUpdates:   &AllowAutoGameServerUpdates,
+		IsRunfileUpdateCheckEnabled:  &IsRunfileUpdateCheckEnabled,
+		IsSSUICLIConsoleEnabled:      &IsSSUICLIConsoleEnabled,
+		LanguageSetting:              LanguageSetting,
+		AutoStartServerOnStartup:     &AutoStartServerOnStartup,
+		BackendName:                  BackendName,
+		BackendEndpointPort:          BackendEndpointPort,
+		RunfileIdentifier:            RunfileIdentifier,
+		RegisteredPlugins:            RegisteredPlugins,
+		RunfileGallerySources:        RunfileGallerySources,
+		GalleryVerificationMode:      GalleryVerificationMode,
+		TrustedPublisherKeys:         TrustedPublisherKeys,
+		ExceptionStartPattern:        ExceptionStartPattern,
+		ExceptionContinuationPattern: ExceptionContinuationPattern,
+		ExceptionFlushTimeout:        ExceptionFlushTimeout,
+		ExceptionAlertCooldown:       ExceptionAlertCooldown,
+		BackupsStoreDir:              BackupsStoreDir,
+		BackupLoopInterval:           BackupLoopInterval,
+		BackupMode:                   BackupMode,
+		BackupMaxFileSize:            BackupMaxFileSize,
+		BackupUseCompression:         &BackupUseCompression,
+		BackupKeepSnapshot:           &BackupKeepSnapshot,
+		BackupLoopActive:             &BackupLoopActive,
+	}
+}
+
+// persistedSteamPassword is the password written to config.json, one from STEAM_PASSWORD is left out and stays in the environment
+func persistedSteamPassword() string {
+	if steamPasswordFromEnv {
+		return ""
 	}
+	return SteamPassword
 }
 
 // safeSaveConfigAtomic writes config atomically using temp file + rename
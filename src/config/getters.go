@@ -68,6 +68,13 @@ func GetGameBranch() string {
 	return GameBranch
 }
 
+// GetSteamCredentials returns the Steam username and the stored, usually encrypted, password
+func GetSteamCredentials() (string, string) {
+	ConfigMu.RLock()
+	defer ConfigMu.RUnlock()
+	return SteamUsername, SteamPassword
+}
+
 func GetUsers() map[string]string {
 	ConfigMu.RLock()
 	defer ConfigMu.RUnlock()
@@ -273,6 +280,24 @@ func GetCustomDetectionsFilePath() string {
 	return CustomDetectionsFilePath
 }
 
+func GetPlayerHistoryFilePath() string {
+	ConfigMu.RLock()
+	defer ConfigMu.RUnlock()
+	return PlayerHistoryFilePath
+}
+
+func GetPlayerAccessFilePath() string {
+	ConfigMu.RLock()
+	defer ConfigMu.RUnlock()
+	return PlayerAccessFilePath
+}
+
+func GetSecretsKeyFilePath() string {
+	ConfigMu.RLock()
+	defer ConfigMu.RUnlock()
+	return SecretsKeyFilePath
+}
+
 func GetGameServerAppID() string {
 	ConfigMu.RLock()
 	defer ConfigMu.RUnlock()
@@ -347,6 +372,36 @@ func GetRegisteredPlugins() map[string]string {
 	return RegisteredPlugins
 }
 
+// GetRunfileGallerySources returns a copy of the configured runfile gallery sources
+func GetRunfileGallerySources() []GallerySource {
+	ConfigMu.RLock()
+	defer ConfigMu.RUnlock()
+	return append([]GallerySource(nil), RunfileGallerySources...)
+}
+
+func GetIsRunfileUpdateCheckEnabled() bool {
+	ConfigMu.RLock()
+	defer ConfigMu.RUnlock()
+	return IsRunfileUpdateCheckEnabled
+}
+
+func GetGalleryVerificationMode() string {
+	ConfigMu.RLock()
+	defer ConfigMu.RUnlock()
+	return GalleryVerificationMode
+}
+
+// GetTrustedPublisherKeys returns a copy of the trusted gallery publisher keys
+func GetTrustedPublisherKeys() map[string]string {
+	ConfigMu.RLock()
+	defer ConfigMu.RUnlock()
+	keys := make(map[string]string, len(TrustedPublisherKeys))
+	for id, key := range TrustedPublisherKeys {
+		keys[id] = key
+	}
+	return keys
+}
+
 func GetGameLogFromLogFile() bool {
 	ConfigMu.RLock()
 	defer ConfigMu.RUnlock()
@@ -400,3 +455,27 @@ func GetIsTelemetryEnabled() bool {
 	defer ConfigMu.RUnlock()
 	return IsTelemetryEnabled
 }
+
+func GetExceptionStartPattern() string {
+	ConfigMu.RLock()
+	defer ConfigMu.RUnlock()
+	return ExceptionStartPattern
+}
+
+func GetExceptionContinuationPattern() string {
+	ConfigMu.RLock()
+	defer ConfigMu.RUnlock()
+	return ExceptionContinuationPattern
+}
+
+func GetExceptionFlushTimeout() time.Duration {
+	ConfigMu.RLock()
+	defer ConfigMu.RUnlock()
+	return ExceptionFlushTimeout
+}
+
+func GetExceptionAlertCooldown() time.Duration {
+	ConfigMu.RLock()
+	defer ConfigMu.RUnlock()
+	return ExceptionAlertCooldown
+}
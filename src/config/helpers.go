@@ -110,6 +110,55 @@ func getPlugins(jsonValue map[string]string, envKey string, defaultValue map[str
 	return defaultValue
 }
 
+// getPublisherKeys retrieves the trusted gallery publisher keys with JSON -> env -> default hierarchy
+func getPublisherKeys(jsonValue map[string]string, envKey string, defaultValue map[string]string) map[string]string {
+	if jsonValue != nil {
+		return jsonValue
+	}
+	if envValue := os.Getenv(envKey); envValue != "" {
+		// Expect env var as "publisher1:base64key1,publisher2:base64key2"
+		keys := make(map[string]string)
+		pairs := strings.Split(envValue, ",")
+		for _, pair := range pairs {
+			parts := strings.SplitN(pair, ":", 2)
+			if len(parts) == 2 {
+				keys[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+			}
+		}
+		if len(keys) > 0 {
+			return keys
+		}
+	}
+	return defaultValue
+}
+
+// getGallerySources retrieves the runfile gallery sources with JSON -> env -> default hierarchy
+func getGallerySources(jsonValue []GallerySource, envKey string, defaultValue []GallerySource) []GallerySource {
+	if len(jsonValue) > 0 {
+		return jsonValue
+	}
+	if envValue := os.Getenv(envKey); envValue != "" {
+		// Expect env var as "name=location,location2", earlier entries get the higher priority
+		var sources []GallerySource
+		entries := strings.Split(envValue, ",")
+		for i, entry := range entries {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			name, location, found := strings.Cut(entry, "=")
+			if !found {
+				name, location = fmt.Sprintf("source%d", i+1), entry
+			}
+			sources = append(sources, GallerySource{Name: strings.TrimSpace(name), Location: strings.TrimSpace(location), Priority: len(entries) - i})
+		}
+		if len(sources) > 0 {
+			return sources
+		}
+	}
+	return defaultValue
+}
+
 func getInt64(jsonVal int64, envKey string, defaultVal int64) int64 {
 	if jsonVal != 0 {
 		return jsonVal
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
@@ -221,6 +224,25 @@ func SetGameBranch(value string) error {
 	return safeSaveConfigAtomic()
 }
 
+// SetSteamCredentials stores the Steam account, the password must already be encrypted. Empty values remove the account.
+func SetSteamCredentials(username, encryptedPassword string) error {
+	ConfigMu.Lock()
+	defer ConfigMu.Unlock()
+
+	username = strings.TrimSpace(username)
+	if username == "" && encryptedPassword != "" {
+		return fmt.Errorf("steam password set without a username")
+	}
+	if strings.ContainsAny(username, " \t\r\n\"") {
+		return fmt.Errorf("steam username contains invalid characters")
+	}
+
+	SteamUsername = username
+	SteamPassword = encryptedPassword
+	steamPasswordFromEnv = false
+	return safeSaveConfigAtomic()
+}
+
 func SetAutoStartServerOnStartup(value bool) error {
 	ConfigMu.Lock()
 	defer ConfigMu.Unlock()
@@ -453,6 +475,78 @@ func SetRegisteredPlugins(value map[string]string) error {
 	return safeSaveConfigAtomic()
 }
 
+// SetRunfileGallerySources replaces the runfile gallery sources, names must be unique
+func SetRunfileGallerySources(value []GallerySource) error {
+	ConfigMu.Lock()
+	defer ConfigMu.Unlock()
+
+	if len(value) == 0 {
+		return fmt.Errorf("at least one gallery source is required")
+	}
+	seen := make(map[string]bool)
+	sources := make([]GallerySource, 0, len(value))
+	for _, source := range value {
+		source.Name = strings.TrimSpace(source.Name)
+		source.Location = strings.TrimSpace(source.Location)
+		if source.Name == "" || source.Location == "" {
+			return fmt.Errorf("gallery source name and location cannot be empty")
+		}
+		if seen[strings.ToLower(source.Name)] {
+			return fmt.Errorf("gallery source %s is listed twice", source.Name)
+		}
+		seen[strings.ToLower(source.Name)] = true
+		if lower := strings.ToLower(source.Location); strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+			if u, err := url.Parse(source.Location); err != nil || u.Host == "" {
+				return fmt.Errorf("gallery source %s has an invalid URL %s", source.Name, source.Location)
+			}
+		}
+		sources = append(sources, source)
+	}
+
+	RunfileGallerySources = sources
+	return safeSaveConfigAtomic()
+}
+
+func SetIsRunfileUpdateCheckEnabled(value bool) error {
+	ConfigMu.Lock()
+	defer ConfigMu.Unlock()
+
+	IsRunfileUpdateCheckEnabled = value
+	return safeSaveConfigAtomic()
+}
+
+func SetGalleryVerificationMode(value string) error {
+	ConfigMu.Lock()
+	defer ConfigMu.Unlock()
+
+	if value != "verify" && value != "require" {
+		return fmt.Errorf("gallery verification mode must be verify or require")
+	}
+	GalleryVerificationMode = value
+	return safeSaveConfigAtomic()
+}
+
+// SetTrustedPublisherKeys replaces the trusted gallery publisher keys, each a base64 encoded ed25519 public key
+func SetTrustedPublisherKeys(value map[string]string) error {
+	ConfigMu.Lock()
+	defer ConfigMu.Unlock()
+
+	keys := make(map[string]string, len(value))
+	for id, key := range value {
+		id, key = strings.TrimSpace(id), strings.TrimSpace(key)
+		if id == "" {
+			return fmt.Errorf("publisher id cannot be empty")
+		}
+		if raw, err := base64.StdEncoding.DecodeString(key); err != nil || len(raw) != ed25519.PublicKeySize {
+			return fmt.Errorf("key of publisher %s is not a base64 encoded ed25519 public key", id)
+		}
+		keys[id] = key
+	}
+
+	TrustedPublisherKeys = keys
+	return safeSaveConfigAtomic()
+}
+
 func SetGameLogFromLogFile(value bool) error {
 	ConfigMu.Lock()
 	defer ConfigMu.Unlock()
@@ -516,3 +610,58 @@ func SetBackupLoopActive(value bool) error {
 	BackupLoopActive = value
 	return safeSaveConfigAtomic()
 }
+
+func SetExceptionStartPattern(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("ExceptionStartPattern cannot be empty")
+	}
+	if _, err := regexp.Compile(value); err != nil {
+		return fmt.Errorf("invalid ExceptionStartPattern: %v", err)
+	}
+
+	ConfigMu.Lock()
+	defer ConfigMu.Unlock()
+
+	ExceptionStartPattern = value
+	return safeSaveConfigAtomic()
+}
+
+func SetExceptionContinuationPattern(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("ExceptionContinuationPattern cannot be empty")
+	}
+	if _, err := regexp.Compile(value); err != nil {
+		return fmt.Errorf("invalid ExceptionContinuationPattern: %v", err)
+	}
+
+	ConfigMu.Lock()
+	defer ConfigMu.Unlock()
+
+	ExceptionContinuationPattern = value
+	return safeSaveConfigAtomic()
+}
+
+func SetExceptionFlushTimeout(value time.Duration) error {
+	if value <= 0 {
+		return fmt.Errorf("ExceptionFlushTimeout must be greater than 0")
+	}
+
+	ConfigMu.Lock()
+	defer ConfigMu.Unlock()
+
+	ExceptionFlushTimeout = value
+	return safeSaveConfigAtomic()
+}
+
+func SetExceptionAlertCooldown(value time.Duration) error {
+	// 0 would read back as unset on the next load and restore the default
+	if value <= 0 {
+		return fmt.Errorf("ExceptionAlertCooldown must be greater than 0")
+	}
+
+	ConfigMu.Lock()
+	defer ConfigMu.Unlock()
+
+	ExceptionAlertCooldown = value
+	return safeSaveConfigAtomic()
+}
@@ -53,6 +53,13 @@ var (
 	IsTelemetryEnabled   = false // ONLY RUNTIME (for now)
 )
 
+// Steam account, used by SteamCMD for games that can't be installed anonymously
+var (
+	SteamUsername        string
+	SteamPassword        string // encrypted with the secrets key, a plain value (from STEAM_PASSWORD) is used as is
+	steamPasswordFromEnv bool   // ONLY RUNTIME, a password from STEAM_PASSWORD is never written to config.json
+)
+
 // Discord integration
 var (
 	DiscordToken            string
@@ -69,6 +76,14 @@ var (
 	ExceptionMessageID      string
 )
 
+// Detection settings
+var (
+	ExceptionStartPattern        string
+	ExceptionContinuationPattern string
+	ExceptionFlushTimeout        time.Duration
+	ExceptionAlertCooldown       time.Duration
+)
+
 // Backup settings
 var (
 	BackupsStoreDir      string
@@ -91,10 +106,11 @@ var (
 
 // SSUI Updates and Game Server Updates
 var (
-	IsUpdateEnabled            bool
-	AllowPrereleaseUpdates     bool
-	AllowMajorUpdates          bool
-	AllowAutoGameServerUpdates bool
+	IsUpdateEnabled             bool
+	AllowPrereleaseUpdates      bool
+	AllowMajorUpdates           bool
+	AllowAutoGameServerUpdates  bool
+	IsRunfileUpdateCheckEnabled bool // periodically compares the installed runfile with the gallery
 )
 
 // BepInEx settings
@@ -110,12 +126,35 @@ var (
 	RegisteredPlugins map[string]string
 )
 
+// Runfile gallery
+
+// DefaultRunfileGalleryURL is the official runfile gallery, used when no sources are configured
+const DefaultRunfileGalleryURL = "https://steamserverui.github.io/runfiles"
+
+// GallerySource is a place runfiles are offered from, a HTTP(S) base URL or a local directory.
+// Both hold a manifest.ssui and the run<Identifier>.ssui files next to it.
+type GallerySource struct {
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Priority int    `json:"priority"` // higher wins when several sources offer the same runfile
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+var (
+	RunfileGallerySources   []GallerySource
+	GalleryVerificationMode string            // "verify" checks digests and signatures that are present, "require" refuses entries without them
+	TrustedPublisherKeys    map[string]string // publisher id to base64 ed25519 public key
+)
+
 // File paths
 var (
 	TLSCertPath              = "./SSUI/tls/cert.pem"
 	TLSKeyPath               = "./SSUI/tls/key.pem"
 	ConfigPath               = "./SSUI/config/config.json"
 	CustomDetectionsFilePath = "./SSUI/config/customdetections.json"
+	PlayerHistoryFilePath    = "./SSUI/config/playerhistory.json"
+	PlayerAccessFilePath     = "./SSUI/config/playeraccess.json"
+	SecretsKeyFilePath       = "./SSUI/config/secrets.key"
 	LogFolder                = "./SSUI/logs/"
 	SSUIFolder               = "./SSUI/"
 	TwoBoxFormFolder         = "./SSUI/twoboxform/"
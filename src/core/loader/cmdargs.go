@@ -3,6 +3,7 @@ package loader
 import (
 	"flag"
 	"fmt"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -10,6 +11,8 @@ import (
 	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/bundlemgr"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/runfile"
 )
 
 // Define flags matching the config variable names
@@ -23,6 +26,12 @@ var devModeFlag bool
 var skipSteamCMDFlag bool
 var sanityCheckFlag bool
 var SetCustomWorkDirFlag string
+var lintRunfileFlag string
+var printRunfileSchemaFlag bool
+var exportBundleFlag string
+var importBundleFlag string
+var bundlePassphraseFlag string
+var bundleWithWorldFlag bool
 
 // ParseFlags parses command-line arguments ONCE at startup (called from func main)
 func ParseFlags() {
@@ -43,6 +52,14 @@ func ParseFlags() {
 	flag.BoolVar(&sanityCheckFlag, "NoSanityCheck", false, "Skips the sanity check. Not recommended.")
 	flag.StringVar(&SetCustomWorkDirFlag, "SetCustomWorkDir", "", "Sets a custom workdir. Not recommended for production use, but possible. (e.g., /home/steam/SSUI/)")
 
+	flag.StringVar(&lintRunfileFlag, "lint-runfile", "", "Lints a runfile for all operating systems and exits (e.g., ./runStationeers.ssui)")
+	flag.BoolVar(&printRunfileSchemaFlag, "runfile-schema", false, "Prints the JSON Schema for runfiles and exits")
+
+	flag.StringVar(&exportBundleFlag, "export", "", "Exports this instance to a bundle and exits (e.g., ./ssui-bundle.zip)")
+	flag.StringVar(&importBundleFlag, "import", "", "Imports an instance bundle and exits (e.g., ./ssui-bundle.zip)")
+	flag.StringVar(&bundlePassphraseFlag, "bundle-passphrase", "", "Passphrase for the secrets of an exported or imported bundle (or set SSUI_BUNDLE_PASSPHRASE)")
+	flag.BoolVar(&bundleWithWorldFlag, "with-world", false, "Includes the latest world backup when exporting or importing a bundle")
+
 	// Parse command-line flags
 	flag.Parse()
 }
@@ -121,3 +138,61 @@ func HandleSanityCheckFlag() {
 		time.Sleep(5 * time.Second)
 	}
 }
+
+// HandleRunfileToolFlags runs the offline runfile tools (lint, schema) and exits. Called right after ParseFlags, before anything touches the disk.
+func HandleRunfileToolFlags() {
+	if printRunfileSchemaFlag {
+		schema, err := runfile.GenerateJSONSchema()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to generate runfile schema: "+err.Error())
+			os.Exit(1)
+		}
+		fmt.Println(string(schema))
+		os.Exit(0)
+	}
+
+	if lintRunfileFlag != "" {
+		issues := runfile.LintRunfile(lintRunfileFlag)
+		for _, issue := range issues {
+			fmt.Println(issue.String())
+		}
+		if runfile.HasLintErrors(issues) {
+			fmt.Printf("%s: %d issue(s), runfile has errors\n", lintRunfileFlag, len(issues))
+			os.Exit(1)
+		}
+		fmt.Printf("%s: %d warning(s), no errors\n", lintRunfileFlag, len(issues))
+		os.Exit(0)
+	}
+}
+
+// HandleBundleFlags exports or imports an instance bundle and exits. Called after ReloadConfig so the paths are known.
+func HandleBundleFlags() {
+	if exportBundleFlag == "" && importBundleFlag == "" {
+		return
+	}
+	passphrase := bundlePassphraseFlag
+	if passphrase == "" {
+		passphrase = os.Getenv("SSUI_BUNDLE_PASSPHRASE")
+	}
+
+	if exportBundleFlag != "" {
+		manifest, err := bundlemgr.ExportToFile(exportBundleFlag, bundlemgr.ExportOptions{Passphrase: passphrase, IncludeWorld: bundleWithWorldFlag, User: "cli"})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Export failed: "+err.Error())
+			os.Exit(1)
+		}
+		fmt.Printf("Exported %d files to %s, secrets included: %t\n", len(manifest.Files), exportBundleFlag, manifest.Secrets != nil)
+		os.Exit(0)
+	}
+
+	result, err := bundlemgr.Import(importBundleFlag, bundlemgr.ImportOptions{Passphrase: passphrase, IncludeWorld: bundleWithWorldFlag, User: "cli"})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Import failed: "+err.Error())
+		os.Exit(1)
+	}
+	for _, warning := range result.Warnings {
+		fmt.Println("Warning: " + warning)
+	}
+	fmt.Printf("Imported %d files from %s, previous files kept in %s\n", len(result.Files), importBundleFlag, result.BackupDir)
+	os.Exit(0)
+}
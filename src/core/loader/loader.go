@@ -11,8 +11,11 @@ import (
 	"github.com/SteamServerUI/SteamServerUI/v7/src/discord/discordbot"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/localization"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/accessmgr"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/backupmgr"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/detectionmgr"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/gamemgr"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/playermgr"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/setup"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/setup/update"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/steamcmd"
@@ -26,12 +29,14 @@ func InitBackend(wg *sync.WaitGroup) {
 	defer wg.Done()
 	ReloadConfig()
 	ReloadRunfile()
+	ReloadAccessLists()
 	ReloadBepInEx()
 	ReloadBackupMgr()
 	ReloadLocalizer()
 	ReloadAppInfoPoller()
 	ReloadDiscordBot()
 	InitDetector()
+	InitPreRestartHooks()
 	telemetry.InitTelemetry()
 }
 
@@ -41,10 +46,12 @@ func ReloadBackend() {
 	logger.Core.Info("Reloading backend...")
 	ReloadConfig()
 	ReloadRunfile()
+	ReloadAccessLists()
 	ReloadBepInEx()
 	ReloadBackupMgr()
 	ReloadLocalizer()
 	ReloadAppInfoPoller()
+	ReplayServerLog()
 	PrintConfigDetails()
 	plugins.ManagePlugins()
 	logger.Core.Info("Backend reload done!")
@@ -81,10 +88,33 @@ func InitDetector() {
 	detector := detectionmgr.Start()
 	detectionmgr.RegisterDefaultHandlers(detector)
 	detectionmgr.InitCustomDetectionsManager(detector)
+	playermgr.InitPlayerHistory(detector)
+	ReplayServerLog()
+	playermgr.ResumeSessions(detector.GetConnectedPlayers())
 	go detectionmgr.StreamLogs(detector)
 	logger.Detection.Info("Detector loaded successfully")
 }
 
+// a log written to within this window most likely belongs to a gameserver that is still running, even if SSUI didn't start it
+const replayLogFreshness = 5 * time.Minute
+
+// ReplayServerLog rebuilds the detectors connected players and server state from the current runs log, without sending notifications
+func ReplayServerLog() {
+	path := gamemgr.CurrentLogPath()
+	info, err := os.Stat(path)
+	if err != nil {
+		logger.Detection.Debug("No server log to replay: " + err.Error())
+		return
+	}
+	if !gamemgr.InternalIsServerRunning() && time.Since(info.ModTime()) > replayLogFreshness {
+		logger.Detection.Debug("Gameserver is not running, skipping log replay")
+		return
+	}
+	if _, err := detectionmgr.ReplayLogFile(detectionmgr.GetDetector(), path); err != nil {
+		logger.Detection.Warn("Failed to replay server log: " + err.Error())
+	}
+}
+
 func RestartBackend() {
 	update.RestartMySelf()
 }
@@ -97,6 +127,15 @@ func ReloadAppInfoPoller() {
 	steamcmd.AppInfoPoller()
 }
 
+// InitPreRestartHooks registers the tasks automatic restarts run while the server is stopped
+func InitPreRestartHooks() {
+	gamemgr.RegisterPreRestartHook("workshop", steamcmd.UpdateWorkshopBeforeRestart)
+}
+
+func ReloadAccessLists() {
+	accessmgr.InitAccessLists()
+}
+
 func ReloadBackupMgr() {
 	backupmgr.InitBackupMgr()
 }
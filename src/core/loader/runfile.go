@@ -27,6 +27,7 @@ func InitRunfile(game string) error {
 	if err := ReloadRunfile(); err != nil {
 		return err
 	}
+	ReloadAccessLists()
 
 	logger.Runfile.Info("Runfile game updated to " + game)
 	logger.Runfile.Info("Running SteamCMD, this may take a while...")
@@ -4,6 +4,8 @@ package security
 //repurposed from a Jacksonthemaster private repo
 
 import (
+	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -73,3 +75,32 @@ func ValidateJWT(tokenString string) (bool, error) {
 	}
 	return true, nil
 }
+
+// GetUsernameFromJWT validates a JWT and returns the username stored in its id claim
+func GetUsernameFromJWT(tokenString string) (string, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(config.GetJwtKey()), nil
+	})
+	if err != nil || !token.Valid {
+		return "", fmt.Errorf("invalid token: %v", err)
+	}
+	username, ok := claims["id"].(string)
+	if !ok || username == "" {
+		return "", fmt.Errorf("token has no user id")
+	}
+	return username, nil
+}
+
+// UsernameFromRequest returns the user behind a request's AuthToken cookie. Falls back to "SSUI" when auth is disabled or no valid token is present.
+func UsernameFromRequest(r *http.Request) string {
+	cookie, err := r.Cookie("AuthToken")
+	if err != nil {
+		return "SSUI"
+	}
+	username, err := GetUsernameFromJWT(cookie.Value)
+	if err != nil {
+		return "SSUI"
+	}
+	return username
+}
@@ -0,0 +1,16 @@
+package security
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IsPathInsideRoot reports whether path is root or below it, after cleaning. Both must be absolute or both relative.
+func IsPathInsideRoot(path, root string) bool {
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator)) && !filepath.IsAbs(rel)
+}
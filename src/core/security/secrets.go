@@ -0,0 +1,132 @@
+// secrets.go
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+/*
+Secret Encryption at Rest
+- Encrypts secret values (e.g. secret runfile args) with AES-256-GCM before they are written to disk
+- The key is generated once and stored next to the config with 0600 permissions
+- Encrypted values are stored as "enc:v1:<base64 nonce+ciphertext>" so plain legacy values can be told apart
+- This protects against leaking secrets through shared runfiles, backups and screenshots, not against someone with full access to the SSUI folder
+*/
+
+const encryptedSecretPrefix = "enc:v1:"
+
+var (
+	secretKey   []byte
+	secretKeyMu sync.Mutex
+)
+
+// IsEncryptedSecret reports whether a stored value was produced by EncryptSecret
+func IsEncryptedSecret(value string) bool {
+	return strings.HasPrefix(value, encryptedSecretPrefix)
+}
+
+// EncryptSecret encrypts a plain value for storage on disk. Empty values stay empty.
+func EncryptSecret(plain string) (string, error) {
+	if plain == "" {
+		return "", nil
+	}
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedSecretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret returns the plain value of a stored secret. Values without the encryption prefix are returned as they are.
+func DecryptSecret(stored string) (string, error) {
+	if !IsEncryptedSecret(stored) {
+		return stored, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, encryptedSecretPrefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret: %w", err)
+	}
+	gcm, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("encrypted secret is too short")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret, was %s replaced? %w", config.GetSecretsKeyFilePath(), err)
+	}
+	return string(plain), nil
+}
+
+func secretCipher() (cipher.AEAD, error) {
+	key, err := loadOrCreateSecretKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func loadOrCreateSecretKey() ([]byte, error) {
+	secretKeyMu.Lock()
+	defer secretKeyMu.Unlock()
+
+	if secretKey != nil {
+		return secretKey, nil
+	}
+
+	path := config.GetSecretsKeyFilePath()
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("secrets key %s is corrupt: expected 32 bytes, got %d", path, len(key))
+		}
+		secretKey = key
+		return secretKey, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read secrets key: %w", err)
+	}
+
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate secrets key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create secrets key directory: %w", err)
+	}
+	if err := os.WriteFile(path, key, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write secrets key: %w", err)
+	}
+	logger.Security.Info("Generated a new key for secrets at rest: " + path)
+	secretKey = key
+	return secretKey, nil
+}
+
+// ResetSecretKey forgets the loaded key, the next use reads it from disk again. Used after the key file was replaced by an import.
+func ResetSecretKey() {
+	secretKeyMu.Lock()
+	defer secretKeyMu.Unlock()
+	secretKey = nil
+}
@@ -14,6 +14,7 @@ var (
 	WarnLogStreamManager    = NewSSEManager(config.GetMaxSSEConnections(), config.GetSSEMessageBufferSize())
 	ErrorLogStreamManager   = NewSSEManager(config.GetMaxSSEConnections(), config.GetSSEMessageBufferSize())
 	BackendLogStreamManager = NewSSEManager(config.GetMaxSSEConnections(), config.GetSSEMessageBufferSize())
+	SteamCMDProgressManager = NewSSEManager(config.GetMaxSSEConnections(), config.GetSSEMessageBufferSize())
 )
 
 // BroadcastConsoleOutput sends log to all connected console log clients
@@ -50,3 +51,8 @@ func BroadcastErrorLog(message string) {
 func BroadcastBackendLog(message string) {
 	BackendLogStreamManager.Broadcast(message)
 }
+
+// BroadcastSteamCMDProgress sends SteamCMD progress as JSON to all connected clients
+func BroadcastSteamCMDProgress(message string) {
+	SteamCMDProgressManager.Broadcast(message)
+}
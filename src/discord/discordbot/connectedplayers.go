@@ -33,6 +33,16 @@ func RemoveFromConnectedPlayers(steamID string, players map[string]string) {
 	sendAndEditMessageInConnectedPlayersChannel(config.GetConnectionListChannelID(), content)
 }
 
+// SetConnectedPlayers publishes a complete player list, used after the detector rebuilt its state from the log
+func SetConnectedPlayers(players map[string]string) {
+	if !config.GetIsDiscordEnabled() || config.DiscordSession == nil {
+		logger.Discord.Debug("Discord not enabled or session not initialized")
+		return
+	}
+	content := formatConnectedPlayers(players)
+	sendAndEditMessageInConnectedPlayersChannel(config.GetConnectionListChannelID(), content)
+}
+
 func sendAndEditMessageInConnectedPlayersChannel(channelID, message string) {
 	playersMutex.Lock()
 	defer playersMutex.Unlock()
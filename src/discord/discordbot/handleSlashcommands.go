@@ -6,9 +6,11 @@ import (
 
 	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/accessmgr"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/commandmgr"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/gamemgr"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/steamcmd"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/runfile"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -17,12 +19,15 @@ type commandHandler func(*discordgo.Session, *discordgo.InteractionCreate, Embed
 
 // Command handlers map
 var handlers = map[string]commandHandler{
-	"start":   handleStart,
-	"stop":    handleStop,
-	"status":  handleStatus,
-	"help":    handleHelp,
-	"update":  handleUpdate,
-	"command": handleCommand,
+	"start":        handleStart,
+	"stop":         handleStop,
+	"status":       handleStatus,
+	"help":         handleHelp,
+	"update":       handleUpdate,
+	"command":      handleCommand,
+	"bansteamid":   handleBanSteamID,
+	"unbansteamid": handleUnbanSteamID,
+	"setconfig":    handleSetConfig,
 }
 
 // Check channel and handle initial validation
@@ -91,7 +96,7 @@ func handleStatus(s *discordgo.Session, i *discordgo.InteractionCreate, data Emb
 func handleUpdate(s *discordgo.Session, i *discordgo.InteractionCreate, data EmbedData) error {
 	thinkingData := EmbedData{
 		Title:       "🎮 Gameserver Update",
-		Description: "The Backend is processing the gameserver update via SteamCMD. Please wait, this may take a while...",
+		Description: updateProgressHint(),
 		Color:       0xFFA500, // Orange color for in-progress
 	}
 
@@ -137,6 +142,7 @@ func handleHelp(s *discordgo.Session, i *discordgo.InteractionCreate, data Embed
 		{Name: "/bansteamid <SteamID>", Value: "Bans a player"},
 		{Name: "/unbansteamid <SteamID>", Value: "Unbans a player"},
 		{Name: "/command <command>", Value: "Sends a command to the gameserver console"},
+		{Name: "/setconfig <file> <key> <value>", Value: "Changes a single value in a gameserver config file"},
 		{Name: "/help", Value: "Shows this help"},
 	}
 	return respond(s, i, data)
@@ -161,3 +167,63 @@ func handleCommand(s *discordgo.Session, i *discordgo.InteractionCreate, data Em
 	}
 	return nil
 }
+
+func handleBanSteamID(s *discordgo.Session, i *discordgo.InteractionCreate, data EmbedData) error {
+	steamID := i.ApplicationCommandData().Options[0].StringValue()
+	if _, err := accessmgr.BanPlayer(steamID, "", "Banned via Discord", "discord:"+interactionUsername(i), 0); err != nil {
+		data.Fields = []EmbedField{{Name: "Error", Value: err.Error(), Inline: true}}
+		return respond(s, i, data)
+	}
+	data.Title, data.Description, data.Color = "Player Banned", "SteamID "+steamID+" has been banned.", 0x00FF00
+	data.Fields = []EmbedField{{Name: "Note", Value: "Depending on the game, a server restart may be needed to take effect.", Inline: true}}
+	return respond(s, i, data)
+}
+
+func handleUnbanSteamID(s *discordgo.Session, i *discordgo.InteractionCreate, data EmbedData) error {
+	steamID := i.ApplicationCommandData().Options[0].StringValue()
+	if err := accessmgr.UnbanPlayer(steamID); err != nil {
+		data.Fields = []EmbedField{{Name: "Error", Value: err.Error(), Inline: true}}
+		return respond(s, i, data)
+	}
+	data.Title, data.Description, data.Color = "Player Unbanned", "SteamID "+steamID+" has been unbanned.", 0x00FF00
+	data.Fields = []EmbedField{{Name: "Note", Value: "Depending on the game, a server restart may be needed to take effect.", Inline: true}}
+	return respond(s, i, data)
+}
+
+func handleSetConfig(s *discordgo.Session, i *discordgo.InteractionCreate, data EmbedData) error {
+	options := i.ApplicationCommandData().Options
+	file, key, value := options[0].StringValue(), options[1].StringValue(), options[2].StringValue()
+	previous, err := runfile.SetFileValue(file, key, value, "discord:"+interactionUsername(i))
+	if err != nil {
+		data.Fields = []EmbedField{{Name: "Error", Value: err.Error(), Inline: true}}
+		return respond(s, i, data)
+	}
+	logger.Discord.Info(fmt.Sprintf("%s set %s in %s via Discord", interactionUsername(i), key, file))
+	// the reply is visible to the whole channel, a value that replaced a secret is a secret as well
+	shownPrevious, shownValue := logger.Redact(previous.Value), logger.Redact(value)
+	if shownPrevious != previous.Value {
+		shownValue = logger.RedactedValue
+	}
+	data.Title, data.Description, data.Color = "Config Changed", fmt.Sprintf("%s in %s changed from `%s` to `%s`.", key, file, shownPrevious, shownValue), 0x00FF00
+	data.Fields = []EmbedField{{Name: "Note", Value: "A server restart is needed for the gameserver to read the change.", Inline: true}}
+	return respond(s, i, data)
+}
+
+// interactionUsername returns the Discord username behind an interaction
+func interactionUsername(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.Username
+	}
+	if i.User != nil {
+		return i.User.Username
+	}
+	return "unknown"
+}
+
+// updateProgressHint points the /update reply at the live SteamCMD progress message
+func updateProgressHint() string {
+	if channelID := config.GetStatusChannelID(); channelID != "" {
+		return "The Backend is updating the gameserver via SteamCMD. Follow the live progress in <#" + channelID + ">, this message is updated when it's done."
+	}
+	return "The Backend is updating the gameserver via SteamCMD, this message is updated when it's done."
+}
@@ -51,6 +51,7 @@ func InitializeDiscordBot() {
 	config.DiscordSession.AddHandler(listenToDiscordReactions)
 	config.DiscordSession.AddHandler(listenToSlashCommands)
 	registerSlashCommands(config.DiscordSession)
+	registerSteamCMDProgress()
 
 	logger.Discord.Info("Bot is now running.")
 	SendMessageToStatusChannel("🤖 SSUI Version " + config.GetVersion() + " connected to Discord.")
@@ -30,7 +30,7 @@ func registerSlashCommands(s *discordgo.Session) {
 		},
 		{
 			Name:        "update",
-			Description: "Update the gameserver via SteamCMD. Feedback will take a while, please be patient.",
+			Description: "Update the gameserver via SteamCMD. Progress is posted live to the status channel.",
 		},
 		{
 			Name:        "command",
@@ -92,6 +92,30 @@ func registerSlashCommands(s *discordgo.Session) {
 				},
 			},
 		},
+		{
+			Name:        "setconfig",
+			Description: "Changes a single value in a gameserver config file. Needs a Server restart to take effect.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "file",
+					Description: "File name as listed in the SSUI file editor",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "key",
+					Description: "Key path, e.g. Server.MaxPlayers",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "value",
+					Description: "New value, must match the type of the old one",
+					Required:    true,
+				},
+			},
+		},
 	}
 
 	logger.Discord.Info("Checking and registering slash commands with Discord...")
@@ -0,0 +1,124 @@
+package discordbot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamcmd"
+)
+
+// progressEditInterval limits how often the SteamCMD progress message is edited, Discord rate limits edits
+const progressEditInterval = 5 * time.Second
+
+var (
+	progressOnce      sync.Once
+	progressMutex     sync.Mutex
+	latestProgress    steamcmd.Progress
+	progressKick      = make(chan struct{}, 1)
+	progressMessageID string    // only touched by the progress worker
+	progressRunStart  time.Time // StartedAt of the run progressMessageID belongs to
+)
+
+// registerSteamCMDProgress follows SteamCMD's progress once, session reconnects keep the same listener
+func registerSteamCMDProgress() {
+	progressOnce.Do(func() {
+		steamcmd.RegisterProgressListener(onSteamCMDProgress)
+		go steamCMDProgressWorker()
+	})
+}
+
+// onSteamCMDProgress keeps the latest progress and wakes the worker without blocking SteamCMD's output
+func onSteamCMDProgress(progress steamcmd.Progress) {
+	progressMutex.Lock()
+	latestProgress = progress
+	progressMutex.Unlock()
+	select {
+	case progressKick <- struct{}{}:
+	default:
+	}
+}
+
+func steamCMDProgressWorker() {
+	for range progressKick {
+		progressMutex.Lock()
+		progress := latestProgress
+		progressMutex.Unlock()
+		postSteamCMDProgress(progress)
+		if progress.Running {
+			time.Sleep(progressEditInterval)
+		}
+	}
+}
+
+// postSteamCMDProgress sends one message per SteamCMD run to the status channel and edits it afterwards
+func postSteamCMDProgress(progress steamcmd.Progress) {
+	channelID := config.GetStatusChannelID()
+	if !config.GetIsDiscordEnabled() || config.DiscordSession == nil || channelID == "" {
+		return
+	}
+	message := formatSteamCMDProgress(progress)
+	if !progress.StartedAt.Equal(progressRunStart) {
+		progressRunStart = progress.StartedAt
+		progressMessageID = ""
+	}
+
+	if progressMessageID != "" {
+		_, err := config.DiscordSession.ChannelMessageEdit(channelID, progressMessageID, message)
+		if err == nil {
+			return
+		}
+		logger.Discord.Error("Error editing SteamCMD progress message in channel " + channelID + ": " + err.Error())
+	}
+	msg, err := config.DiscordSession.ChannelMessageSend(channelID, message)
+	if err != nil {
+		logger.Discord.Error("Error sending SteamCMD progress message to channel " + channelID + ": " + err.Error())
+		return
+	}
+	progressMessageID = msg.ID
+}
+
+func formatSteamCMDProgress(progress steamcmd.Progress) string {
+	title := "Gameserver update"
+	if progress.Operation == "workshop" {
+		title = "Workshop download"
+	}
+
+	switch progress.State {
+	case steamcmd.ProgressDone:
+		return fmt.Sprintf("✅ **%s** finished in %s.", title, progress.FinishedAt.Sub(progress.StartedAt).Round(time.Second))
+	case steamcmd.ProgressFailed:
+		return fmt.Sprintf("❌ **%s** failed: %s", title, progress.Error)
+	case steamcmd.ProgressSteamGuard:
+		return fmt.Sprintf("🔑 **%s** is waiting for a Steam Guard code, submit it in the UI.", title)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔄 **%s** — %s", title, strings.ReplaceAll(progress.State, "_", " ")))
+	if progress.Item != "" {
+		sb.WriteString(" item " + progress.Item)
+	}
+	if progress.BytesTotal > 0 {
+		filled := int(progress.Percent / 10)
+		filled = min(max(filled, 0), 10)
+		sb.WriteString(fmt.Sprintf("\n`[%s%s]` %.1f%% (%s / %s)", strings.Repeat("█", filled), strings.Repeat("░", 10-filled),
+			progress.Percent, formatProgressBytes(progress.BytesDone), formatProgressBytes(progress.BytesTotal)))
+	}
+	return sb.String()
+}
+
+func formatProgressBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
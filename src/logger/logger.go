@@ -179,6 +179,9 @@ func (l *Logger) log(entry logEntry) {
 	// Initialize global channels if not already done
 	l.Init()
 
+	// Never let registered secrets reach the console, SSE streams or log files
+	entry.message = Redact(entry.message)
+
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	// Handle CLEAN severity separately
 	if entry.severity == CLEAN {
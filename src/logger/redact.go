@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redaction placeholder for secret values
+const RedactedValue = "********"
+
+// Secrets shorter than this are not redacted from free text, they would match all over the place.
+// Callers that know exactly where a short secret is (like the start command) mask it themselves.
+const minRedactLength = 4
+
+var (
+	redactMu      sync.RWMutex
+	redactSources = make(map[string][]string) // owner -> secret values
+	redactValues  []string                    // all values, longest first
+)
+
+// SetRedactions replaces the secret values registered by owner (e.g. "runfile"). They are masked in every log line from then on.
+func SetRedactions(owner string, secrets []string) {
+	redactMu.Lock()
+	defer redactMu.Unlock()
+
+	var kept []string
+	for _, secret := range secrets {
+		if len(secret) >= minRedactLength {
+			kept = append(kept, secret)
+		}
+	}
+	if len(kept) == 0 {
+		delete(redactSources, owner)
+	} else {
+		redactSources[owner] = kept
+	}
+
+	redactValues = redactValues[:0]
+	for _, values := range redactSources {
+		redactValues = append(redactValues, values...)
+	}
+	// longest first, so a secret containing another one is masked as a whole
+	sort.Slice(redactValues, func(i, j int) bool {
+		return len(redactValues[i]) > len(redactValues[j])
+	})
+}
+
+// Redact masks all registered secret values in text
+func Redact(text string) string {
+	redactMu.RLock()
+	defer redactMu.RUnlock()
+
+	for _, secret := range redactValues {
+		if strings.Contains(text, secret) {
+			text = strings.ReplaceAll(text, secret, RedactedValue)
+		}
+	}
+	return text
+}
@@ -0,0 +1,597 @@
+// access.go
+package accessmgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/runfile"
+)
+
+/*
+Player Access Control
+- Maintains bans (with reason, issuer and optional expiry), a whitelist and an admin list
+- Persists the lists to a JSON file in the SSUI config folder
+- Syncs the lists to the gameserver's own files, as declared in the runfile's access_lists section
+- Merges entries from the gameserver's own files on every init before writing them, so entries added
+  in-game or present before access_lists was declared (or the runfile was switched) are kept
+- Lifts expired temporary bans automatically
+*/
+
+const (
+	ListBans      = "bans"
+	ListWhitelist = "whitelist"
+	ListAdmins    = "admins"
+)
+
+var steamIDRegex = regexp.MustCompile(`^\d{5,20}$`)
+
+// Ban is a single banned player
+type Ban struct {
+	SteamID   string     `json:"steamID"`
+	Username  string     `json:"username,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	IssuedBy  string     `json:"issuedBy"`
+	IssuedAt  time.Time  `json:"issuedAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` // nil for permanent bans
+}
+
+// Entry is a single whitelisted player or admin
+type Entry struct {
+	SteamID  string    `json:"steamID"`
+	Username string    `json:"username,omitempty"`
+	Note     string    `json:"note,omitempty"`
+	AddedBy  string    `json:"addedBy"`
+	AddedAt  time.Time `json:"addedAt"`
+}
+
+type accessFile struct {
+	Bans      []Ban   `json:"bans"`
+	Whitelist []Entry `json:"whitelist"`
+	Admins    []Entry `json:"admins"`
+}
+
+var (
+	accessMu    sync.Mutex
+	access      = accessFile{Bans: []Ban{}, Whitelist: []Entry{}, Admins: []Entry{}}
+	expiryOnce  sync.Once
+	accessReady bool
+)
+
+// ValidateSteamID checks that a SteamID looks like a numeric Steam ID
+func ValidateSteamID(steamID string) error {
+	if !steamIDRegex.MatchString(steamID) {
+		return fmt.Errorf("invalid SteamID %q: must be numeric", steamID)
+	}
+	return nil
+}
+
+// InitAccessLists loads the stored lists, syncs them to the gameserver files and starts the ban expiry loop.
+// Safe to call again after a runfile reload, the loop is only started once.
+func InitAccessLists() {
+	if err := loadAccessLists(); err != nil {
+		logger.Security.Error("Failed to load player access lists: " + err.Error())
+	}
+	if err := SyncGameFiles(); err != nil {
+		logger.Security.Warn("Failed to sync player access lists to gameserver files: " + err.Error())
+	}
+	expiryOnce.Do(func() {
+		go expiryLoop()
+	})
+}
+
+func loadAccessLists() error {
+	accessMu.Lock()
+	defer accessMu.Unlock()
+
+	data, err := os.ReadFile(config.GetPlayerAccessFilePath())
+	if os.IsNotExist(err) {
+		// First start, adopt whatever the gameserver already has
+		access = accessFile{Bans: []Ban{}, Whitelist: []Entry{}, Admins: []Entry{}}
+		importGameFilesLocked()
+		accessReady = true
+		return saveAccessListsLocked()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read access lists: %w", err)
+	}
+
+	var loaded accessFile
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to decode access lists: %w", err)
+	}
+	if loaded.Bans == nil {
+		loaded.Bans = []Ban{}
+	}
+	if loaded.Whitelist == nil {
+		loaded.Whitelist = []Entry{}
+	}
+	if loaded.Admins == nil {
+		loaded.Admins = []Entry{}
+	}
+	access = loaded
+	accessReady = true
+	if importGameFilesLocked() > 0 {
+		return saveAccessListsLocked()
+	}
+	return nil
+}
+
+// saveAccessListsLocked writes the lists atomically. Must be called with accessMu held.
+func saveAccessListsLocked() error {
+	path := config.GetPlayerAccessFilePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create access list directory: %w", err)
+	}
+	data, err := json.MarshalIndent(access, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode access lists: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write access lists: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace access lists: %w", err)
+	}
+	return nil
+}
+
+// commitLocked persists the lists and pushes them to the gameserver files. Must be called with accessMu held.
+func commitLocked() error {
+	if !accessReady {
+		return fmt.Errorf("player access lists are not loaded, refusing to overwrite them")
+	}
+	if err := saveAccessListsLocked(); err != nil {
+		return err
+	}
+	if err := syncGameFilesLocked(); err != nil {
+		logger.Security.Warn("Failed to sync player access lists to gameserver files: " + err.Error())
+	}
+	return nil
+}
+
+// BanPlayer bans a player. A zero duration bans permanently. Banning an already banned player updates the ban.
+func BanPlayer(steamID, username, reason, issuedBy string, duration time.Duration) (Ban, error) {
+	if err := ValidateSteamID(steamID); err != nil {
+		return Ban{}, err
+	}
+	if duration < 0 {
+		return Ban{}, fmt.Errorf("ban duration cannot be negative")
+	}
+
+	accessMu.Lock()
+	defer accessMu.Unlock()
+
+	ban := Ban{
+		SteamID:  steamID,
+		Username: username,
+		Reason:   reason,
+		IssuedBy: issuedBy,
+		IssuedAt: time.Now(),
+	}
+	if duration > 0 {
+		expiresAt := ban.IssuedAt.Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	replaced := false
+	for i := range access.Bans {
+		if access.Bans[i].SteamID == steamID {
+			if ban.Username == "" {
+				ban.Username = access.Bans[i].Username
+			}
+			access.Bans[i] = ban
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		access.Bans = append(access.Bans, ban)
+	}
+
+	if err := commitLocked(); err != nil {
+		return Ban{}, err
+	}
+	if ban.ExpiresAt != nil {
+		logger.Security.Info(fmt.Sprintf("Banned player steamID=%s until=%s by=%s reason=%s", steamID, ban.ExpiresAt.Format(time.RFC3339), issuedBy, reason))
+	} else {
+		logger.Security.Info(fmt.Sprintf("Banned player steamID=%s permanently by=%s reason=%s", steamID, issuedBy, reason))
+	}
+	return ban, nil
+}
+
+// UnbanPlayer lifts a ban
+func UnbanPlayer(steamID string) error {
+	accessMu.Lock()
+	defer accessMu.Unlock()
+
+	for i := range access.Bans {
+		if access.Bans[i].SteamID == steamID {
+			access.Bans = append(access.Bans[:i], access.Bans[i+1:]...)
+			if err := commitLocked(); err != nil {
+				return err
+			}
+			logger.Security.Info("Unbanned player steamID=" + steamID)
+			return nil
+		}
+	}
+	return fmt.Errorf("player %s is not banned", steamID)
+}
+
+// GetBans returns all active bans, sorted by issue date
+func GetBans() []Ban {
+	accessMu.Lock()
+	defer accessMu.Unlock()
+
+	bans := activeBansLocked(time.Now())
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].IssuedAt.Before(bans[j].IssuedAt)
+	})
+	return bans
+}
+
+// IsBanned reports whether a player is currently banned
+func IsBanned(steamID string) bool {
+	accessMu.Lock()
+	defer accessMu.Unlock()
+
+	now := time.Now()
+	for _, ban := range access.Bans {
+		if ban.SteamID == steamID && ban.activeAt(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b Ban) activeAt(now time.Time) bool {
+	return b.ExpiresAt == nil || b.ExpiresAt.After(now)
+}
+
+// activeBansLocked returns a copy of the bans that haven't expired yet. Must be called with accessMu held.
+func activeBansLocked(now time.Time) []Ban {
+	bans := []Ban{}
+	for _, ban := range access.Bans {
+		if ban.activeAt(now) {
+			bans = append(bans, ban)
+		}
+	}
+	return bans
+}
+
+// listLocked returns a pointer to the named entry list. Must be called with accessMu held.
+func listLocked(list string) (*[]Entry, error) {
+	switch list {
+	case ListWhitelist:
+		return &access.Whitelist, nil
+	case ListAdmins:
+		return &access.Admins, nil
+	default:
+		return nil, fmt.Errorf("unknown access list %q", list)
+	}
+}
+
+// AddEntry adds a player to the whitelist or admin list, or updates the existing entry
+func AddEntry(list, steamID, username, note, addedBy string) (Entry, error) {
+	if err := ValidateSteamID(steamID); err != nil {
+		return Entry{}, err
+	}
+
+	accessMu.Lock()
+	defer accessMu.Unlock()
+
+	entries, err := listLocked(list)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry := Entry{SteamID: steamID, Username: username, Note: note, AddedBy: addedBy, AddedAt: time.Now()}
+	replaced := false
+	for i := range *entries {
+		if (*entries)[i].SteamID == steamID {
+			entry.AddedAt = (*entries)[i].AddedAt
+			(*entries)[i] = entry
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		*entries = append(*entries, entry)
+	}
+
+	if err := commitLocked(); err != nil {
+		return Entry{}, err
+	}
+	logger.Security.Info(fmt.Sprintf("Added player to %s steamID=%s by=%s", list, steamID, addedBy))
+	return entry, nil
+}
+
+// RemoveEntry removes a player from the whitelist or admin list
+func RemoveEntry(list, steamID string) error {
+	accessMu.Lock()
+	defer accessMu.Unlock()
+
+	entries, err := listLocked(list)
+	if err != nil {
+		return err
+	}
+	for i := range *entries {
+		if (*entries)[i].SteamID == steamID {
+			*entries = append((*entries)[:i], (*entries)[i+1:]...)
+			if err := commitLocked(); err != nil {
+				return err
+			}
+			logger.Security.Info(fmt.Sprintf("Removed player from %s steamID=%s", list, steamID))
+			return nil
+		}
+	}
+	return fmt.Errorf("player %s is not on the %s list", steamID, list)
+}
+
+// GetEntries returns a copy of the whitelist or admin list
+func GetEntries(list string) ([]Entry, error) {
+	accessMu.Lock()
+	defer accessMu.Unlock()
+
+	entries, err := listLocked(list)
+	if err != nil {
+		return nil, err
+	}
+	return append([]Entry{}, *entries...), nil
+}
+
+// expiryLoop lifts expired temporary bans once a minute
+func expiryLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		LiftExpiredBans()
+	}
+}
+
+// LiftExpiredBans removes all bans whose expiry has passed and returns how many were lifted
+func LiftExpiredBans() int {
+	accessMu.Lock()
+	defer accessMu.Unlock()
+
+	if !accessReady {
+		return 0
+	}
+
+	now := time.Now()
+	kept := access.Bans[:0]
+	lifted := 0
+	for _, ban := range access.Bans {
+		if !ban.activeAt(now) {
+			logger.Security.Info(fmt.Sprintf("Temporary ban expired, lifting ban steamID=%s", ban.SteamID))
+			lifted++
+			continue
+		}
+		kept = append(kept, ban)
+	}
+	access.Bans = kept
+	if lifted > 0 {
+		if err := commitLocked(); err != nil {
+			logger.Security.Error("Failed to save access lists after lifting expired bans: " + err.Error())
+		}
+	}
+	return lifted
+}
+
+// SyncGameFiles writes all access lists to the gameserver files declared in the runfile
+func SyncGameFiles() error {
+	accessMu.Lock()
+	defer accessMu.Unlock()
+	return syncGameFilesLocked()
+}
+
+// syncGameFilesLocked must be called with accessMu held
+func syncGameFilesLocked() error {
+	declared := runfile.GetAccessListFiles()
+	if declared == nil {
+		return nil
+	}
+
+	var errs []string
+	if declared.Bans != nil {
+		// expired bans are lifted by the expiry loop within a minute, they must not reach the gameserver meanwhile
+		ids := []string{}
+		for _, ban := range activeBansLocked(time.Now()) {
+			ids = append(ids, ban.SteamID)
+		}
+		if err := writeGameList(declared.Bans, ids); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if declared.Whitelist != nil {
+		if err := writeGameList(declared.Whitelist, entryIDs(access.Whitelist)); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if declared.Admins != nil {
+		if err := writeGameList(declared.Admins, entryIDs(access.Admins)); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// importGameFilesLocked merges SteamIDs from existing gameserver files into the lists and returns how many were added.
+// Must be called with accessMu held.
+func importGameFilesLocked() int {
+	declared := runfile.GetAccessListFiles()
+	if declared == nil {
+		return 0
+	}
+	now := time.Now()
+	bans, whitelist, admins := 0, 0, 0
+	if declared.Bans != nil {
+		ids, _ := readGameList(declared.Bans)
+		known := map[string]bool{}
+		for _, ban := range access.Bans {
+			known[ban.SteamID] = true
+		}
+		for _, id := range ids {
+			if !known[id] {
+				access.Bans = append(access.Bans, Ban{SteamID: id, IssuedBy: "imported", IssuedAt: now})
+				bans++
+			}
+		}
+	}
+	if declared.Whitelist != nil {
+		whitelist = mergeGameList(declared.Whitelist, &access.Whitelist, now)
+	}
+	if declared.Admins != nil {
+		admins = mergeGameList(declared.Admins, &access.Admins, now)
+	}
+	if bans+whitelist+admins > 0 {
+		logger.Security.Info(fmt.Sprintf("Imported entries from the gameserver access lists: bans=%d whitelist=%d admins=%d", bans, whitelist, admins))
+	}
+	return bans + whitelist + admins
+}
+
+// mergeGameList adds the SteamIDs of a gameserver file that are missing from entries and returns how many were added
+func mergeGameList(list *runfile.AccessListFile, entries *[]Entry, now time.Time) int {
+	ids, _ := readGameList(list)
+	known := map[string]bool{}
+	for _, entry := range *entries {
+		known[entry.SteamID] = true
+	}
+	added := 0
+	for _, id := range ids {
+		if !known[id] {
+			*entries = append(*entries, Entry{SteamID: id, AddedBy: "imported", AddedAt: now})
+			added++
+		}
+	}
+	return added
+}
+
+func entryIDs(entries []Entry) []string {
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.SteamID)
+	}
+	return ids
+}
+
+// gameListPath resolves a declared access list file inside the gameserver directory. Symlinks are followed,
+// the file or a directory on its way may not exist yet, so the longest existing part is resolved.
+func gameListPath(list *runfile.AccessListFile) (string, error) {
+	root, err := filepath.Abs(config.GetRunfileIdentifier())
+	if err != nil {
+		return "", err
+	}
+	if real, err := filepath.EvalSymlinks(root); err == nil {
+		root = real
+	}
+	escapes := fmt.Errorf("access list path %s escapes the gameserver directory", list.Filepath)
+	path := filepath.Join(root, filepath.Clean(list.Filepath))
+	if !security.IsPathInsideRoot(path, root) {
+		return "", escapes
+	}
+	existing, rest := path, ""
+	for {
+		real, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			path = filepath.Join(real, rest)
+			break
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = filepath.Dir(existing)
+	}
+	if !security.IsPathInsideRoot(path, root) {
+		return "", escapes
+	}
+	return path, nil
+}
+
+func writeGameList(list *runfile.AccessListFile, ids []string) error {
+	path, err := gameListPath(list)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	switch list.Format {
+	case "lines":
+		data = []byte(strings.Join(ids, "\n"))
+		if len(ids) > 0 {
+			data = append(data, '\n')
+		}
+	case "comma":
+		data = []byte(strings.Join(ids, ","))
+	case "json":
+		data, err = json.MarshalIndent(ids, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", list.Filepath, err)
+		}
+	default:
+		return fmt.Errorf("unsupported access list format %s", list.Format)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", list.Filepath, err)
+	}
+	tmpPath := path + ".ssui.tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", list.Filepath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace %s: %w", list.Filepath, err)
+	}
+	return nil
+}
+
+func readGameList(list *runfile.AccessListFile) ([]string, error) {
+	path, err := gameListPath(list)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []string
+	switch list.Format {
+	case "json":
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", list.Filepath, err)
+		}
+	default:
+		raw = strings.FieldsFunc(string(data), func(r rune) bool {
+			return r == ',' || r == '\n' || r == '\r' || r == ' ' || r == '\t'
+		})
+	}
+
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, id := range raw {
+		id = strings.TrimSpace(id)
+		if ValidateSteamID(id) == nil && !seen[id] {
+			ids = append(ids, id)
+			seen[id] = true
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,212 @@
+// bundlemgr.go
+package bundlemgr
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
+)
+
+/*
+Instance Bundles
+- A bundle is a zip archive that moves an SSUI instance to another host: config, custom detections, the active runfile
+  with its overrides, presets (which hold the preset schedules) and Workshop items, registered plugins, the TLS certificate and optionally
+  the latest world backup
+- manifest.json lists every file with its size and SHA-256, an import checks all of them before anything is written
+- Secrets (secrets key, TLS key, JWT key, Discord token, Steam password) are only exported with a passphrase, sealed in secrets.enc.
+  Without them, values encrypted with the secrets key can't be read on the new host and are cleared from the runfiles,
+  and the host keeps its own JWT key, Discord token and Steam password
+- Plugins are only imported if they match the signed digest of the plugin gallery, like a gallery download
+- Files an import replaces are copied to SSUI/import-backups/<timestamp> first
+*/
+
+const (
+	bundleFormatVersion = 1
+	manifestName        = "manifest.json"
+	secretsName         = "secrets.enc"
+)
+
+// Kinds of bundle files, each kind has a fixed destination on import
+const (
+	KindConfig     = "config"
+	KindDetections = "detections"
+	KindRunfile    = "runfile"
+	KindPlugin     = "plugin"
+	KindTLSCert    = "tls"
+	KindWorld      = "world"
+)
+
+// Paths of the files that only travel inside secrets.enc
+const (
+	secretsKeyPath = "secrets/secrets.key"
+	tlsKeyPath     = "secrets/key.pem"
+)
+
+// runfileNamePattern matches the runfile and its companion files
+var runfileNamePattern = regexp.MustCompile(`^run[^/\\]+\.(ssui|overrides\.json|presets\.json|workshop\.json)$`)
+
+// Manifest describes the content of a bundle
+type Manifest struct {
+	FormatVersion     int           `json:"format_version"`
+	SSUIVersion       string        `json:"ssui_version"`
+	CreatedAt         time.Time     `json:"created_at"`
+	CreatedBy         string        `json:"created_by,omitempty"`
+	BackendName       string        `json:"backend_name,omitempty"`
+	RunfileIdentifier string        `json:"runfile_identifier"`
+	Files             []FileEntry   `json:"files"`
+	Secrets           *SecretsEntry `json:"secrets,omitempty"` // nil if the bundle was exported without a passphrase
+	WorldBackup       string        `json:"world_backup,omitempty"`
+}
+
+// FileEntry is a file of the bundle
+type FileEntry struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// secretValues is the sealed content of secrets.enc
+type secretValues struct {
+	Files  map[string][]byte `json:"files"`
+	Config map[string]string `json:"config"` // config.json keys whose values were removed from the exported config
+}
+
+// pluginVerifier checks a bundled plugin against the signed digest of the plugin gallery, see RegisterPluginVerifier
+var (
+	pluginVerifierMu sync.Mutex
+	pluginVerifier   func(filename string, digest []byte) error
+)
+
+// RegisterPluginVerifier sets the check bundled plugins have to pass, the gallery registers it. Without one, bundled plugins are skipped.
+func RegisterPluginVerifier(verify func(filename string, digest []byte) error) {
+	pluginVerifierMu.Lock()
+	defer pluginVerifierMu.Unlock()
+	pluginVerifier = verify
+}
+
+func verifyBundledPlugin(filename, sha256Hex string) error {
+	pluginVerifierMu.Lock()
+	verify := pluginVerifier
+	pluginVerifierMu.Unlock()
+	if verify == nil {
+		return fmt.Errorf("plugins can't be verified")
+	}
+	digest, err := hex.DecodeString(sha256Hex)
+	if err != nil {
+		return fmt.Errorf("invalid digest: %w", err)
+	}
+	return verify(filename, digest)
+}
+
+// secretConfigKeys are the config.json keys that are exported only inside secrets.enc
+var secretConfigKeys = []string{"JwtKey", "discordToken", "SteamPassword"}
+
+// runfileFiles returns the runfile and its companion files for an identifier
+func runfileFiles(identifier string) []string {
+	return []string{
+		fmt.Sprintf("run%s.ssui", identifier),
+		fmt.Sprintf("run%s.overrides.json", identifier),
+		fmt.Sprintf("run%s.presets.json", identifier),
+		fmt.Sprintf("run%s.workshop.json", identifier),
+	}
+}
+
+// clearEncryptedValues empties every string that was encrypted with the secrets key, data is returned unchanged if there is none
+func clearEncryptedValues(data []byte) ([]byte, int, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+	cleared := 0
+	var walk func(v any) any
+	walk = func(v any) any {
+		switch value := v.(type) {
+		case map[string]any:
+			for k, child := range value {
+				value[k] = walk(child)
+			}
+		case []any:
+			for i, child := range value {
+				value[i] = walk(child)
+			}
+		case string:
+			if security.IsEncryptedSecret(value) {
+				cleared++
+				return ""
+			}
+		}
+		return v
+	}
+	doc = walk(doc)
+	if cleared == 0 {
+		return data, 0, nil
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	return out, cleared, err
+}
+
+// latestWorldBackup returns the name of the newest backup in the backup store
+func latestWorldBackup() (string, error) {
+	entries, err := os.ReadDir(config.GetBackupsStoreDir())
+	if err != nil {
+		return "", fmt.Errorf("failed to read backup directory: %w", err)
+	}
+	var latest string
+	var latestTime time.Time
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "backup_") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if latest == "" || info.ModTime().After(latestTime) {
+			latest, latestTime = entry.Name(), info.ModTime()
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("there is no world backup yet")
+	}
+	return latest, nil
+}
+
+// writeFileAtomic replaces a file through a temporary file in the same directory
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
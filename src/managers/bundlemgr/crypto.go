@@ -0,0 +1,110 @@
+// crypto.go
+package bundlemgr
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrWrongPassphrase is returned when secrets.enc can't be opened with the given passphrase
+var ErrWrongPassphrase = errors.New("wrong passphrase for the bundle secrets")
+
+// minPassphraseLength keeps the sealed secrets from being guessed offline too easily
+const minPassphraseLength = 8
+
+// SecretsEntry describes how secrets.enc was sealed
+type SecretsEntry struct {
+	KDF    string `json:"kdf"` // scrypt
+	Salt   string `json:"salt"`
+	N      int    `json:"n"`
+	R      int    `json:"r"`
+	P      int    `json:"p"`
+	SHA256 string `json:"sha256"`
+}
+
+// secretsAAD binds the ciphertext to the bundle format
+var secretsAAD = []byte("ssui-bundle-secrets-v1")
+
+func bundleCipher(passphrase string, entry *SecretsEntry) (cipher.AEAD, error) {
+	if entry.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported key derivation %q", entry.KDF)
+	}
+	// the parameters come from the bundle, absurd ones would exhaust memory
+	if entry.N > 1<<20 || entry.R > 16 || entry.P > 4 {
+		return nil, fmt.Errorf("key derivation parameters are out of range")
+	}
+	salt, err := base64.StdEncoding.DecodeString(entry.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, entry.N, entry.R, entry.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealSecrets encrypts the secret values with a key derived from passphrase
+func sealSecrets(passphrase string, values *secretValues) (*SecretsEntry, []byte, error) {
+	if len(passphrase) < minPassphraseLength {
+		return nil, nil, fmt.Errorf("passphrase must be at least %d characters", minPassphraseLength)
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	entry := &SecretsEntry{KDF: "scrypt", Salt: base64.StdEncoding.EncodeToString(salt), N: 1 << 15, R: 8, P: 1}
+	gcm, err := bundleCipher(passphrase, entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	plain, err := json.Marshal(values)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, plain, secretsAAD)
+	digest := sha256.Sum256(sealed)
+	entry.SHA256 = hex.EncodeToString(digest[:])
+	return entry, sealed, nil
+}
+
+// openSecrets decrypts secrets.enc
+func openSecrets(passphrase string, entry *SecretsEntry, sealed []byte) (*secretValues, error) {
+	digest := sha256.Sum256(sealed)
+	if hex.EncodeToString(digest[:]) != entry.SHA256 {
+		return nil, fmt.Errorf("%s does not match its checksum", secretsName)
+	}
+	gcm, err := bundleCipher(passphrase, entry)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%s is too short", secretsName)
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], secretsAAD)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	var values secretValues
+	if err := json.Unmarshal(plain, &values); err != nil {
+		return nil, fmt.Errorf("%s is corrupt: %w", secretsName, err)
+	}
+	return &values, nil
+}
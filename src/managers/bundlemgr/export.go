@@ -0,0 +1,250 @@
+// export.go
+package bundlemgr
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+// ExportOptions controls what goes into a bundle
+type ExportOptions struct {
+	Passphrase   string // seals the secrets into the bundle, without it they are left out
+	IncludeWorld bool   // adds the latest world backup
+	User         string
+}
+
+type exporter struct {
+	archive  *zip.Writer
+	manifest *Manifest
+}
+
+// add writes a file into the archive and records it in the manifest
+func (e *exporter) add(bundlePath, kind string, data []byte) error {
+	header := &zip.FileHeader{Name: bundlePath, Method: zip.Deflate, Modified: time.Now()}
+	writer, err := e.archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	digest := sha256.Sum256(data)
+	e.manifest.Files = append(e.manifest.Files, FileEntry{Path: bundlePath, Kind: kind, Size: int64(len(data)), SHA256: hex.EncodeToString(digest[:])})
+	return nil
+}
+
+// addFile streams a file from disk into the archive, used for plugins and world backups which can be large
+func (e *exporter) addFile(bundlePath, kind, source string) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer, err := e.archive.CreateHeader(&zip.FileHeader{Name: bundlePath, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(writer, hash), file)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", source, err)
+	}
+	e.manifest.Files = append(e.manifest.Files, FileEntry{Path: bundlePath, Kind: kind, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))})
+	return nil
+}
+
+// Export writes a bundle of this instance to w
+func Export(w io.Writer, opts ExportOptions) (*Manifest, error) {
+	sealing := opts.Passphrase != ""
+	if sealing && len(opts.Passphrase) < minPassphraseLength {
+		return nil, fmt.Errorf("passphrase must be at least %d characters", minPassphraseLength)
+	}
+	identifier := config.GetRunfileIdentifier()
+	e := &exporter{
+		archive: zip.NewWriter(w),
+		manifest: &Manifest{
+			FormatVersion:     bundleFormatVersion,
+			SSUIVersion:       config.GetVersion(),
+			CreatedAt:         time.Now().UTC(),
+			CreatedBy:         opts.User,
+			BackendName:       config.GetBackendName(),
+			RunfileIdentifier: identifier,
+		},
+	}
+	secrets := &secretValues{Files: make(map[string][]byte), Config: make(map[string]string)}
+
+	// Config, with the secret values moved out
+	configData, err := os.ReadFile(config.GetConfigPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var configDoc map[string]any
+	if err := json.Unmarshal(configData, &configDoc); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	for _, key := range secretConfigKeys {
+		if value, ok := configDoc[key].(string); ok && value != "" {
+			secrets.Config[key] = value
+			configDoc[key] = ""
+		}
+	}
+	if configData, err = json.MarshalIndent(configDoc, "", "  "); err != nil {
+		return nil, err
+	}
+	if err := e.add("config/config.json", KindConfig, configData); err != nil {
+		return nil, err
+	}
+
+	if data, err := os.ReadFile(config.GetCustomDetectionsFilePath()); err == nil {
+		if err := e.add("config/customdetections.json", KindDetections, data); err != nil {
+			return nil, err
+		}
+	}
+
+	// The active runfile with its overrides and presets, the presets file holds the schedules
+	if identifier != "" {
+		for _, name := range runfileFiles(identifier) {
+			data, err := os.ReadFile(filepath.Join(config.GetRunFilesFolder(), name))
+			if err != nil {
+				continue
+			}
+			if !sealing {
+				cleared := 0
+				if data, cleared, err = clearEncryptedValues(data); err != nil {
+					return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+				}
+				if cleared > 0 {
+					logger.Core.Warn(fmt.Sprintf("Export without passphrase: cleared %d secret values in %s", cleared, name))
+				}
+			}
+			if err := e.add("runfiles/"+name, KindRunfile, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for pluginName, filename := range config.GetRegisteredPlugins() {
+		source := filepath.Join(config.GetPluginsFolder(), filepath.Base(filename))
+		if _, err := os.Stat(source); err != nil {
+			logger.Core.Warn(fmt.Sprintf("Export: registered plugin %s has no file %s, skipped", pluginName, source))
+			continue
+		}
+		if err := e.addFile("plugins/"+filepath.Base(filename), KindPlugin, source); err != nil {
+			return nil, err
+		}
+	}
+
+	if data, err := os.ReadFile(config.GetTLSCertPath()); err == nil {
+		if err := e.add("tls/cert.pem", KindTLSCert, data); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.IncludeWorld {
+		if err := e.addWorldBackup(); err != nil {
+			return nil, err
+		}
+	}
+
+	if sealing {
+		if data, err := os.ReadFile(config.GetSecretsKeyFilePath()); err == nil {
+			secrets.Files[secretsKeyPath] = data
+		}
+		if data, err := os.ReadFile(config.GetTLSKeyPath()); err == nil {
+			secrets.Files[tlsKeyPath] = data
+		}
+		entry, sealed, err := sealSecrets(opts.Passphrase, secrets)
+		if err != nil {
+			return nil, err
+		}
+		writer, err := e.archive.Create(secretsName)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write(sealed); err != nil {
+			return nil, err
+		}
+		e.manifest.Secrets = entry
+	}
+
+	manifestData, err := json.MarshalIndent(e.manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	writer, err := e.archive.Create(manifestName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(manifestData); err != nil {
+		return nil, err
+	}
+	if err := e.archive.Close(); err != nil {
+		return nil, err
+	}
+	logger.Core.Info(fmt.Sprintf("Exported instance bundle with %d files, secrets included: %t", len(e.manifest.Files), sealing))
+	return e.manifest, nil
+}
+
+// addWorldBackup adds the newest backup, a tar archive or a copy directory
+func (e *exporter) addWorldBackup() error {
+	name, err := latestWorldBackup()
+	if err != nil {
+		return err
+	}
+	source := filepath.Join(config.GetBackupsStoreDir(), name)
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	e.manifest.WorldBackup = name
+	if !info.IsDir() {
+		return e.addFile(path.Join("world", name), KindWorld, source)
+	}
+	return filepath.WalkDir(source, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(config.GetBackupsStoreDir(), p)
+		if err != nil {
+			return err
+		}
+		return e.addFile(path.Join("world", filepath.ToSlash(rel)), KindWorld, p)
+	})
+}
+
+// ExportToFile writes a bundle to a file, used by the --export flag
+func ExportToFile(target string, opts ExportOptions) (*Manifest, error) {
+	tmpPath := target + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", target, err)
+	}
+	manifest, err := Export(file, opts)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, target); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	return manifest, nil
+}
@@ -0,0 +1,458 @@
+// import.go
+package bundlemgr
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+// maxInlineFileSize limits the files that are read into memory to be checked or changed, config and runfiles are far smaller
+const maxInlineFileSize = 16 << 20
+
+// ImportOptions controls how a bundle is applied
+type ImportOptions struct {
+	Passphrase   string // opens the sealed secrets, without it they are skipped
+	IncludeWorld bool   // puts the bundled world backup into the backup store
+	DryRun       bool   // only validates the bundle
+	User         string
+}
+
+// ImportResult describes what an import did, or would do for a dry run
+type ImportResult struct {
+	Manifest       *Manifest `json:"manifest"`
+	Files          []string  `json:"files"` // destinations that were written
+	Warnings       []string  `json:"warnings,omitempty"`
+	SecretsApplied bool      `json:"secrets_applied"`
+	BackupDir      string    `json:"backup_dir,omitempty"` // where the replaced files were copied to
+	DryRun         bool      `json:"dry_run"`
+}
+
+type plannedFile struct {
+	entry FileEntry
+	file  *zip.File // nil for secrets
+	dest  string
+	perm  os.FileMode
+	data  []byte // set for files that are written from memory
+}
+
+// destination maps a bundle path to where it is written, anything not matching its kind is refused
+func destination(entry FileEntry, identifier string) (string, os.FileMode, error) {
+	dir, name := path.Split(entry.Path)
+	switch entry.Kind {
+	case KindConfig:
+		if entry.Path == "config/config.json" {
+			return config.GetConfigPath(), 0600, nil
+		}
+	case KindDetections:
+		if entry.Path == "config/customdetections.json" {
+			return config.GetCustomDetectionsFilePath(), 0644, nil
+		}
+	case KindRunfile:
+		if dir == "runfiles/" && runfileNamePattern.MatchString(name) && strings.HasPrefix(name, "run"+identifier+".") {
+			return filepath.Join(config.GetRunFilesFolder(), name), 0644, nil
+		}
+	case KindPlugin:
+		if dir == "plugins/" && name != "" && name != ".." && !strings.ContainsAny(name, `/\:`) {
+			return filepath.Join(config.GetPluginsFolder(), name), 0755, nil
+		}
+	case KindTLSCert:
+		if entry.Path == "tls/cert.pem" {
+			return config.GetTLSCertPath(), 0644, nil
+		}
+	case KindWorld:
+		rel := strings.TrimPrefix(entry.Path, "world/")
+		clean := path.Clean(rel)
+		if strings.HasPrefix(entry.Path, "world/") && clean == rel && strings.HasPrefix(clean, "backup_") && !strings.Contains(clean, "..") && !strings.Contains(clean, `\`) {
+			return filepath.Join(config.GetBackupsStoreDir(), filepath.FromSlash(clean)), 0644, nil
+		}
+	}
+	return "", 0, fmt.Errorf("bundle file %s is not allowed as %s", entry.Path, entry.Kind)
+}
+
+func readZipFile(file *zip.File, limit int64) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s is larger than %d bytes", file.Name, limit)
+	}
+	return data, nil
+}
+
+// verifyZipFile checks size and digest of a bundle file against the manifest
+func verifyZipFile(file *zip.File, entry FileEntry) error {
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, io.LimitReader(rc, entry.Size+1))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", entry.Path, err)
+	}
+	if size != entry.Size || hex.EncodeToString(hash.Sum(nil)) != entry.SHA256 {
+		return fmt.Errorf("%s does not match the manifest, the bundle is damaged or was modified", entry.Path)
+	}
+	return nil
+}
+
+// Import validates a bundle and, unless DryRun is set, applies it. Nothing is written if validation fails.
+func Import(archivePath string, opts ImportOptions) (*ImportResult, error) {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer reader.Close()
+
+	zipFiles := make(map[string]*zip.File)
+	for _, file := range reader.File {
+		if _, duplicate := zipFiles[file.Name]; duplicate {
+			return nil, fmt.Errorf("bundle contains %s twice", file.Name)
+		}
+		zipFiles[file.Name] = file
+	}
+	manifestFile, ok := zipFiles[manifestName]
+	if !ok {
+		return nil, fmt.Errorf("not an SSUI bundle, %s is missing", manifestName)
+	}
+	manifestData, err := readZipFile(manifestFile, 1<<20)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("bundle manifest is corrupt: %w", err)
+	}
+	if manifest.FormatVersion != bundleFormatVersion {
+		return nil, fmt.Errorf("bundle format %d is not supported, this SSUI reads format %d", manifest.FormatVersion, bundleFormatVersion)
+	}
+	if manifest.RunfileIdentifier != "" && strings.ContainsAny(manifest.RunfileIdentifier, `/\`) {
+		return nil, fmt.Errorf("bundle runfile identifier %q is invalid", manifest.RunfileIdentifier)
+	}
+
+	result := &ImportResult{Manifest: &manifest, DryRun: opts.DryRun}
+	listed := map[string]bool{manifestName: true, secretsName: true}
+	var plan []*plannedFile
+	var configFile *plannedFile
+	var tlsCert *plannedFile
+	for _, entry := range manifest.Files {
+		if listed[entry.Path] {
+			return nil, fmt.Errorf("bundle lists %s twice", entry.Path)
+		}
+		listed[entry.Path] = true
+		file, ok := zipFiles[entry.Path]
+		if !ok {
+			return nil, fmt.Errorf("bundle manifest lists %s, but the file is missing", entry.Path)
+		}
+		dest, perm, err := destination(entry, manifest.RunfileIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyZipFile(file, entry); err != nil {
+			return nil, err
+		}
+		if entry.Kind == KindWorld && !opts.IncludeWorld {
+			continue
+		}
+		planned := &plannedFile{entry: entry, file: file, dest: dest, perm: perm}
+		switch entry.Kind {
+		case KindConfig:
+			configFile = planned
+		case KindTLSCert:
+			tlsCert = planned
+		}
+		plan = append(plan, planned)
+	}
+	for name := range zipFiles {
+		if !listed[name] && !strings.HasSuffix(name, "/") {
+			return nil, fmt.Errorf("bundle contains %s, which is not in its manifest", name)
+		}
+	}
+	if configFile == nil {
+		return nil, fmt.Errorf("bundle has no config")
+	}
+	if manifest.WorldBackup != "" && !opts.IncludeWorld {
+		result.Warnings = append(result.Warnings, "the bundle contains world backup "+manifest.WorldBackup+", it was not imported")
+	}
+
+	// Secrets
+	var secrets *secretValues
+	if manifest.Secrets != nil {
+		sealedFile, ok := zipFiles[secretsName]
+		if !ok {
+			return nil, fmt.Errorf("bundle manifest lists secrets, but %s is missing", secretsName)
+		}
+		if opts.Passphrase == "" {
+			result.Warnings = append(result.Warnings, "the bundle contains secrets, they were skipped because no passphrase was given")
+		} else {
+			sealed, err := readZipFile(sealedFile, maxInlineFileSize)
+			if err != nil {
+				return nil, err
+			}
+			if secrets, err = openSecrets(opts.Passphrase, manifest.Secrets, sealed); err != nil {
+				return nil, err
+			}
+		}
+	} else if _, ok := zipFiles[secretsName]; ok {
+		return nil, fmt.Errorf("bundle contains %s, which is not in its manifest", secretsName)
+	}
+
+	secretsKeyRestored := false
+	if secrets != nil {
+		for name, data := range secrets.Files {
+			switch name {
+			case secretsKeyPath:
+				if len(data) != 32 {
+					return nil, fmt.Errorf("bundled secrets key is corrupt")
+				}
+				plan = append(plan, &plannedFile{entry: FileEntry{Path: name}, dest: config.GetSecretsKeyFilePath(), perm: 0600, data: data})
+				secretsKeyRestored = true
+			case tlsKeyPath:
+				plan = append(plan, &plannedFile{entry: FileEntry{Path: name}, dest: config.GetTLSKeyPath(), perm: 0600, data: data})
+			default:
+				return nil, fmt.Errorf("bundle secrets contain unknown file %s", name)
+			}
+		}
+		result.SecretsApplied = true
+	}
+	if tlsCert != nil && (secrets == nil || secrets.Files[tlsKeyPath] == nil) {
+		// a certificate without its key would break HTTPS, this host keeps its own pair
+		plan = removePlanned(plan, tlsCert)
+		result.Warnings = append(result.Warnings, "the TLS certificate was skipped because its key is not part of the import")
+	}
+
+	// Plugins run as their own process, so they need the same signed digest as a gallery download
+	var unverified []*plannedFile
+	skippedPlugins := make(map[string]bool)
+	for _, planned := range plan {
+		if planned.entry.Kind != KindPlugin {
+			continue
+		}
+		if err := verifyBundledPlugin(path.Base(planned.entry.Path), planned.entry.SHA256); err != nil {
+			unverified = append(unverified, planned)
+			skippedPlugins[path.Base(planned.entry.Path)] = true
+			result.Warnings = append(result.Warnings, fmt.Sprintf("plugin %s was skipped, install it from the plugin gallery: %v", path.Base(planned.entry.Path), err))
+		}
+	}
+	for _, planned := range unverified {
+		plan = removePlanned(plan, planned)
+	}
+	if secretsKeyRestored {
+		result.Warnings = append(result.Warnings, undecryptableAfterImport(plan)...)
+	}
+
+	// Config, with the secret values put back
+	configData, err := readZipFile(configFile.file, maxInlineFileSize)
+	if err != nil {
+		return nil, err
+	}
+	var configDoc map[string]any
+	if err := json.Unmarshal(configData, &configDoc); err != nil {
+		return nil, fmt.Errorf("bundled config is corrupt: %w", err)
+	}
+	if secrets != nil {
+		for _, key := range secretConfigKeys {
+			if value, ok := secrets.Config[key]; ok {
+				configDoc[key] = value
+			}
+		}
+	} else if err := keepHostSecrets(configDoc); err != nil {
+		return nil, err
+	}
+	if registered, ok := configDoc["RegisteredPlugins"].(map[string]any); ok {
+		for name, filename := range registered {
+			if filename, ok := filename.(string); ok && skippedPlugins[filepath.Base(filename)] {
+				delete(registered, name)
+			}
+		}
+	}
+	if configFile.data, err = json.MarshalIndent(configDoc, "", "  "); err != nil {
+		return nil, err
+	}
+	var typed config.JsonConfig
+	if err := json.Unmarshal(configFile.data, &typed); err != nil {
+		return nil, fmt.Errorf("bundled config is invalid: %w", err)
+	}
+
+	// Runfiles are checked, and values encrypted with a key that stays behind are cleared
+	for _, planned := range plan {
+		if planned.entry.Kind != KindRunfile {
+			continue
+		}
+		data, err := readZipFile(planned.file, maxInlineFileSize)
+		if err != nil {
+			return nil, err
+		}
+		cleaned, cleared, err := clearEncryptedValues(data)
+		if err != nil {
+			return nil, fmt.Errorf("bundled %s is not valid JSON: %w", planned.entry.Path, err)
+		}
+		planned.data = data
+		if !secretsKeyRestored && cleared > 0 {
+			planned.data = cleaned
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%d secret values in %s were cleared, enter them again after the import", cleared, path.Base(planned.entry.Path)))
+		}
+	}
+
+	for _, planned := range plan {
+		result.Files = append(result.Files, planned.dest)
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	// Apply, the replaced files are kept
+	result.BackupDir = filepath.Join(config.GetSSUIFolder(), "import-backups", time.Now().Format("2006-01-02_15-04-05"))
+	for _, planned := range plan {
+		if planned.entry.Kind == KindWorld {
+			continue
+		}
+		if err := backupExisting(planned.dest, filepath.Join(result.BackupDir, filepath.FromSlash(planned.entry.Path))); err != nil {
+			return nil, fmt.Errorf("failed to back up %s before the import: %w", planned.dest, err)
+		}
+	}
+	for _, planned := range plan {
+		if err := writePlanned(planned); err != nil {
+			return result, fmt.Errorf("import stopped, the replaced files are in %s: %w", result.BackupDir, err)
+		}
+	}
+	if secretsKeyRestored {
+		security.ResetSecretKey()
+	}
+	logger.Core.Info(fmt.Sprintf("%s imported an instance bundle of %s created %s, %d files written, previous files kept in %s",
+		opts.User, manifest.RunfileIdentifier, manifest.CreatedAt.Format(time.RFC3339), len(plan), result.BackupDir))
+	return result, nil
+}
+
+// keepHostSecrets replaces the secret config keys, which the exporter blanked, with the values of this host
+func keepHostSecrets(configDoc map[string]any) error {
+	var host map[string]any
+	data, err := os.ReadFile(config.GetConfigPath())
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read the current config: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &host); err != nil {
+			return fmt.Errorf("current config is corrupt: %w", err)
+		}
+	}
+	for _, key := range secretConfigKeys {
+		if value, ok := host[key]; ok {
+			configDoc[key] = value
+		} else {
+			delete(configDoc, key)
+		}
+	}
+	return nil
+}
+
+// undecryptableAfterImport lists local runfile files the import doesn't replace that hold values encrypted with the secrets key it replaces
+func undecryptableAfterImport(plan []*plannedFile) []string {
+	replaced := make(map[string]bool)
+	for _, planned := range plan {
+		replaced[filepath.Clean(planned.dest)] = true
+	}
+	entries, err := os.ReadDir(config.GetRunFilesFolder())
+	if err != nil {
+		return nil
+	}
+	var warnings []string
+	for _, entry := range entries {
+		fullPath := filepath.Join(config.GetRunFilesFolder(), entry.Name())
+		if entry.IsDir() || !runfileNamePattern.MatchString(entry.Name()) || replaced[filepath.Clean(fullPath)] {
+			continue
+		}
+		data, err := os.ReadFile(fullPath)
+		if err != nil {
+			continue
+		}
+		if _, encrypted, err := clearEncryptedValues(data); err == nil && encrypted > 0 {
+			warnings = append(warnings, fmt.Sprintf("%d secret values in %s are encrypted with the replaced secrets key and can't be read anymore, enter them again after the import", encrypted, entry.Name()))
+		}
+	}
+	return warnings
+}
+
+func removePlanned(plan []*plannedFile, remove *plannedFile) []*plannedFile {
+	kept := plan[:0]
+	for _, planned := range plan {
+		if planned != remove {
+			kept = append(kept, planned)
+		}
+	}
+	return kept
+}
+
+// backupExisting copies a file that is about to be replaced
+func backupExisting(source, target string) error {
+	data, err := os.ReadFile(source)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0600)
+}
+
+func writePlanned(planned *plannedFile) error {
+	perm := planned.perm
+	if runtime.GOOS == "windows" {
+		perm = 0644
+	}
+	if planned.data != nil {
+		return writeFileAtomic(planned.dest, planned.data, perm)
+	}
+	// large files are streamed, they were verified before
+	rc, err := planned.file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := os.MkdirAll(filepath.Dir(planned.dest), 0755); err != nil {
+		return err
+	}
+	tmpPath := planned.dest + ".import"
+	out, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, planned.dest); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
@@ -174,25 +174,32 @@ func (m *CustomDetectionsManager) updateDetector() {
 	customPatterns := []CustomPattern{}
 
 	for _, cd := range m.Detections {
-		if cd.Type == "regex" {
-			re, err := regexp.Compile(cd.Pattern)
-			if err == nil {
-				customPatterns = append(customPatterns, CustomPattern{
-					Pattern:     re,
-					EventType:   EventType(cd.EventType),
-					MessageTmpl: cd.Message,
-					IsRegex:     true,
-				})
-			}
-		} else {
-			customPatterns = append(customPatterns, CustomPattern{
-				Keyword:     cd.Pattern,
-				EventType:   EventType(cd.EventType),
-				MessageTmpl: cd.Message,
-				IsRegex:     false,
-			})
+		if cp, err := newCustomPattern(cd); err == nil {
+			customPatterns = append(customPatterns, cp)
 		}
 	}
 
 	m.detector.SetCustomPatterns(customPatterns)
 }
+
+// newCustomPattern compiles a custom detection into the pattern form used by the detector
+func newCustomPattern(cd CustomDetection) (CustomPattern, error) {
+	if cd.Type == "regex" {
+		re, err := regexp.Compile(cd.Pattern)
+		if err != nil {
+			return CustomPattern{}, err
+		}
+		return CustomPattern{
+			Pattern:     re,
+			EventType:   EventType(cd.EventType),
+			MessageTmpl: cd.Message,
+			IsRegex:     true,
+		}, nil
+	}
+	return CustomPattern{
+		Keyword:     cd.Pattern,
+		EventType:   EventType(cd.EventType),
+		MessageTmpl: cd.Message,
+		IsRegex:     false,
+	}, nil
+}
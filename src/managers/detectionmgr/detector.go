@@ -20,7 +20,8 @@ Core Event Detection Engine
 - Implements multi-stage processing pipeline:
   1. Basic keyword checks
   2. Complex regex pattern matching
-  3. Custom rule evaluation
+  3. Multi-line exception aggregation
+  4. Custom rule evaluation
 - Handles event distribution to registered handlers
 */
 
@@ -29,6 +30,7 @@ func NewDetector() *Detector {
 	return &Detector{
 		handlers:         make(map[EventType][]Handler),
 		connectedPlayers: make(map[string]string),
+		exceptions:       newExceptionAggregator(),
 	}
 }
 
@@ -42,6 +44,13 @@ func (d *Detector) RegisterHandler(eventType EventType, handler Handler) {
 
 // ProcessLogMessage analyzes a log message and triggers appropriate handlers
 func (d *Detector) ProcessLogMessage(logMessage string) {
+	d.processMu.Lock()
+	defer d.processMu.Unlock()
+	d.processLogMessage(logMessage)
+}
+
+// processLogMessage must be called with processMu held
+func (d *Detector) processLogMessage(logMessage string) {
 	// Check for simple keyword patterns
 	keywordPatterns := map[string]EventType{
 		"Ready":                               EventServerReady,
@@ -63,34 +72,45 @@ func (d *Detector) ProcessLogMessage(logMessage string) {
 	// Process regex patterns for more complex detections
 	d.processRegexPatterns(logMessage)
 
+	// Exceptions and custom detections only produce notifications, nothing to rebuild during a replay
+	if d.replaying {
+		return
+	}
+
+	// Group exceptions and their stack trace lines into a single event
+	d.processExceptionLine(logMessage)
+
 	// Process CUSTOM PATTERNS (both regex and keywords)
 	for _, cp := range d.customPatterns {
-		if cp.IsRegex {
-			matches := cp.Pattern.FindStringSubmatch(logMessage)
-			if matches != nil {
-				// Format message with {0}, {1} placeholders
-				message := formatMessage(cp.MessageTmpl, matches)
-				d.triggerEvent(Event{
-					Type:      cp.EventType,
-					Message:   message,
-					RawLog:    logMessage,
-					Timestamp: time.Now().Format(time.RFC3339),
-				})
-			}
-		} else {
-			// Keyword matching
-			if strings.Contains(logMessage, cp.Keyword) {
-				d.triggerEvent(Event{
-					Type:      cp.EventType,
-					Message:   cp.MessageTmpl,
-					RawLog:    logMessage,
-					Timestamp: time.Now().Format(time.RFC3339),
-				})
-			}
+		if matches, ok := cp.match(logMessage); ok {
+			d.triggerEvent(Event{
+				Type:      cp.EventType,
+				Message:   cp.render(matches),
+				RawLog:    logMessage,
+				Timestamp: time.Now().Format(time.RFC3339),
+			})
 		}
 	}
 }
 
+// match checks a log message against a custom pattern and returns the regex submatches (nil for keywords)
+func (cp CustomPattern) match(logMessage string) ([]string, bool) {
+	if cp.IsRegex {
+		matches := cp.Pattern.FindStringSubmatch(logMessage)
+		return matches, matches != nil
+	}
+	// Keyword matching
+	return nil, strings.Contains(logMessage, cp.Keyword)
+}
+
+// render builds the event message for a match, replacing {0}, {1} placeholders for regex patterns
+func (cp CustomPattern) render(matches []string) string {
+	if !cp.IsRegex {
+		return cp.MessageTmpl
+	}
+	return formatMessage(cp.MessageTmpl, matches)
+}
+
 func formatMessage(template string, matches []string) string {
 	for i, match := range matches {
 		placeholder := fmt.Sprintf("{%d}", i)
@@ -114,7 +134,9 @@ func (d *Detector) processRegexPatterns(logMessage string) {
 
 				// Update connected players
 				d.connectedPlayers[steamID] = username
-				discordbot.AddToConnectedPlayers(username, steamID, time.Now(), d.connectedPlayers)
+				if !d.replaying {
+					discordbot.AddToConnectedPlayers(username, steamID, time.Now(), d.connectedPlayers)
+				}
 
 				d.triggerEvent(Event{
 					Type:      EventPlayerReady,
@@ -156,7 +178,9 @@ func (d *Detector) processRegexPatterns(logMessage string) {
 
 				// Remove from connected players
 				delete(d.connectedPlayers, steamID)
-				discordbot.RemoveFromConnectedPlayers(steamID, d.connectedPlayers)
+				if !d.replaying {
+					discordbot.RemoveFromConnectedPlayers(steamID, d.connectedPlayers)
+				}
 
 				d.triggerEvent(Event{
 					Type:      EventPlayerDisconnect,
@@ -187,21 +211,6 @@ func (d *Detector) processRegexPatterns(logMessage string) {
 				})
 			},
 		},
-		{
-			// Exception pattern
-			pattern: regexp.MustCompile(`(?m)^\s*>\s*\d{2}:\d{2}:\d{2}:.*Exception.*|>\s+\d{2}:\d{2}:\d{2}:.*StackTrace`),
-			handler: func(matches []string, logMessage string) {
-				d.triggerEvent(Event{
-					Type:      EventException,
-					Message:   "Exception detected",
-					RawLog:    logMessage,
-					Timestamp: time.Now().Format(time.RFC3339),
-					ExceptionInfo: &ExceptionInfo{
-						StackTrace: logMessage, // Using the full log message as the stack trace
-					},
-				})
-			},
-		},
 		{
 			pattern: regexp.MustCompile(`\d{2}:\d{2}:\d{2}: Changed setting '(.+?)' from '(.+?)' to '(.+?)'`),
 			handler: func(matches []string, logMessage string) {
@@ -263,8 +272,22 @@ func (d *Detector) processRegexPatterns(logMessage string) {
 	}
 }
 
-// triggerEvent calls all registered handlers for an event type
+// triggerEvent calls all registered handlers for an event type. While replaying, only the server state is recorded.
 func (d *Detector) triggerEvent(event Event) {
+	switch event.Type {
+	case EventServerRunning, EventServerStarting, EventServerReady:
+		at := time.Now()
+		if d.replaying && !d.replayLineAt.IsZero() {
+			at = d.replayLineAt
+		}
+		d.stateMu.Lock()
+		d.serverState = event.Type
+		d.serverStateAt = at
+		d.stateMu.Unlock()
+	}
+	if d.replaying {
+		return
+	}
 	if handlers, ok := d.handlers[event.Type]; ok {
 		for _, handler := range handlers {
 			handler(event)
@@ -281,6 +304,13 @@ func (d *Detector) GetConnectedPlayers() map[string]string {
 	return players
 }
 
+// GetServerState returns the last server lifecycle event seen and when it was seen
+func (d *Detector) GetServerState() (EventType, time.Time) {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+	return d.serverState, d.serverStateAt
+}
+
 // ClearConnectedPlayers clears the connected players map
 func (d *Detector) ClearConnectedPlayers() {
 	d.connectedPlayers = make(map[string]string)
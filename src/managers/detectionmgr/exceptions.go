@@ -0,0 +1,256 @@
+// exceptions.go
+package detectionmgr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+/*
+Multi-line Exception Aggregation
+- Groups an exception start line and its continuation lines (stack frames) into a single EXCEPTION event
+- Start and continuation patterns as well as the flush timeout are configurable
+- Fingerprints every exception by its normalized stack (timestamps, addresses and numbers stripped)
+- Alerts once per fingerprint per cooldown window and carries the occurrence count instead of re-alerting every time
+- Keeps an in-memory list of exception groups for the API
+*/
+
+const (
+	maxExceptionLines  = 200
+	maxExceptionGroups = 200
+	fingerprintLines   = 20
+)
+
+var (
+	timestampPrefixRegex = regexp.MustCompile(`^\s*>?\s*\d{2}:\d{2}:\d{2}:?\s*`)
+	hexRegex             = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+	bracketedHashRegex   = regexp.MustCompile(`<[0-9a-fA-F]{16,}>`)
+	numberRegex          = regexp.MustCompile(`\d+`)
+)
+
+// ExceptionGroup collects all occurrences of exceptions sharing the same normalized stack
+type ExceptionGroup struct {
+	Fingerprint    string    `json:"fingerprint"`
+	Summary        string    `json:"summary"`
+	LastStackTrace string    `json:"lastStackTrace"`
+	Count          int       `json:"count"`
+	FirstSeen      time.Time `json:"firstSeen"`
+	LastSeen       time.Time `json:"lastSeen"`
+	LastAlerted    time.Time `json:"lastAlerted"`
+	SinceLastAlert int       `json:"sinceLastAlert"`
+}
+
+type exceptionAggregator struct {
+	mu       sync.Mutex
+	lines    []string
+	timer    *time.Timer
+	batch    uint64    // increments for every buffered exception, a late timer for an older one does nothing
+	deadline time.Time // when the buffered exception is flushed if no continuation line arrives
+	startSrc string
+	contSrc  string
+	startRe  *regexp.Regexp
+	contRe   *regexp.Regexp
+	groups   map[string]*ExceptionGroup
+}
+
+func newExceptionAggregator() *exceptionAggregator {
+	return &exceptionAggregator{
+		groups: make(map[string]*ExceptionGroup),
+	}
+}
+
+// refreshPatterns recompiles the start and continuation patterns if the config changed. Must be called with mu held.
+func (a *exceptionAggregator) refreshPatterns() {
+	startSrc := config.GetExceptionStartPattern()
+	if startSrc != a.startSrc || a.startRe == nil {
+		re, err := regexp.Compile(startSrc)
+		if err != nil {
+			logger.Detection.Warn("Invalid ExceptionStartPattern, keeping the previous one: " + err.Error())
+		} else {
+			a.startRe = re
+		}
+		a.startSrc = startSrc
+	}
+	contSrc := config.GetExceptionContinuationPattern()
+	if contSrc != a.contSrc || a.contRe == nil {
+		re, err := regexp.Compile(contSrc)
+		if err != nil {
+			logger.Detection.Warn("Invalid ExceptionContinuationPattern, keeping the previous one: " + err.Error())
+		} else {
+			a.contRe = re
+		}
+		a.contSrc = contSrc
+	}
+}
+
+// processExceptionLine feeds a log line into the aggregator. Lines that end a buffered exception flush it.
+func (d *Detector) processExceptionLine(logMessage string) {
+	a := d.exceptions
+	a.mu.Lock()
+	a.refreshPatterns()
+
+	if len(a.lines) > 0 {
+		if a.contRe != nil && a.contRe.MatchString(logMessage) && !(a.startRe != nil && a.startRe.MatchString(logMessage)) {
+			if len(a.lines) < maxExceptionLines {
+				a.lines = append(a.lines, logMessage)
+			}
+			a.armTimerLocked(d)
+			a.mu.Unlock()
+			return
+		}
+		event := a.flushLocked()
+		a.mu.Unlock()
+		if event != nil {
+			d.triggerEvent(*event)
+		}
+		a.mu.Lock()
+	}
+
+	if a.startRe != nil && a.startRe.MatchString(logMessage) {
+		a.lines = []string{logMessage}
+		a.batch++
+		a.armTimerLocked(d)
+	}
+	a.mu.Unlock()
+}
+
+// armTimerLocked (re)starts the flush timer for the buffered exception. Must be called with mu held.
+func (a *exceptionAggregator) armTimerLocked(d *Detector) {
+	timeout := config.GetExceptionFlushTimeout()
+	a.deadline = time.Now().Add(timeout)
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+	batch := a.batch
+	a.timer = time.AfterFunc(timeout, func() { d.flushException(batch) })
+}
+
+// flushException is called by the flush timer once no continuation line arrived in time. A timer that
+// fired while a continuation line or a new exception was being processed leaves the buffer alone.
+func (d *Detector) flushException(batch uint64) {
+	d.processMu.Lock()
+	defer d.processMu.Unlock()
+	a := d.exceptions
+	a.mu.Lock()
+	if a.batch != batch || time.Now().Before(a.deadline) {
+		a.mu.Unlock()
+		return
+	}
+	event := a.flushLocked()
+	a.mu.Unlock()
+	if event != nil {
+		d.triggerEvent(*event)
+	}
+}
+
+// flushLocked closes the buffered exception, records it in its group and returns an event if an alert is due. Must be called with mu held.
+func (a *exceptionAggregator) flushLocked() *Event {
+	if len(a.lines) == 0 {
+		return nil
+	}
+	a.timer.Stop()
+	lines := a.lines
+	a.lines = nil
+
+	stackTrace := strings.Join(lines, "\n")
+	fingerprint := fingerprintException(lines)
+	now := time.Now()
+
+	group, ok := a.groups[fingerprint]
+	if !ok {
+		a.evictOldestGroupLocked()
+		group = &ExceptionGroup{
+			Fingerprint: fingerprint,
+			Summary:     strings.TrimSpace(timestampPrefixRegex.ReplaceAllString(lines[0], "")),
+			FirstSeen:   now,
+		}
+		a.groups[fingerprint] = group
+	}
+	group.Count++
+	group.SinceLastAlert++
+	group.LastSeen = now
+	group.LastStackTrace = stackTrace
+
+	if !group.LastAlerted.IsZero() && now.Sub(group.LastAlerted) < config.GetExceptionAlertCooldown() {
+		logger.Detection.Debugf("Suppressed repeated exception alert fingerprint=%s count=%d", fingerprint, group.Count)
+		return nil
+	}
+
+	occurrences := group.SinceLastAlert
+	group.SinceLastAlert = 0
+	group.LastAlerted = now
+
+	return &Event{
+		Type:      EventException,
+		Message:   "Exception detected",
+		RawLog:    stackTrace,
+		Timestamp: now.Format(time.RFC3339),
+		ExceptionInfo: &ExceptionInfo{
+			StackTrace:  stackTrace,
+			Fingerprint: fingerprint,
+			Occurrences: occurrences,
+			TotalCount:  group.Count,
+		},
+	}
+}
+
+// evictOldestGroupLocked keeps the group list bounded. Must be called with mu held.
+func (a *exceptionAggregator) evictOldestGroupLocked() {
+	if len(a.groups) < maxExceptionGroups {
+		return
+	}
+	var oldest *ExceptionGroup
+	for _, g := range a.groups {
+		if oldest == nil || g.LastSeen.Before(oldest.LastSeen) {
+			oldest = g
+		}
+	}
+	delete(a.groups, oldest.Fingerprint)
+}
+
+// fingerprintException hashes the normalized stack so the same exception from different times and addresses groups together
+func fingerprintException(lines []string) string {
+	if len(lines) > fingerprintLines {
+		lines = lines[:fingerprintLines]
+	}
+	normalized := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = timestampPrefixRegex.ReplaceAllString(line, "")
+		line = hexRegex.ReplaceAllString(line, "0x?")
+		line = bracketedHashRegex.ReplaceAllString(line, "<?>")
+		line = numberRegex.ReplaceAllString(line, "N")
+		normalized = append(normalized, strings.TrimSpace(line))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(normalized, "\n")))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// GetExceptionGroups returns a copy of all exception groups, most recently seen first
+func (d *Detector) GetExceptionGroups() []ExceptionGroup {
+	d.exceptions.mu.Lock()
+	defer d.exceptions.mu.Unlock()
+
+	groups := make([]ExceptionGroup, 0, len(d.exceptions.groups))
+	for _, g := range d.exceptions.groups {
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].LastSeen.After(groups[j].LastSeen)
+	})
+	return groups
+}
+
+// ClearExceptionGroups forgets all exception groups
+func (d *Detector) ClearExceptionGroups() {
+	d.exceptions.mu.Lock()
+	defer d.exceptions.mu.Unlock()
+	d.exceptions.groups = make(map[string]*ExceptionGroup)
+}
@@ -118,6 +118,10 @@ func DefaultHandlers() map[EventType]Handler {
 		EventException: func(event Event) {
 			// Initial alert message
 			alertMessage := "🎮 [Gameserver] 🚨 Exception detected!"
+			if event.ExceptionInfo != nil && event.ExceptionInfo.Occurrences > 1 {
+				alertMessage = fmt.Sprintf("🎮 [Gameserver] 🚨 Exception detected! (%d occurrences since last alert, %d total, fingerprint %s)",
+					event.ExceptionInfo.Occurrences, event.ExceptionInfo.TotalCount, event.ExceptionInfo.Fingerprint)
+			}
 			logger.Detection.Info(alertMessage)
 			ssestream.BroadcastDetectionEvent(alertMessage)
 			discordbot.SendUntrackedMessageToErrorChannel(alertMessage)
@@ -12,6 +12,7 @@ import (
 HTTP API for custom detections.
 - Handles GET (list), POST (add), and DELETE (remove) requests for custom patterns.
 - Interfaces with the CustomDetectionsManager over HTTP.
+- Exposes the aggregated exception groups of the detector.
 */
 
 var customDetectionsManager *CustomDetectionsManager
@@ -113,3 +114,31 @@ func HandleDeleteCustomDetection(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
+
+// HandleExceptionGroups lists (GET) or clears (DELETE) the aggregated exception groups
+func HandleExceptionGroups(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		groups := GetDetector().GetExceptionGroups()
+		if fingerprint := r.URL.Query().Get("fingerprint"); fingerprint != "" {
+			for _, group := range groups {
+				if group.Fingerprint == fingerprint {
+					json.NewEncoder(w).Encode(group)
+					return
+				}
+			}
+			http.Error(w, "exception group not found", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(groups)
+
+	case http.MethodDelete:
+		GetDetector().ClearExceptionGroups()
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
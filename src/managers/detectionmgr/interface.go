@@ -1,7 +1,10 @@
 // interface.go
 package detectionmgr
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 /*
 Code-Public Detection API interface
@@ -53,3 +56,8 @@ func GetPlayers(detector *Detector) map[string]string {
 func ClearPlayers(detector *Detector) {
 	detector.ClearConnectedPlayers()
 }
+
+// GetServerState returns the last server lifecycle event (SERVER_RUNNING, SERVER_STARTING, SERVER_READY) and when it was seen
+func GetServerState(detector *Detector) (EventType, time.Time) {
+	return detector.GetServerState()
+}
@@ -0,0 +1,149 @@
+// replay.go
+package detectionmgr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/discord/discordbot"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/runfile"
+)
+
+/*
+Log Replay
+- Rebuilds detector state after a backend reload or SSUI restart
+- Replays the current run of a log file (everything after the last run start marker of the runfile). Only the last
+  maxReplayBytes are read, without a marker in them nothing is replayed, so players of old runs don't come back
+- State changes get the time of their log line, not the time of the replay
+- Runs in a state-only mode: connected players and the server state are updated,
+  but no handlers are called, so nothing is announced twice
+*/
+
+const (
+	// maxReplayLineSize guards against huge single lines in the log (bufio.Scanner default is 64KB)
+	maxReplayLineSize = 1024 * 1024
+	// maxReplayBytes is how much of the end of the log is searched for the current run
+	maxReplayBytes = 16 * 1024 * 1024
+)
+
+// lineTimeRegex matches the HH:MM:SS prefix gameservers put in front of their log lines
+var lineTimeRegex = regexp.MustCompile(`^\s*>?\s*(\d{2}):(\d{2}):(\d{2})`)
+
+// ReplayLogFile rebuilds the connected players and server state from the current run in a log file.
+// Returns the number of replayed lines.
+func ReplayLogFile(detector *Detector, path string) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open log for replay: %w", err)
+	}
+	marker := runfile.GetLogRunStartMarker()
+	lines, found, err := readCurrentRun(path, marker)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		logger.Detection.Warnf("No run start marker %q in the last %d MB of %s, skipping log replay", marker, maxReplayBytes>>20, path)
+		return 0, nil
+	}
+	detector.replay(lines, info.ModTime())
+
+	players := detector.GetConnectedPlayers()
+	state, _ := detector.GetServerState()
+	logger.Detection.Infof("Replayed %d log lines from %s: %d connected players, server state %q", len(lines), path, len(players), state)
+	return len(lines), nil
+}
+
+// readCurrentRun returns the lines of the log file starting at the last run start marker within its last maxReplayBytes.
+// found is false if there is no marker in that part of the log.
+func readCurrentRun(path, runStartMarker string) ([]string, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open log for replay: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open log for replay: %w", err)
+	}
+	offset := max(info.Size()-maxReplayBytes, 0)
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, false, fmt.Errorf("failed to read log for replay: %w", err)
+	}
+
+	var lines []string
+	found := false
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxReplayLineSize)
+	if offset > 0 {
+		// the first line is most likely cut off by the seek
+		scanner.Scan()
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, runStartMarker) {
+			// A new run started, everything before belongs to an old run
+			lines, found = lines[:0], true
+		}
+		if found {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, false, fmt.Errorf("failed to read log for replay: %w", err)
+	}
+	return lines, found, nil
+}
+
+// replay feeds lines through the detector in state-only mode, replacing the current player state.
+// logWritten is the last write of the log, the lines' times of day are placed relative to it.
+func (d *Detector) replay(lines []string, logWritten time.Time) {
+	d.processMu.Lock()
+	defer d.processMu.Unlock()
+
+	d.connectedPlayers = make(map[string]string)
+	d.stateMu.Lock()
+	d.serverState = ""
+	d.serverStateAt = time.Time{}
+	d.stateMu.Unlock()
+	d.replaying = true
+	d.replayLineAt = time.Time{}
+	for _, line := range lines {
+		d.replayLineAt = replayLineTime(line, logWritten, d.replayLineAt)
+		d.processLogMessage(line)
+	}
+	d.replaying = false
+	d.replayLineAt = time.Time{}
+
+	// Publish the rebuilt list once instead of once per replayed line
+	discordbot.SetConnectedPlayers(d.connectedPlayers)
+}
+
+// replayLineTime returns when a replayed line was written, from its time of day on the day the log was last written.
+// Lines without a time keep the time of the line before.
+func replayLineTime(line string, logWritten, previous time.Time) time.Time {
+	match := lineTimeRegex.FindStringSubmatch(line)
+	if match == nil {
+		return previous
+	}
+	hour, _ := strconv.Atoi(match[1])
+	minute, _ := strconv.Atoi(match[2])
+	second, _ := strconv.Atoi(match[3])
+	if hour > 23 || minute > 59 || second > 59 {
+		return previous
+	}
+	year, month, day := logWritten.Date()
+	at := time.Date(year, month, day, hour, minute, second, 0, logWritten.Location())
+	if at.After(logWritten.Add(time.Minute)) {
+		// written before midnight, the log was last written the day after
+		at = at.AddDate(0, 0, -1)
+	}
+	return at
+}
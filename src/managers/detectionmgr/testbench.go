@@ -0,0 +1,287 @@
+// testbench.go
+package detectionmgr
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
+)
+
+/*
+Custom Detection Test Bench
+- Runs a draft CustomDetection against sample text or an archived log without saving it
+- Uses the exact same matching and formatMessage rendering as the live detector
+- Reports every match with its capture groups and the rendered event message
+- Validates regex syntax and warns about patterns that are overly broad or unusually large, Go's RE2 engine has no
+  catastrophic backtracking, so nested quantifiers are fine
+- Archived logs are resolved inside the gameserver directory or the SSUI log folder only
+*/
+
+const (
+	testBenchMaxMatches   = 500
+	testBenchMaxLogBytes  = 10 * 1024 * 1024
+	testBenchMaxProgInsts = 2000
+)
+
+var placeholderRegex = regexp.MustCompile(`\{(\d+)\}`)
+
+// TestDetectionRequest is the payload accepted by the test bench
+type TestDetectionRequest struct {
+	Detection  CustomDetection `json:"detection"`
+	SampleText string          `json:"sampleText,omitempty"`
+	LogFile    string          `json:"logFile,omitempty"`   // path relative to the log source root
+	LogSource  string          `json:"logSource,omitempty"` // "game" (default) or "ssui"
+}
+
+// TestDetectionMatch describes a single matching line
+type TestDetectionMatch struct {
+	Line    int      `json:"line"`
+	Text    string   `json:"text"`
+	Groups  []string `json:"groups"`
+	Message string   `json:"message"`
+}
+
+// TestDetectionResult is returned by the test bench
+type TestDetectionResult struct {
+	Valid        bool                 `json:"valid"`
+	Error        string               `json:"error,omitempty"`
+	Warnings     []string             `json:"warnings"`
+	LinesTested  int                  `json:"linesTested"`
+	MatchCount   int                  `json:"matchCount"`
+	Truncated    bool                 `json:"truncated"`
+	Matches      []TestDetectionMatch `json:"matches"`
+	GroupCount   int                  `json:"groupCount"`
+	EventType    string               `json:"eventType"`
+	PatternType  string               `json:"patternType"`
+	SourceLabel  string               `json:"source"`
+	LogTruncated bool                 `json:"logTruncated,omitempty"`
+}
+
+// TestCustomDetection runs a draft detection against the given input and reports what it would have triggered
+func TestCustomDetection(req TestDetectionRequest) TestDetectionResult {
+	cd := req.Detection
+	if cd.EventType == "" {
+		cd.EventType = string(EventCustomDetection)
+	}
+	result := TestDetectionResult{
+		Warnings:    []string{},
+		Matches:     []TestDetectionMatch{},
+		EventType:   cd.EventType,
+		PatternType: cd.Type,
+	}
+
+	if cd.Type != "regex" && cd.Type != "keyword" {
+		result.Error = "Type must be 'regex' or 'keyword'"
+		return result
+	}
+	if cd.Pattern == "" {
+		result.Error = "Pattern cannot be empty"
+		return result
+	}
+
+	cp, err := newCustomPattern(cd)
+	if err != nil {
+		result.Error = "invalid regex pattern: " + err.Error()
+		return result
+	}
+	result.Valid = true
+
+	if cp.IsRegex {
+		result.GroupCount = cp.Pattern.NumSubexp()
+		result.Warnings = append(result.Warnings, analyzeRegex(cd.Pattern, cp)...)
+		result.Warnings = append(result.Warnings, checkPlaceholders(cd.Message, result.GroupCount)...)
+	} else if strings.TrimSpace(cd.Pattern) != cd.Pattern {
+		result.Warnings = append(result.Warnings, "keyword has leading or trailing whitespace, which is matched literally")
+	}
+	if !isKnownEventType(EventType(cd.EventType)) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("event type %s has no default handler and will not produce a notification", cd.EventType))
+	}
+
+	reader, label, truncated, err := openTestInput(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer reader.Close()
+	result.SourceLabel = label
+	result.LogTruncated = truncated
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		result.LinesTested++
+		line := scanner.Text()
+		matches, ok := cp.match(line)
+		if !ok {
+			continue
+		}
+		result.MatchCount++
+		if len(result.Matches) >= testBenchMaxMatches {
+			result.Truncated = true
+			continue
+		}
+		groups := []string{}
+		if len(matches) > 1 {
+			groups = matches[1:]
+		}
+		result.Matches = append(result.Matches, TestDetectionMatch{
+			Line:    result.LinesTested,
+			Text:    line,
+			Groups:  groups,
+			Message: cp.render(matches),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		result.Warnings = append(result.Warnings, "stopped reading input early: "+err.Error())
+	}
+
+	if result.LinesTested > 1 && result.MatchCount == result.LinesTested {
+		result.Warnings = append(result.Warnings, "pattern matched every line of the input and would trigger on all log output")
+	}
+	return result
+}
+
+// openTestInput returns a reader for the sample text or the referenced archived log
+func openTestInput(req TestDetectionRequest) (io.ReadCloser, string, bool, error) {
+	if req.LogFile == "" {
+		return io.NopCloser(strings.NewReader(req.SampleText)), "sample", false, nil
+	}
+
+	var root string
+	switch req.LogSource {
+	case "", "game":
+		root = config.GetRunfileIdentifier()
+	case "ssui":
+		root = config.GetLogFolder()
+	default:
+		return nil, "", false, fmt.Errorf("logSource must be 'game' or 'ssui'")
+	}
+
+	path, err := resolveLogPath(root, req.LogFile)
+	if err != nil {
+		return nil, "", false, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, "", false, fmt.Errorf("failed to stat log file: %w", err)
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, "", false, fmt.Errorf("log file is a directory")
+	}
+
+	// Only test the tail of large logs so a huge archive can't stall the API
+	truncated := false
+	if info.Size() > testBenchMaxLogBytes {
+		if _, err := file.Seek(info.Size()-testBenchMaxLogBytes, io.SeekStart); err != nil {
+			file.Close()
+			return nil, "", false, fmt.Errorf("failed to seek log file: %w", err)
+		}
+		truncated = true
+	}
+	return file, req.LogFile, truncated, nil
+}
+
+// resolveLogPath joins a user supplied path to root and makes sure it (and any symlink target) stays inside root
+func resolveLogPath(root, name string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve log root: %w", err)
+	}
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("log file must be a relative path")
+	}
+	path := filepath.Join(absRoot, filepath.Clean(name))
+	if !security.IsPathInsideRoot(path, absRoot) {
+		return "", fmt.Errorf("log file must be inside %s", root)
+	}
+	realRoot, err := filepath.EvalSymlinks(absRoot)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve log root: %w", err)
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("log file not found: %s", name)
+	}
+	// checked on the resolved paths, so a symlink in the log folder can't point outside of it
+	if !security.IsPathInsideRoot(realPath, realRoot) {
+		return "", fmt.Errorf("log file must be inside %s", root)
+	}
+	return realPath, nil
+}
+
+// analyzeRegex looks for patterns that are too broad to be useful or expensive to run against every line
+func analyzeRegex(pattern string, cp CustomPattern) []string {
+	warnings := []string{}
+
+	if cp.Pattern.MatchString("") {
+		warnings = append(warnings, "pattern matches an empty string and will trigger on every log line")
+	}
+
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return warnings
+	}
+	if strings.HasPrefix(pattern, ".*") || strings.HasPrefix(pattern, "^.*") {
+		warnings = append(warnings, "leading .* is redundant for unanchored matching and slows down every log line")
+	}
+	if prog, err := syntax.Compile(re.Simplify()); err == nil && len(prog.Inst) > testBenchMaxProgInsts {
+		warnings = append(warnings, fmt.Sprintf("pattern compiles to %d instructions, consider simplifying it since it runs against every log line", len(prog.Inst)))
+	}
+	return warnings
+}
+
+// checkPlaceholders warns about {n} placeholders in the message that no capture group will fill
+func checkPlaceholders(message string, groupCount int) []string {
+	warnings := []string{}
+	for _, m := range placeholderRegex.FindAllStringSubmatch(message, -1) {
+		var index int
+		fmt.Sscanf(m[1], "%d", &index)
+		if index > groupCount {
+			warnings = append(warnings, fmt.Sprintf("message placeholder {%d} has no matching capture group (pattern has %d)", index, groupCount))
+		}
+	}
+	return warnings
+}
+
+func isKnownEventType(eventType EventType) bool {
+	_, ok := DefaultHandlers()[eventType]
+	return ok
+}
+
+// HandleTestCustomDetection handles POST requests to test a draft custom detection
+func HandleTestCustomDetection(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req TestDetectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.SampleText == "" && req.LogFile == "" {
+		http.Error(w, "Either sampleText or logFile is required", http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(TestCustomDetection(req))
+}
@@ -1,7 +1,11 @@
 // types.go
 package detectionmgr
 
-import "regexp"
+import (
+	"regexp"
+	"sync"
+	"time"
+)
 
 // EventType defines the type of event detected
 type EventType string
@@ -27,6 +31,13 @@ type Detector struct {
 	handlers         map[EventType][]Handler
 	connectedPlayers map[string]string // SteamID -> Username
 	customPatterns   []CustomPattern
+	exceptions       *exceptionAggregator
+	processMu        sync.Mutex // serializes live log processing and log replays
+	replaying        bool       // state-only mode, handlers are not called
+	replayLineAt     time.Time  // when the replayed line was written, zero outside of replays
+	stateMu          sync.Mutex // guards serverState and serverStateAt, readers must not wait for a replay
+	serverState      EventType  // last server lifecycle event seen
+	serverStateAt    time.Time
 }
 
 type CustomPattern struct {
@@ -61,7 +72,10 @@ type BackupInfo struct {
 
 // ExceptionInfo contains information about a server exception
 type ExceptionInfo struct {
-	StackTrace string
+	StackTrace  string
+	Fingerprint string
+	Occurrences int // occurrences since the last alert for this fingerprint, including this one
+	TotalCount  int
 }
 
 // Handler is a function that handles detected events
@@ -0,0 +1,285 @@
+// archive.go
+package filemgr
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+// WriteZip streams a directory as zip archive. Symlinks are followed only to files inside the game directory.
+func WriteZip(w io.Writer, dir string) error {
+	root, err := gameRoot()
+	if err != nil {
+		return err
+	}
+	base, err := resolve(root, dir)
+	if err != nil {
+		return err
+	}
+	archive := zip.NewWriter(w)
+	err = filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			logger.Core.Warn("Skipping " + path + " in zip download: " + err.Error())
+			return nil
+		}
+		name := filepath.ToSlash(strings.TrimPrefix(relativeTo(base, path), "./"))
+		if path == base {
+			return nil
+		}
+		source := path
+		if d.Type()&fs.ModeSymlink != 0 {
+			target, err := resolve(root, relativeTo(root, path))
+			if err != nil {
+				return nil
+			}
+			if info, err := os.Stat(target); err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+			source = target
+		} else if d.IsDir() {
+			_, err := archive.Create(name + "/")
+			return err
+		} else if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := os.Stat(source)
+		if err != nil {
+			return nil
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name, header.Method = name, zip.Deflate
+		writer, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(source)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(writer, file)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write zip of %s: %w", dir, err)
+	}
+	return archive.Close()
+}
+
+// Extraction limits, an archive that unpacks to more than this is refused or aborted (zip bombs)
+const (
+	maxExtractBytes   = 32 << 30 // 32 GiB, whole gameserver installs stay below this
+	maxExtractEntries = 200_000
+)
+
+// ExtractResult summarizes an extraction
+type ExtractResult struct {
+	Files   int      `json:"files"`
+	Skipped []string `json:"skipped,omitempty"` // symlinks and other entries that were not extracted
+}
+
+// Extract unpacks a .zip, .tar, .tar.gz or .tgz archive from the game directory into dest, existing files are replaced
+func Extract(archivePath, dest string) (ExtractResult, error) {
+	root, err := gameRoot()
+	if err != nil {
+		return ExtractResult{}, err
+	}
+	source, err := resolve(root, archivePath)
+	if err != nil {
+		return ExtractResult{}, err
+	}
+	destClean, err := cleanRelative(dest)
+	if err != nil {
+		return ExtractResult{}, err
+	}
+	if err := Mkdir(destClean); err != nil {
+		return ExtractResult{}, err
+	}
+
+	file, err := os.Open(source)
+	if err != nil {
+		return ExtractResult{}, fmt.Errorf("failed to open %s: %w", archivePath, err)
+	}
+	defer file.Close()
+
+	x := &extractor{root: root, dest: destClean}
+	lower := strings.ToLower(source)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		info, err := file.Stat()
+		if err != nil {
+			return ExtractResult{}, err
+		}
+		err = x.zip(file, info.Size())
+		return x.result, err
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return ExtractResult{}, fmt.Errorf("failed to read %s: %w", archivePath, err)
+		}
+		defer gz.Close()
+		err = x.tar(gz)
+		return x.result, err
+	case strings.HasSuffix(lower, ".tar"):
+		err = x.tar(file)
+		return x.result, err
+	default:
+		return ExtractResult{}, fmt.Errorf("%s is not a .zip, .tar, .tar.gz or .tgz archive", archivePath)
+	}
+}
+
+type extractor struct {
+	root    string
+	dest    string
+	result  ExtractResult
+	written int64 // uncompressed bytes written so far
+	entries int
+}
+
+// countEntry enforces maxExtractEntries, called for every archive entry
+func (x *extractor) countEntry() error {
+	x.entries++
+	if x.entries > maxExtractEntries {
+		return fmt.Errorf("archive has more than %d entries", maxExtractEntries)
+	}
+	return nil
+}
+
+// target resolves an archive entry name, every entry goes through the same checks as user supplied paths
+func (x *extractor) target(name string) (string, error) {
+	path, err := resolve(x.root, filepath.Join(x.dest, filepath.FromSlash(name)))
+	if err != nil {
+		return "", fmt.Errorf("archive entry %s: %w", name, err)
+	}
+	return path, nil
+}
+
+func (x *extractor) writeFile(name string, mode os.FileMode, r io.Reader) error {
+	// the entry itself isn't followed, a symlink at its place is replaced below
+	path, err := resolveLink(x.root, filepath.Join(x.dest, filepath.FromSlash(name)))
+	if err != nil {
+		return fmt.Errorf("archive entry %s: %w", name, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", name, err)
+	}
+	// a symlink at the target would otherwise be written through
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm()|0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+	defer out.Close()
+	// the declared sizes can lie, so the limit is enforced on what is actually read
+	n, err := io.Copy(out, io.LimitReader(r, maxExtractBytes-x.written+1))
+	x.written += n
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if x.written > maxExtractBytes {
+		return fmt.Errorf("archive unpacks to more than %d GiB, extraction stopped at %s", maxExtractBytes>>30, name)
+	}
+	x.result.Files++
+	return nil
+}
+
+func (x *extractor) mkdir(name string) error {
+	path, err := x.target(name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(path, 0755)
+}
+
+func (x *extractor) zip(r io.ReaderAt, size int64) error {
+	reader, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("failed to read zip: %w", err)
+	}
+	// zip has a central directory, so a malicious entry is refused before anything is written
+	if len(reader.File) > maxExtractEntries {
+		return fmt.Errorf("archive has more than %d entries", maxExtractEntries)
+	}
+	var declared uint64
+	for _, f := range reader.File {
+		if _, err := cleanRelative(filepath.Join(x.dest, filepath.FromSlash(f.Name))); err != nil {
+			return fmt.Errorf("archive entry %s: %w", f.Name, err)
+		}
+		// compared without adding first, forged sizes could overflow the sum
+		if f.UncompressedSize64 > maxExtractBytes-declared {
+			return fmt.Errorf("archive unpacks to more than %d GiB", maxExtractBytes>>30)
+		}
+		declared += f.UncompressedSize64
+	}
+	for _, f := range reader.File {
+		if err := x.countEntry(); err != nil {
+			return err
+		}
+		switch mode := f.Mode(); {
+		case mode.IsDir():
+			if err := x.mkdir(f.Name); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("failed to open %s in zip: %w", f.Name, err)
+			}
+			err = x.writeFile(f.Name, mode, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			x.result.Skipped = append(x.result.Skipped, f.Name)
+		}
+	}
+	return nil
+}
+
+func (x *extractor) tar(r io.Reader) error {
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar: %w", err)
+		}
+		if err := x.countEntry(); err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := x.mkdir(header.Name); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := x.writeFile(header.Name, os.FileMode(header.Mode), reader); err != nil {
+				return err
+			}
+		case tar.TypeXGlobalHeader:
+			// pax metadata, nothing to extract
+		default:
+			x.result.Skipped = append(x.result.Skipped, header.Name)
+		}
+	}
+}
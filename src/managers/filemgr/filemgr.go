@@ -0,0 +1,251 @@
+// filemgr.go
+package filemgr
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+/*
+Game Directory File Manager
+- Lists, renames, deletes, creates, uploads, downloads and extracts files in the gameserver directory ./<RunfileIdentifier>
+- All paths are relative to that directory with forward slashes, "" or "." is the directory itself
+- Paths are checked after resolving symlinks, a symlink pointing outside the game directory can't be followed,
+  only deleted or renamed itself
+- Archives are extracted with the same checks for every entry, symlinks inside archives are skipped
+*/
+
+// ErrOutsideRoot is returned for paths that leave the game directory
+var ErrOutsideRoot = errors.New("path is outside of the game directory")
+
+// Entry is a file or directory in a listing
+type Entry struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"` // relative to the game directory
+	IsDir     bool      `json:"isDir"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"modTime"`
+	IsSymlink bool      `json:"isSymlink,omitempty"`
+	Broken    bool      `json:"broken,omitempty"` // symlink that is dangling or points outside the game directory
+}
+
+// gameRoot returns the real absolute path of the game directory
+func gameRoot() (string, error) {
+	root, err := filepath.Abs(config.GetRunfileIdentifier())
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve game directory: %w", err)
+	}
+	real, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("game directory %s is not available: %w", root, err)
+	}
+	return real, nil
+}
+
+// cleanRelative validates a user supplied relative path
+func cleanRelative(rel string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(strings.TrimSpace(rel)))
+	if filepath.IsAbs(clean) || filepath.VolumeName(clean) != "" || strings.HasPrefix(clean, string(os.PathSeparator)) {
+		return "", fmt.Errorf("%w: %s must be relative", ErrOutsideRoot, rel)
+	}
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(os.PathSeparator)) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideRoot, rel)
+	}
+	return clean, nil
+}
+
+// resolve turns a relative path into an absolute one whose existing part, symlinks followed, stays inside root
+func resolve(root, rel string) (string, error) {
+	clean, err := cleanRelative(rel)
+	if err != nil {
+		return "", err
+	}
+	full := filepath.Join(root, clean)
+	if !security.IsPathInsideRoot(full, root) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideRoot, rel)
+	}
+	// the path may not exist yet, so the longest existing prefix is resolved and the rest appended
+	existing, rest := full, ""
+	for {
+		real, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			full = filepath.Join(real, rest)
+			break
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = filepath.Dir(existing)
+	}
+	if !security.IsPathInsideRoot(full, root) {
+		return "", fmt.Errorf("%w: %s leads outside through a symlink", ErrOutsideRoot, rel)
+	}
+	return full, nil
+}
+
+// resolveLink resolves only the parent of a path, so the last element is used as is, even if it's a symlink
+func resolveLink(root, rel string) (string, error) {
+	clean, err := cleanRelative(rel)
+	if err != nil {
+		return "", err
+	}
+	if clean == "." {
+		return "", fmt.Errorf("the game directory itself can't be changed")
+	}
+	parent, err := resolve(root, filepath.Dir(clean))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(parent, filepath.Base(clean)), nil
+}
+
+func relativeTo(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// List returns the entries of a directory, directories first
+func List(dir string) ([]Entry, error) {
+	root, err := gameRoot()
+	if err != nil {
+		return nil, err
+	}
+	path, err := resolve(root, dir)
+	if err != nil {
+		return nil, err
+	}
+	dirEntries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	entries := make([]Entry, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		full := filepath.Join(path, dirEntry.Name())
+		info, err := os.Lstat(full)
+		if err != nil {
+			continue
+		}
+		entry := Entry{Name: dirEntry.Name(), Path: relativeTo(root, full), Size: info.Size(), ModTime: info.ModTime(), IsDir: info.IsDir()}
+		if info.Mode()&os.ModeSymlink != 0 {
+			entry.IsSymlink = true
+			if target, err := resolve(root, relativeTo(root, full)); err == nil {
+				if targetInfo, err := os.Stat(target); err == nil {
+					entry.IsDir, entry.Size, entry.ModTime = targetInfo.IsDir(), targetInfo.Size(), targetInfo.ModTime()
+				} else {
+					entry.Broken = true
+				}
+			} else {
+				entry.Broken = true
+			}
+		}
+		if entry.IsDir {
+			entry.Size = 0
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDir != entries[j].IsDir {
+			return entries[i].IsDir
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+	return entries, nil
+}
+
+// Stat returns the entry for a single path
+func Stat(rel string) (Entry, string, error) {
+	root, err := gameRoot()
+	if err != nil {
+		return Entry{}, "", err
+	}
+	path, err := resolve(root, rel)
+	if err != nil {
+		return Entry{}, "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return Entry{}, "", fmt.Errorf("%s not found", rel)
+	}
+	return Entry{Name: info.Name(), Path: relativeTo(root, path), IsDir: info.IsDir(), Size: info.Size(), ModTime: info.ModTime()}, path, nil
+}
+
+// Mkdir creates a directory and its missing parents
+func Mkdir(rel string) error {
+	root, err := gameRoot()
+	if err != nil {
+		return err
+	}
+	path, err := resolve(root, rel)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", rel, err)
+	}
+	return nil
+}
+
+// Rename moves a file, directory or symlink within the game directory, it never replaces an existing target
+func Rename(from, to string) error {
+	root, err := gameRoot()
+	if err != nil {
+		return err
+	}
+	source, err := resolveLink(root, from)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(source); err != nil {
+		return fmt.Errorf("%s not found", from)
+	}
+	target, err := resolveLink(root, to)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(target); err == nil {
+		return fmt.Errorf("%s already exists", to)
+	}
+	if security.IsPathInsideRoot(target, source) {
+		return fmt.Errorf("can't move %s into itself", from)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", to, err)
+	}
+	if err := os.Rename(source, target); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", from, to, err)
+	}
+	return nil
+}
+
+// Delete removes a file, a symlink (not its target) or a directory with everything in it
+func Delete(rel string) error {
+	root, err := gameRoot()
+	if err != nil {
+		return err
+	}
+	path, err := resolveLink(root, rel)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(path); err != nil {
+		return fmt.Errorf("%s not found", rel)
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", rel, err)
+	}
+	logger.Core.Info("Deleted " + relativeTo(root, path) + " from the game directory")
+	return nil
+}
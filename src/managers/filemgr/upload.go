@@ -0,0 +1,333 @@
+// upload.go
+package filemgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/google/uuid"
+)
+
+/*
+Resumable Uploads
+- An upload is started with the target path and total size and gets an ID
+- Chunks are appended at the offset the server reports, a client that lost its connection asks for the offset and continues
+- Partial data lives in SSUI/uploads, next to a small JSON file, so uploads survive an SSUI restart
+- When all bytes arrived the file is moved into the game directory, uploads untouched for uploadExpiry are removed
+- If the move fails the data is dropped and the upload is marked failed, it has to be started again
+- A chunk claims its upload under uploadsMutex and is copied without holding it, so a slow client only blocks its own upload
+*/
+
+const uploadExpiry = 24 * time.Hour
+
+// Upload is the state of a resumable upload
+type Upload struct {
+	ID        string    `json:"id"`
+	Path      string    `json:"path"` // target, relative to the game directory
+	Size      int64     `json:"size"`
+	Received  int64     `json:"received"`
+	Overwrite bool      `json:"overwrite"`
+	User      string    `json:"user,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Done      bool      `json:"done"`
+	Error     string    `json:"error,omitempty"` // set when the upload failed, its data was removed
+}
+
+// ErrOffsetMismatch is returned when a chunk doesn't continue where the upload stands
+type ErrOffsetMismatch struct {
+	Expected int64
+}
+
+func (e ErrOffsetMismatch) Error() string {
+	return fmt.Sprintf("chunk offset does not match, the upload continues at byte %d", e.Expected)
+}
+
+var (
+	uploadsMutex   sync.Mutex
+	writingUploads = make(map[string]bool) // uploads a chunk is being copied into, guarded by uploadsMutex
+)
+
+func uploadsDir() string {
+	return filepath.Join(config.GetSSUIFolder(), "uploads")
+}
+
+func uploadPaths(id string) (meta, data string, err error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return "", "", fmt.Errorf("invalid upload id")
+	}
+	return filepath.Join(uploadsDir(), id+".json"), filepath.Join(uploadsDir(), id+".part"), nil
+}
+
+func loadUpload(id string) (*Upload, error) {
+	metaPath, _, err := uploadPaths(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		return nil, fmt.Errorf("upload %s not found", id)
+	}
+	var upload Upload
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, fmt.Errorf("upload %s is corrupt: %w", id, err)
+	}
+	return &upload, nil
+}
+
+func saveUpload(upload *Upload) error {
+	metaPath, _, err := uploadPaths(upload.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(upload)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(metaPath, data, 0600)
+}
+
+func removeUpload(id string) {
+	metaPath, dataPath, err := uploadPaths(id)
+	if err != nil {
+		return
+	}
+	os.Remove(metaPath)
+	os.Remove(dataPath)
+}
+
+// removeExpiredUploads deletes uploads that were not continued for uploadExpiry, uploadsMutex must be held
+func removeExpiredUploads() {
+	entries, err := os.ReadDir(uploadsDir())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		id, isMeta := strings.CutSuffix(entry.Name(), ".json")
+		if !isMeta {
+			continue
+		}
+		if writingUploads[id] {
+			continue
+		}
+		if upload, err := loadUpload(id); err != nil || time.Since(upload.UpdatedAt) > uploadExpiry {
+			removeUpload(id)
+			logger.Core.Debug("Removed expired upload " + id)
+		}
+	}
+}
+
+// StartUpload checks the target and creates an upload for size bytes
+func StartUpload(path string, size int64, overwrite bool, user string) (*Upload, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("size must not be negative")
+	}
+	root, err := gameRoot()
+	if err != nil {
+		return nil, err
+	}
+	target, err := resolveLink(root, path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Lstat(target); err == nil {
+		if info.IsDir() {
+			return nil, fmt.Errorf("%s is a directory", path)
+		}
+		if !overwrite {
+			return nil, fmt.Errorf("%s already exists", path)
+		}
+	}
+
+	uploadsMutex.Lock()
+	defer uploadsMutex.Unlock()
+	removeExpiredUploads()
+	if err := os.MkdirAll(uploadsDir(), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	upload := &Upload{
+		ID:        uuid.NewString(),
+		Path:      relativeTo(root, target),
+		Size:      size,
+		Overwrite: overwrite,
+		User:      user,
+		UpdatedAt: time.Now(),
+	}
+	_, dataPath, _ := uploadPaths(upload.ID)
+	if err := os.WriteFile(dataPath, nil, 0600); err != nil {
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+	if err := saveUpload(upload); err != nil {
+		removeUpload(upload.ID)
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+	if size == 0 {
+		return upload, finishUpload(upload)
+	}
+	return upload, nil
+}
+
+// GetUpload returns the state of an upload, Received is where the next chunk has to start
+func GetUpload(id string) (*Upload, error) {
+	uploadsMutex.Lock()
+	defer uploadsMutex.Unlock()
+	return loadUpload(id)
+}
+
+// CancelUpload discards an upload, a chunk that is being written ends with an error
+func CancelUpload(id string) error {
+	uploadsMutex.Lock()
+	defer uploadsMutex.Unlock()
+	if _, err := loadUpload(id); err != nil {
+		return err
+	}
+	removeUpload(id)
+	return nil
+}
+
+// WriteChunk appends a chunk at offset and moves the file into place once it is complete
+func WriteChunk(id string, offset int64, r io.Reader) (*Upload, error) {
+	upload, file, err := claimUpload(id, offset)
+	if err != nil {
+		return upload, err
+	}
+	// one byte more than allowed is read, to tell an oversized chunk apart from an exact one
+	written, copyErr := io.Copy(file, io.LimitReader(r, upload.Size-offset+1))
+	file.Close()
+
+	uploadsMutex.Lock()
+	defer uploadsMutex.Unlock()
+	delete(writingUploads, id)
+	if _, err := loadUpload(id); err != nil {
+		// cancelled while the chunk was copied, the data may have been kept open on removal
+		removeUpload(id)
+		return nil, fmt.Errorf("upload %s was cancelled", id)
+	}
+	if written > upload.Size-offset {
+		return upload, fmt.Errorf("chunk is larger than the %d bytes left of the upload", upload.Size-offset)
+	}
+	// whatever arrived counts, the client resumes after it even if the connection broke
+	upload.Received += written
+	upload.UpdatedAt = time.Now()
+	if err := saveUpload(upload); err != nil {
+		return nil, err
+	}
+	if copyErr != nil {
+		return upload, fmt.Errorf("upload interrupted at byte %d: %w", upload.Received, copyErr)
+	}
+	if upload.Received == upload.Size {
+		if err := finishUpload(upload); err != nil {
+			return upload, failUpload(upload, err)
+		}
+	}
+	return upload, nil
+}
+
+// claimUpload checks a chunk against the upload and marks the upload as being written, the data file is returned at offset
+func claimUpload(id string, offset int64) (*Upload, *os.File, error) {
+	uploadsMutex.Lock()
+	defer uploadsMutex.Unlock()
+
+	upload, err := loadUpload(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if upload.Error != "" {
+		return upload, nil, fmt.Errorf("upload failed (%s), start it again", upload.Error)
+	}
+	if writingUploads[id] {
+		return upload, nil, fmt.Errorf("another chunk of upload %s is still being written", id)
+	}
+	if offset != upload.Received {
+		return upload, nil, ErrOffsetMismatch{Expected: upload.Received}
+	}
+	_, dataPath, _ := uploadPaths(id)
+	file, err := os.OpenFile(dataPath, os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open upload: %w", err)
+	}
+	if err := file.Truncate(offset); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to prepare upload: %w", err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to prepare upload: %w", err)
+	}
+	writingUploads[id] = true
+	return upload, file, nil
+}
+
+// failUpload drops the data of an upload that can't be completed and keeps it marked failed until it expires, uploadsMutex must be held
+func failUpload(upload *Upload, cause error) error {
+	_, dataPath, _ := uploadPaths(upload.ID)
+	os.Remove(dataPath)
+	upload.Error = cause.Error()
+	upload.UpdatedAt = time.Now()
+	if err := saveUpload(upload); err != nil {
+		removeUpload(upload.ID)
+	}
+	logger.Core.Warn(fmt.Sprintf("Upload of %s by %s failed: %v", upload.Path, upload.User, cause))
+	return cause
+}
+
+// finishUpload moves the complete data to the target, uploadsMutex must be held
+func finishUpload(upload *Upload) error {
+	root, err := gameRoot()
+	if err != nil {
+		return err
+	}
+	// checked again, the directory may have changed while the upload was running
+	target, err := resolveLink(root, upload.Path)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Lstat(target); err == nil && (info.IsDir() || !upload.Overwrite) {
+		return fmt.Errorf("%s already exists", upload.Path)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", upload.Path, err)
+	}
+	_, dataPath, _ := uploadPaths(upload.ID)
+	if err := os.Rename(dataPath, target); err != nil {
+		// SSUI/uploads may be on another filesystem than the game directory
+		if err := copyFile(dataPath, target); err != nil {
+			return fmt.Errorf("failed to move upload to %s: %w", upload.Path, err)
+		}
+	}
+	os.Chmod(target, 0644)
+	upload.Done = true
+	removeUpload(upload.ID)
+	logger.Core.Info(fmt.Sprintf("%s uploaded %s (%d bytes) to the game directory", upload.User, upload.Path, upload.Size))
+	return nil
+}
+
+func copyFile(source, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmpPath := target + ".ssui-upload"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, target)
+}
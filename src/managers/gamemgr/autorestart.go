@@ -1,7 +1,10 @@
 package gamemgr
 
 import (
+	"fmt"
+	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
@@ -12,8 +15,41 @@ import (
 var (
 	autoRestartDone chan struct{}
 	// other local vars are defined in processmanagement.go
+
+	preRestartMu    sync.Mutex
+	preRestartHooks = make(map[string]func() error)
 )
 
+// RegisterPreRestartHook adds a task that runs during automatic restarts while the server is stopped, like updating mods.
+// The packages providing these tasks import gamemgr, so they are registered here. Registering a name again replaces its hook.
+func RegisterPreRestartHook(name string, hook func() error) {
+	preRestartMu.Lock()
+	defer preRestartMu.Unlock()
+	preRestartHooks[name] = hook
+}
+
+// runPreRestartHooks runs the registered hooks by name, a failing hook is logged and doesn't stop the restart
+func runPreRestartHooks() {
+	preRestartMu.Lock()
+	names := make([]string, 0, len(preRestartHooks))
+	for name := range preRestartHooks {
+		names = append(names, name)
+	}
+	hooks := make(map[string]func() error, len(preRestartHooks))
+	for name, hook := range preRestartHooks {
+		hooks[name] = hook
+	}
+	preRestartMu.Unlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		logger.Core.Debug("Auto-restart: running pre-restart task " + name)
+		if err := hooks[name](); err != nil {
+			logger.Core.Error(fmt.Sprintf("Auto-restart: pre-restart task %s failed: %s", name, err.Error()))
+		}
+	}
+}
+
 // startAutoRestart runs a goroutine that restarts the server either after a specified duration in minutes
 // or at a specific time of day (HH:MM) every day.
 func startAutoRestart(schedule string, done chan struct{}) {
@@ -79,6 +115,8 @@ func startAutoRestart(schedule string, done chan struct{}) {
 				return
 			}
 
+			runPreRestartHooks()
+
 			logger.Core.Info("Auto-restart: waiting 5 seconds before restarting")
 			time.Sleep(5 * time.Second)
 
@@ -136,6 +174,8 @@ func scheduleDailyRestart(t time.Time, done chan struct{}) {
 				continue
 			}
 
+			runPreRestartHooks()
+
 			logger.Core.Debug("Daily auto-restart: waiting 5 seconds before restarting")
 			time.Sleep(5 * time.Second)
 
@@ -0,0 +1,236 @@
+// command.go
+package gamemgr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/runfile"
+)
+
+/*
+Server Command
+- BuildServerCommand assembles executable, args, working directory and environment exactly like InternalStartServer uses them
+- The command of the running server is kept, so a preview can tell whether a restart is needed to apply changes
+- Previews mask secret args and redact registered secrets from the environment
+*/
+
+// ServerCommand is everything needed to start the gameserver process
+type ServerCommand struct {
+	Executable string   `json:"executable"`
+	Args       []string `json:"args"`
+	Dir        string   `json:"working_directory"`
+	Env        []string `json:"environment"` // only the variables SSUI sets on top of its own environment
+	fullEnv    []string // passed to the process, nil means the SSUI environment is inherited
+}
+
+// CommandChange is one difference between the running and the previewed command
+type CommandChange struct {
+	Field   string `json:"field"` // "executable", "working_directory", "arg" or "environment"
+	Op      string `json:"op"`    // "+" (only in the preview), "-" (only in the running command) or "~" (changed)
+	Running string `json:"running,omitempty"`
+	Preview string `json:"preview,omitempty"`
+}
+
+// CommandPreview compares what the next start would run with what is running now
+type CommandPreview struct {
+	Preview         *ServerCommand  `json:"preview,omitempty"`
+	PreviewError    string          `json:"preview_error,omitempty"`
+	ServerRunning   bool            `json:"server_running"`
+	Running         *ServerCommand  `json:"running,omitempty"` // nil if the server was not started by this SSUI instance
+	RestartRequired bool            `json:"restart_required"`
+	ScheduledPreset string          `json:"scheduled_preset,omitempty"` // due scheduled preset the next start applies, included in Preview
+	Changes         []CommandChange `json:"changes"`
+}
+
+var (
+	runningCommand       *ServerCommand // guarded by mu, set when the server was started
+	runningCommandMasked *ServerCommand // masked when the server was started, secrets may have changed since
+)
+
+// BuildServerCommand builds the command the next server start would run, without starting anything
+func BuildServerCommand() (*ServerCommand, error) {
+	args, err := runfile.BuildCommandArgs()
+	if err != nil {
+		return nil, err
+	}
+	return buildServerCommand(args)
+}
+
+func buildServerCommand(args []string) (*ServerCommand, error) {
+	executable, err := runfile.CurrentRunfile.GetExecutable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get executable path from runfile: %w", err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("error getting current working directory: %v", err)
+	}
+
+	command := &ServerCommand{
+		Executable: executable,
+		Args:       args,
+		Dir:        filepath.Join(cwd, config.GetRunfileIdentifier()),
+		Env:        []string{},
+	}
+	if config.GetIsBepInExEnabled() && runtime.GOOS == "linux" {
+		// Set up SSCM (BepInEx/Doorstop) environment
+		command.fullEnv, err = SetupBepInExEnvironment()
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up SSCM environment: %v", err)
+		}
+		command.Env = addedEnv(command.fullEnv)
+	}
+	return command, nil
+}
+
+// addedEnv returns the variables of env that are not set the same way in the SSUI process
+func addedEnv(env []string) []string {
+	own := make(map[string]bool)
+	for _, variable := range os.Environ() {
+		own[variable] = true
+	}
+	added := make(map[string]string)
+	for _, variable := range env {
+		if !own[variable] {
+			name, _, _ := strings.Cut(variable, "=")
+			added[name] = variable // later entries win, like they do for the process
+		}
+	}
+	result := make([]string, 0, len(added))
+	for _, variable := range added {
+		result = append(result, variable)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// masked returns a copy of the command that is safe to show
+func (c *ServerCommand) masked() *ServerCommand {
+	masked := &ServerCommand{Executable: c.Executable, Args: runfile.MaskSecretArgs(c.Args), Dir: c.Dir}
+	masked.Env = make([]string, len(c.Env))
+	for i, variable := range c.Env {
+		masked.Env[i] = logger.Redact(variable)
+	}
+	return masked
+}
+
+// GetCommandPreview builds the next command and diffs it against the running one
+func GetCommandPreview() CommandPreview {
+	preview := CommandPreview{Changes: []CommandChange{}}
+	// the start applies a due scheduled preset before building its command, the preview has to as well
+	args, presetName, err := runfile.BuildPreviewCommandArgs()
+	preview.ScheduledPreset = presetName
+	var next *ServerCommand
+	if err == nil {
+		next, err = buildServerCommand(args)
+	}
+	if err != nil {
+		preview.PreviewError = err.Error()
+	} else {
+		preview.Preview = next.masked()
+	}
+
+	mu.Lock()
+	preview.ServerRunning = internalIsServerRunningNoLock()
+	running, runningMasked := runningCommand, runningCommandMasked
+	mu.Unlock()
+
+	if !preview.ServerRunning || running == nil {
+		return preview
+	}
+	preview.Running = runningMasked
+	if next != nil {
+		preview.Changes = diffCommands(running, runningMasked, next, preview.Preview)
+		preview.RestartRequired = len(preview.Changes) > 0
+	}
+	return preview
+}
+
+// diffCommands compares the raw commands and reports the differences with the masked values
+func diffCommands(running, runningMasked, next, nextMasked *ServerCommand) []CommandChange {
+	changes := []CommandChange{}
+	if running.Executable != next.Executable {
+		changes = append(changes, CommandChange{Field: "executable", Op: "~", Running: running.Executable, Preview: next.Executable})
+	}
+	if running.Dir != next.Dir {
+		changes = append(changes, CommandChange{Field: "working_directory", Op: "~", Running: running.Dir, Preview: next.Dir})
+	}
+	changes = append(changes, diffArgs(running.Args, runningMasked.Args, next.Args, nextMasked.Args)...)
+
+	runningEnv := envMap(running.Env, runningMasked.Env)
+	nextEnv := envMap(next.Env, nextMasked.Env)
+	names := make([]string, 0, len(runningEnv)+len(nextEnv))
+	for name := range runningEnv {
+		names = append(names, name)
+	}
+	for name := range nextEnv {
+		if _, ok := runningEnv[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		before, inRunning := runningEnv[name]
+		after, inNext := nextEnv[name]
+		switch {
+		case !inNext:
+			changes = append(changes, CommandChange{Field: "environment", Op: "-", Running: before[1]})
+		case !inRunning:
+			changes = append(changes, CommandChange{Field: "environment", Op: "+", Preview: after[1]})
+		case before[0] != after[0]:
+			changes = append(changes, CommandChange{Field: "environment", Op: "~", Running: before[1], Preview: after[1]})
+		}
+	}
+	return changes
+}
+
+// envMap maps variable names to their raw and masked assignment
+func envMap(raw, masked []string) map[string][2]string {
+	variables := make(map[string][2]string)
+	for i, variable := range raw {
+		name, _, _ := strings.Cut(variable, "=")
+		variables[name] = [2]string{variable, masked[i]}
+	}
+	return variables
+}
+
+// diffArgs is a longest common subsequence diff of two argument lists
+func diffArgs(before, beforeMasked, after, afterMasked []string) []CommandChange {
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var changes []CommandChange
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			i++
+			j++
+		case i < len(before) && (j == len(after) || lcs[i+1][j] >= lcs[i][j+1]):
+			changes = append(changes, CommandChange{Field: "arg", Op: "-", Running: beforeMasked[i]})
+			i++
+		default:
+			changes = append(changes, CommandChange{Field: "arg", Op: "+", Preview: afterMasked[j]})
+			j++
+		}
+	}
+	return changes
+}
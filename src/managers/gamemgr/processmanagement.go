@@ -2,7 +2,9 @@
 package gamemgr
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -34,46 +36,37 @@ func InternalStartServer() error {
 		return fmt.Errorf("server is already running")
 	}
 
-	args, err := runfile.BuildCommandArgs()
+	// A preset scheduled for this start replaces the values before the command is built
+	runfile.ApplyScheduledPresets()
+
+	command, err := BuildServerCommand()
 	if err != nil {
-		logger.Core.Error("Failed to build command args: " + err.Error())
+		logger.Core.Error("Failed to build server command: " + err.Error())
 		return err
 	}
 
-	executable, err := runfile.CurrentRunfile.GetExecutable()
-	if err != nil {
-		logger.Core.Error("Failed to get executable path from runfile: " + err.Error())
+	// Config files the runfile templates from its args must be in place before the gameserver reads them
+	if _, err := runfile.RenderTemplates(); err != nil {
+		logger.Core.Error("Failed to render config templates: " + err.Error())
 		return err
 	}
-	executablePath := executable
+
+	warnAboutPortsInUse()
 
 	logger.Core.Info("=== GAMESERVER STARTING ===")
 	logger.Core.Info("BepInEx/Doorstop enabled: " + strconv.FormatBool(config.GetIsBepInExEnabled()))
 
-	if config.GetIsBepInExEnabled() && runtime.GOOS == "linux" {
-
-		var envVars []string
-		// Set up SSCM (BepInEx/Doorstop) environment
-		envVars, err = SetupBepInExEnvironment()
-		if err != nil {
-			return fmt.Errorf("failed to set up SSCM environment: %v", err)
-		}
-		// Create command after environment is set
-		cmd = exec.Command(executablePath, args...)
+	cmd = exec.Command(command.Executable, command.Args...)
+	if command.fullEnv != nil {
 		// Set the environment for the command
-		if envVars != nil {
-			cmd.Env = envVars
-			logger.Core.Info("BepInEx/Doorstop environment configured for server process")
-		}
-	} else {
-		// Use ExePath directly as the command for non-BepInEx or Windows
-		cmd = exec.Command(executablePath, args...)
+		cmd.Env = command.fullEnv
+		logger.Core.Info("BepInEx/Doorstop environment configured for server process")
 	}
 
 	// Log executable and arguments
-	logger.Core.Info("• Executable: " + executablePath)
+	logger.Core.Info("• Executable: " + command.Executable)
 	var formattedArgs []string
-	for _, arg := range args {
+	for _, arg := range runfile.MaskSecretArgs(command.Args) {
 		if strings.ContainsAny(arg, " \t\n\"'") {
 			formattedArgs = append(formattedArgs, `"`+strings.ReplaceAll(arg, `"`, `\"`)+`"`)
 		} else {
@@ -82,16 +75,7 @@ func InternalStartServer() error {
 	}
 	logger.Core.Info("• Arguments: " + strings.Join(formattedArgs, " "))
 
-	// get current working directory of SSUI
-	cwd, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("error getting current working directory: %v", err)
-	}
-	childWD := filepath.Join(cwd, config.GetRunfileIdentifier())
-
-	logger.Core.Debug("Child working directory: " + childWD)
-
-	cmd.Dir = childWD
+	cmd.Dir = command.Dir
 	logger.Core.Debug("Set gamservers working directory to: " + cmd.Dir)
 	// Handle log reading based on GetGameLogFromLogFile
 	if config.GetGameLogFromLogFile() {
@@ -138,8 +122,13 @@ func InternalStartServer() error {
 		logger.Core.Debug("Created pipes")
 
 		// Start reading stdout and stderr pipes
-		go readPipe(stdout)
-		go readPipe(stderr)
+		// the archive is closed once both pipes hit EOF, which happens when the process exits or is stopped
+		archive := openConsoleArchive()
+		var pipes sync.WaitGroup
+		pipes.Add(2)
+		go func() { readPipe(stdout); pipes.Done() }()
+		go func() { readPipe(stderr); pipes.Done() }()
+		go func() { pipes.Wait(); closeConsoleArchive(archive) }()
 	}
 
 	// Monitor process exit
@@ -156,6 +145,7 @@ func InternalStartServer() error {
 
 	// Create a UUID for this specific run
 	createGameServerUUID()
+	runningCommand, runningCommandMasked = command, command.masked()
 
 	// Start auto-restart goroutine if AutoRestartServerTimer is set greater than 0
 	if config.GetAutoRestartServerTimer() != "0" {
@@ -170,6 +160,34 @@ func InternalStartServer() error {
 	return nil
 }
 
+// warnAboutPortsInUse logs a warning for every port arg another process is already listening on. It doesn't block the start, the check can't see every kind of conflict.
+func warnAboutPortsInUse() {
+	for flag, port := range runfile.GetPorts() {
+		address := ":" + strconv.Itoa(port)
+		listener, err := net.Listen("tcp", address)
+		if err == nil {
+			listener.Close()
+		}
+		logPortCheck(port, flag, "tcp", err)
+		conn, err := net.ListenPacket("udp", address)
+		if err == nil {
+			conn.Close()
+		}
+		logPortCheck(port, flag, "udp", err)
+	}
+}
+
+// logPortCheck reports a failed test bind. Without permission to bind privileged ports SSUI can't tell whether the port is free.
+func logPortCheck(port int, flag, network string, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, os.ErrPermission):
+		logger.Core.Debug(fmt.Sprintf("Port %d (%s) could not be checked (%s), SSUI is not allowed to bind it: %v", port, flag, network, err))
+	default:
+		logger.Core.Warn(fmt.Sprintf("Port %d (%s) seems to be in use already (%s): %v", port, flag, network, err))
+	}
+}
+
 func InternalStopServer() error {
 	mu.Lock()
 	defer mu.Unlock()
@@ -6,20 +6,76 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/core/ssestream"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
 )
 
+// consoleArchiveFile keeps the piped console output of the current run, so the detector can replay it after a backend reload
+const consoleArchiveFile = "ssui-console.log"
+
+var (
+	consoleArchive   *os.File
+	consoleArchiveMu sync.Mutex
+)
+
+// CurrentLogPath returns the log file that holds the output of the current (or last) gameserver run
+func CurrentLogPath() string {
+	if config.GetGameLogFromLogFile() {
+		return filepath.Join(config.GetRunfileIdentifier(), "gameserver.log")
+	}
+	return filepath.Join(config.GetRunfileIdentifier(), consoleArchiveFile)
+}
+
+// openConsoleArchive truncates the console archive for a new run and returns it, nil if it couldn't be created
+func openConsoleArchive() *os.File {
+	consoleArchiveMu.Lock()
+	defer consoleArchiveMu.Unlock()
+	if consoleArchive != nil {
+		consoleArchive.Close()
+	}
+	file, err := os.Create(filepath.Join(config.GetRunfileIdentifier(), consoleArchiveFile))
+	if err != nil {
+		logger.Core.Warn("Failed to create console archive, log replay will not be available: " + err.Error())
+		consoleArchive = nil
+		return nil
+	}
+	consoleArchive = file
+	return file
+}
+
+// closeConsoleArchive closes the archive of a finished run, unless a newer run already replaced it
+func closeConsoleArchive(file *os.File) {
+	consoleArchiveMu.Lock()
+	defer consoleArchiveMu.Unlock()
+	if file == nil || consoleArchive != file {
+		return
+	}
+	consoleArchive.Close()
+	consoleArchive = nil
+}
+
+func archiveConsoleLine(line string) {
+	consoleArchiveMu.Lock()
+	defer consoleArchiveMu.Unlock()
+	if consoleArchive != nil {
+		consoleArchive.WriteString(line + "\n")
+	}
+}
+
 // readPipe for Windows
 func readPipe(pipe io.ReadCloser) {
 	scanner := bufio.NewScanner(pipe)
 	logger.Core.Debug("Started reading pipe")
 	for scanner.Scan() {
-		output := scanner.Text()
+		output := logger.Redact(scanner.Text())
+		archiveConsoleLine(output)
 		ssestream.BroadcastConsoleOutput(output)
 	}
 	if err := scanner.Err(); err != nil {
@@ -92,7 +148,7 @@ func tailLogFile(logFilePath string) {
 	go func() {
 		defer pipe.Close() // Close pipe when goroutine exits
 		for scanner.Scan() {
-			output := scanner.Text()
+			output := logger.Redact(scanner.Text())
 			ssestream.BroadcastConsoleOutput(output)
 		}
 		if err := scanner.Err(); err != nil {
@@ -0,0 +1,423 @@
+// history.go
+package playermgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/detectionmgr"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/gamemgr"
+)
+
+/*
+Persistent Player Session History
+- Records PLAYER_CONNECTING, PLAYER_READY and PLAYER_DISCONNECT events from the detector
+- Builds per-player sessions with first seen, last seen and total playtime
+- Tracks peak concurrent players per day
+- Persists everything to a JSON file so history survives SSUI restarts
+- While players are online, a heartbeat records every heartbeatInterval that the gameserver is still running
+- Sessions left open when the gameserver or SSUI restarts or crashes are closed at the last time the server
+  was known to be alive, so they keep their playtime
+- After an SSUI restart, sessions of players the log replay still shows as connected stay open, see ResumeSessions
+*/
+
+const (
+	maxSessionsPerPlayer = 200
+	heartbeatInterval    = time.Minute
+)
+
+// PlayerSession is a single visit of a player on the gameserver
+type PlayerSession struct {
+	ConnectedAt    time.Time  `json:"connectedAt"`
+	ReadyAt        *time.Time `json:"readyAt,omitempty"`
+	DisconnectedAt *time.Time `json:"disconnectedAt,omitempty"`
+	EndReason      string     `json:"endReason,omitempty"` // "disconnect" or "server_restart"
+}
+
+// PlayerRecord holds everything known about a single player
+type PlayerRecord struct {
+	SteamID         string          `json:"steamID"`
+	Username        string          `json:"username"`
+	KnownNames      []string        `json:"knownNames"`
+	FirstSeen       time.Time       `json:"firstSeen"`
+	LastSeen        time.Time       `json:"lastSeen"`
+	PlaytimeSeconds int64           `json:"playtimeSeconds"`
+	SessionCount    int             `json:"sessionCount"`
+	Sessions        []PlayerSession `json:"sessions"`
+}
+
+// PlayerSummary is a PlayerRecord without the session list, used for listings
+type PlayerSummary struct {
+	SteamID         string    `json:"steamID"`
+	Username        string    `json:"username"`
+	FirstSeen       time.Time `json:"firstSeen"`
+	LastSeen        time.Time `json:"lastSeen"`
+	PlaytimeSeconds int64     `json:"playtimeSeconds"`
+	SessionCount    int       `json:"sessionCount"`
+	Online          bool      `json:"online"`
+}
+
+// DailyPeak is the highest number of concurrently connected players on a day
+type DailyPeak struct {
+	Date string `json:"date"`
+	Peak int    `json:"peak"`
+}
+
+type historyFile struct {
+	Players    map[string]*PlayerRecord `json:"players"`
+	DailyPeaks map[string]int           `json:"dailyPeaks"`       // YYYY-MM-DD -> peak concurrent players
+	AliveAt    time.Time                `json:"aliveAt,omitzero"` // last heartbeat or player event of a running gameserver
+}
+
+var (
+	historyMu sync.Mutex
+	history   = historyFile{
+		Players:    make(map[string]*PlayerRecord),
+		DailyPeaks: make(map[string]int),
+	}
+	online = make(map[string]bool) // SteamIDs with an open session in the current run
+)
+
+// InitPlayerHistory loads the stored history and registers the detector handlers that keep it up to date
+func InitPlayerHistory(detector *detectionmgr.Detector) {
+	if err := loadHistory(); err != nil {
+		logger.Detection.Error("Failed to load player history: " + err.Error())
+	}
+	detectionmgr.AddHandler(detector, detectionmgr.EventPlayerConnecting, func(event detectionmgr.Event) {
+		if event.PlayerInfo != nil {
+			recordConnecting(event.PlayerInfo.SteamID, event.PlayerInfo.Username, eventTime(event))
+		}
+	})
+	detectionmgr.AddHandler(detector, detectionmgr.EventPlayerReady, func(event detectionmgr.Event) {
+		if event.PlayerInfo != nil {
+			recordReady(event.PlayerInfo.SteamID, event.PlayerInfo.Username, eventTime(event))
+		}
+	})
+	detectionmgr.AddHandler(detector, detectionmgr.EventPlayerDisconnect, func(event detectionmgr.Event) {
+		if event.PlayerInfo != nil {
+			recordDisconnect(event.PlayerInfo.SteamID, event.PlayerInfo.Username, eventTime(event))
+		}
+	})
+	detectionmgr.AddHandler(detector, detectionmgr.EventServerRunning, func(event detectionmgr.Event) {
+		closeOpenSessions(eventTime(event))
+	})
+	go runHeartbeat()
+	logger.Detection.Debug("Player history initialized")
+}
+
+// runHeartbeat persists that the gameserver is alive while sessions are open, open sessions are closed at that time after a crash
+func runHeartbeat() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !gamemgr.InternalIsServerRunning() {
+			continue
+		}
+		historyMu.Lock()
+		if len(online) > 0 {
+			history.AliveAt = time.Now()
+			saveHistoryLocked()
+		}
+		historyMu.Unlock()
+	}
+}
+
+// lastAlive returns when the player of an open session was last known to be on the server. Sessions end no later
+// than before, the start of the next run, unless before is zero. Must be called with historyMu held.
+func lastAlive(player *PlayerRecord, before time.Time) time.Time {
+	at := player.LastSeen
+	if history.AliveAt.After(at) && (before.IsZero() || history.AliveAt.Before(before)) {
+		at = history.AliveAt
+	}
+	return at
+}
+
+func eventTime(event detectionmgr.Event) time.Time {
+	if t, err := time.Parse(time.RFC3339, event.Timestamp); err == nil {
+		return t
+	}
+	return time.Now()
+}
+
+// getOrCreatePlayer must be called with historyMu held
+func getOrCreatePlayer(steamID, username string, at time.Time) *PlayerRecord {
+	player, ok := history.Players[steamID]
+	if !ok {
+		player = &PlayerRecord{
+			SteamID:    steamID,
+			Username:   username,
+			KnownNames: []string{},
+			FirstSeen:  at,
+			Sessions:   []PlayerSession{},
+		}
+		history.Players[steamID] = player
+	}
+	if username != "" {
+		player.Username = username
+		known := false
+		for _, name := range player.KnownNames {
+			if name == username {
+				known = true
+				break
+			}
+		}
+		if !known {
+			player.KnownNames = append(player.KnownNames, username)
+		}
+	}
+	player.LastSeen = at
+	if at.After(history.AliveAt) {
+		history.AliveAt = at
+	}
+	return player
+}
+
+// openSession returns the open session of a player, or nil. Must be called with historyMu held.
+func openSession(player *PlayerRecord) *PlayerSession {
+	if !online[player.SteamID] || len(player.Sessions) == 0 {
+		return nil
+	}
+	last := &player.Sessions[len(player.Sessions)-1]
+	if last.DisconnectedAt != nil {
+		return nil
+	}
+	return last
+}
+
+// startSession must be called with historyMu held
+func startSession(player *PlayerRecord, at time.Time) *PlayerSession {
+	player.Sessions = append(player.Sessions, PlayerSession{ConnectedAt: at})
+	if len(player.Sessions) > maxSessionsPerPlayer {
+		player.Sessions = player.Sessions[len(player.Sessions)-maxSessionsPerPlayer:]
+	}
+	player.SessionCount++
+	online[player.SteamID] = true
+
+	day := at.Format("2006-01-02")
+	if len(online) > history.DailyPeaks[day] {
+		history.DailyPeaks[day] = len(online)
+	}
+	return &player.Sessions[len(player.Sessions)-1]
+}
+
+// endSession must be called with historyMu held
+func endSession(player *PlayerRecord, session *PlayerSession, at time.Time, reason string) {
+	session.DisconnectedAt = &at
+	session.EndReason = reason
+	start := session.ConnectedAt
+	if session.ReadyAt != nil {
+		start = *session.ReadyAt
+	}
+	if at.After(start) {
+		player.PlaytimeSeconds += int64(at.Sub(start).Seconds())
+	}
+	delete(online, player.SteamID)
+}
+
+func recordConnecting(steamID, username string, at time.Time) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	player := getOrCreatePlayer(steamID, username, at)
+	if session := openSession(player); session != nil {
+		// A reconnect without a disconnect line, close the stale session first
+		endSession(player, session, at, "server_restart")
+	}
+	startSession(player, at)
+	saveHistoryLocked()
+}
+
+func recordReady(steamID, username string, at time.Time) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	player := getOrCreatePlayer(steamID, username, at)
+	session := openSession(player)
+	if session == nil {
+		session = startSession(player, at)
+	}
+	if session.ReadyAt == nil {
+		session.ReadyAt = &at
+	}
+	saveHistoryLocked()
+}
+
+func recordDisconnect(steamID, username string, at time.Time) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	player := getOrCreatePlayer(steamID, username, at)
+	if session := openSession(player); session != nil {
+		endSession(player, session, at, "disconnect")
+	}
+	saveHistoryLocked()
+}
+
+// closeOpenSessions ends every open session at the last time the server was known to be alive, used when a new server run starts
+func closeOpenSessions(runStart time.Time) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	if len(online) == 0 {
+		return
+	}
+	for steamID := range online {
+		if player, ok := history.Players[steamID]; ok {
+			if session := openSession(player); session != nil {
+				endSession(player, session, lastAlive(player, runStart), "server_restart")
+			}
+		}
+	}
+	online = make(map[string]bool)
+	saveHistoryLocked()
+}
+
+func loadHistory() error {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	data, err := os.ReadFile(config.GetPlayerHistoryFilePath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read player history: %w", err)
+	}
+
+	var loaded historyFile
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to decode player history: %w", err)
+	}
+	if loaded.Players == nil {
+		loaded.Players = make(map[string]*PlayerRecord)
+	}
+	if loaded.DailyPeaks == nil {
+		loaded.DailyPeaks = make(map[string]int)
+	}
+	history = loaded
+	online = make(map[string]bool)
+
+	// Sessions that were open when SSUI stopped stay open until ResumeSessions knows who is still connected
+	for _, player := range history.Players {
+		if len(player.Sessions) > 0 && player.Sessions[len(player.Sessions)-1].DisconnectedAt == nil {
+			online[player.SteamID] = true
+		}
+	}
+	return nil
+}
+
+// ResumeSessions is called once after the startup log replay with the players it found connected. Their open sessions
+// continue, the other sessions left open by the last SSUI run are closed at the last time the server was known to be alive.
+func ResumeSessions(connected map[string]string) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	for steamID := range online {
+		if _, ok := connected[steamID]; ok {
+			continue
+		}
+		if player, ok := history.Players[steamID]; ok {
+			if session := openSession(player); session != nil {
+				endSession(player, session, lastAlive(player, time.Time{}), "server_restart")
+			}
+		}
+		delete(online, steamID)
+	}
+	// connected while SSUI was down, their session starts now so the disconnect is recorded
+	now := time.Now()
+	for steamID, username := range connected {
+		if !online[steamID] {
+			startSession(getOrCreatePlayer(steamID, username, now), now)
+		}
+	}
+	saveHistoryLocked()
+}
+
+// saveHistoryLocked writes the history atomically. Must be called with historyMu held.
+func saveHistoryLocked() {
+	path := config.GetPlayerHistoryFilePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		logger.Detection.Error("Failed to create player history directory: " + err.Error())
+		return
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		logger.Detection.Error("Failed to encode player history: " + err.Error())
+		return
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		logger.Detection.Error("Failed to write player history: " + err.Error())
+		return
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		logger.Detection.Error("Failed to replace player history: " + err.Error())
+	}
+}
+
+// GetPlayerSummaries returns all known players without their sessions, most recently seen first
+func GetPlayerSummaries() []PlayerSummary {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	summaries := make([]PlayerSummary, 0, len(history.Players))
+	for _, player := range history.Players {
+		summaries = append(summaries, PlayerSummary{
+			SteamID:         player.SteamID,
+			Username:        player.Username,
+			FirstSeen:       player.FirstSeen,
+			LastSeen:        player.LastSeen,
+			PlaytimeSeconds: player.PlaytimeSeconds,
+			SessionCount:    player.SessionCount,
+			Online:          online[player.SteamID],
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].LastSeen.After(summaries[j].LastSeen)
+	})
+	return summaries
+}
+
+// GetPlayerRecord returns a copy of a single player's record including sessions
+func GetPlayerRecord(steamID string) (PlayerRecord, bool) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	player, ok := history.Players[steamID]
+	if !ok {
+		return PlayerRecord{}, false
+	}
+	record := *player
+	record.KnownNames = append([]string{}, player.KnownNames...)
+	record.Sessions = append([]PlayerSession{}, player.Sessions...)
+	return record, true
+}
+
+// GetDailyPeaks returns the peak concurrent players per day for the last n days (all days if n <= 0), oldest first
+func GetDailyPeaks(days int) []DailyPeak {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	cutoff := ""
+	if days > 0 {
+		cutoff = time.Now().AddDate(0, 0, -days+1).Format("2006-01-02")
+	}
+	peaks := make([]DailyPeak, 0, len(history.DailyPeaks))
+	for date, peak := range history.DailyPeaks {
+		if date >= cutoff {
+			peaks = append(peaks, DailyPeak{Date: date, Peak: peak})
+		}
+	}
+	sort.Slice(peaks, func(i, j int) bool {
+		return peaks[i].Date < peaks[j].Date
+	})
+	return peaks
+}
@@ -2,6 +2,7 @@ package steamcmd
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"maps"
 	"os"
@@ -9,7 +10,6 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
-	"strings"
 	"sync"
 	"time"
 
@@ -93,31 +93,22 @@ func getAppInfo() error {
 		steamcmddir = SteamCMDWindowsDir
 	}
 
+	login, err := loginArgs(serverLoginRequired())
+	if err != nil {
+		steamMu.Unlock()
+		return err
+	}
+
 	// Build SteamCMD command with +app_info_update to ensure fresh data
-	cmd := exec.Command(filepath.Join(steamcmddir, executable), "+login", "anonymous", "+app_info_update", "1", "+app_info_print", appid, "+quit")
+	args := append(login, "+app_info_update", "1", "+app_info_print", appid, "+quit")
+	cmd := exec.Command(filepath.Join(steamcmddir, executable), args...)
 
-	// Capture output instead of printing directly
+	// Capture output instead of printing directly, stdout is set by runWithLogin
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	if runtime.GOOS == "linux" {
-		env := os.Environ()
-		// Replace or set HOME
-		newEnv := make([]string, 0, len(env)+1)
-		foundHome := false
-		for _, e := range env {
-			if !strings.HasPrefix(e, "HOME=") {
-				newEnv = append(newEnv, e)
-			} else {
-				newEnv = append(newEnv, "HOME="+currentDir)
-				foundHome = true
-			}
-		}
-		if !foundHome {
-			newEnv = append(newEnv, "HOME="+currentDir)
-		}
-		cmd.Env = newEnv
+		cmd.Env = steamCMDEnv(currentDir)
 	}
 
 	// Log the command
@@ -126,8 +117,13 @@ func getAppInfo() error {
 	//	logger.Install.Debug("🕑 Running SteamCMD for app info: " + cmdString)
 	//}
 	// Run the command
-	err = cmd.Run()
+	// the poller runs in the background, it must not hold steamMu while waiting for a Steam Guard code
+	err = runUnattended(cmd, &stdout)
 	if err != nil {
+		steamMu.Unlock()
+		if errors.Is(err, ErrSteamGuardRequired) {
+			return fmt.Errorf("app info poll skipped: %w", err)
+		}
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			logger.Install.Errorf("❌ SteamCMD app info failed (code %d): %s\n", exitErr.ExitCode(), stderr.String())
 			return fmt.Errorf("SteamCMD app info failed with exit code %d: %w", exitErr.ExitCode(), err)
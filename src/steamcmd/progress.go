@@ -0,0 +1,223 @@
+package steamcmd
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/ssestream"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+/*
+SteamCMD Progress
+- Game updates and Workshop downloads parse SteamCMD's output into a Progress while it runs
+- "Update state (0x61) downloading, progress: 12.34 (1234 / 10000)" lines give the phase, percent and bytes
+- Every change is kept as the current progress for /api/v2/steamcmd/status and broadcast on the SteamCMD progress
+  SSE stream, at most every progressInterval unless the state changes
+- Other packages follow the progress with RegisterProgressListener, discordbot uses it for its progress message
+- SteamCMD can exit 0 after "Error! App '...' state is 0x... after update job", a run with such a line is marked failed
+*/
+
+const (
+	progressInterval = time.Second
+	maxProgressLine  = 512 // longer lines only keep their end, like the prompt watcher
+)
+
+// Progress states, Phase holds SteamCMD's own wording
+const (
+	ProgressStarting      = "starting"
+	ProgressLoggingIn     = "logging_in"
+	ProgressSteamGuard    = "waiting_for_steam_guard"
+	ProgressPreallocating = "preallocating"
+	ProgressDownloading   = "downloading"
+	ProgressValidating    = "validating"
+	ProgressCommitting    = "committing"
+	ProgressDone          = "done"
+	ProgressFailed        = "failed"
+)
+
+// Progress describes a running or the last finished SteamCMD run
+type Progress struct {
+	Operation  string    `json:"operation"` // "app_update" or "workshop"
+	State      string    `json:"state"`
+	Phase      string    `json:"phase,omitempty"`
+	Percent    float64   `json:"percent"`
+	BytesDone  int64     `json:"bytes_done"`
+	BytesTotal int64     `json:"bytes_total"`
+	Item       string    `json:"item,omitempty"` // Workshop item being downloaded
+	Message    string    `json:"message,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Running    bool      `json:"running"`
+	StartedAt  time.Time `json:"started_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+}
+
+var (
+	progressMu        sync.Mutex
+	currentProgress   *Progress
+	lastBroadcast     time.Time
+	progressListeners []func(Progress)
+
+	updateStatePattern  = regexp.MustCompile(`Update state \(0x[0-9a-fA-F]+\) ([^,]+), progress: ([\d.]+) \((\d+) / (\d+)\)`)
+	downloadItemPattern = regexp.MustCompile(`Downloading item (\d+)`)
+)
+
+// RegisterProgressListener calls fn with every broadcast progress. fn must not block, SteamCMD's output waits for it.
+func RegisterProgressListener(fn func(Progress)) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	progressListeners = append(progressListeners, fn)
+}
+
+// GetProgress returns the progress of the running or the last SteamCMD run, nil if there was none yet
+func GetProgress() *Progress {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	if currentProgress == nil {
+		return nil
+	}
+	progress := *currentProgress
+	return &progress
+}
+
+// updateProgress changes the current progress of a running SteamCMD and publishes it
+func updateProgress(change func(p *Progress)) {
+	progressMu.Lock()
+	if currentProgress == nil || !currentProgress.Running {
+		progressMu.Unlock()
+		return
+	}
+	previousState := currentProgress.State
+	change(currentProgress)
+	currentProgress.UpdatedAt = time.Now()
+	publish := currentProgress.State != previousState || !currentProgress.Running || time.Since(lastBroadcast) >= progressInterval
+	progress := *currentProgress
+	listeners := progressListeners
+	if publish {
+		lastBroadcast = time.Now()
+	}
+	progressMu.Unlock()
+
+	if publish {
+		publishProgress(progress, listeners)
+	}
+}
+
+func publishProgress(progress Progress, listeners []func(Progress)) {
+	if data, err := json.Marshal(progress); err == nil {
+		ssestream.BroadcastSteamCMDProgress(string(data))
+	}
+	for _, listener := range listeners {
+		listener(progress)
+	}
+}
+
+// progressTracker parses SteamCMD's output line by line
+type progressTracker struct {
+	line      []byte
+	errorLine string // last "Error!" line, fails the run even if SteamCMD exits 0
+}
+
+// startProgress begins tracking a SteamCMD run
+func startProgress(operation string) *progressTracker {
+	now := time.Now()
+	progressMu.Lock()
+	currentProgress = &Progress{Operation: operation, State: ProgressStarting, Running: true, StartedAt: now, UpdatedAt: now}
+	progress := *currentProgress
+	listeners := progressListeners
+	lastBroadcast = now
+	progressMu.Unlock()
+	publishProgress(progress, listeners)
+	return &progressTracker{}
+}
+
+func (t *progressTracker) Write(data []byte) (int, error) {
+	for _, b := range data {
+		if b != '\n' && b != '\r' {
+			t.line = append(t.line, b)
+			if len(t.line) > maxProgressLine {
+				t.line = t.line[len(t.line)-maxProgressLine:]
+			}
+			continue
+		}
+		if len(t.line) > 0 {
+			t.parseLine(string(t.line))
+			t.line = t.line[:0]
+		}
+	}
+	return len(data), nil
+}
+
+// finish marks the run as done or failed
+func (t *progressTracker) finish(err error) {
+	if err == nil && t.errorLine != "" {
+		err = errors.New(t.errorLine)
+	}
+	updateProgress(func(p *Progress) {
+		p.Running = false
+		p.FinishedAt = time.Now()
+		if err != nil {
+			p.State = ProgressFailed
+			p.Error = err.Error()
+			return
+		}
+		p.State = ProgressDone
+		if p.BytesTotal > 0 {
+			p.BytesDone, p.Percent = p.BytesTotal, 100
+		}
+	})
+}
+
+func (t *progressTracker) parseLine(line string) {
+	line = strings.TrimSpace(line)
+	if match := updateStatePattern.FindStringSubmatch(line); match != nil {
+		phase := strings.TrimSpace(match[1])
+		percent, _ := strconv.ParseFloat(match[2], 64)
+		done, _ := strconv.ParseInt(match[3], 10, 64)
+		total, _ := strconv.ParseInt(match[4], 10, 64)
+		updateProgress(func(p *Progress) {
+			p.State, p.Phase = progressState(phase), phase
+			p.Percent, p.BytesDone, p.BytesTotal = percent, done, total
+		})
+		return
+	}
+	switch {
+	case strings.HasPrefix(line, "Logging in user"):
+		updateProgress(func(p *Progress) { p.State, p.Message = ProgressLoggingIn, line })
+	case downloadItemPattern.MatchString(line):
+		item := downloadItemPattern.FindStringSubmatch(line)[1]
+		updateProgress(func(p *Progress) {
+			p.State, p.Item, p.Message = ProgressDownloading, item, line
+			p.Percent, p.BytesDone, p.BytesTotal = 0, 0, 0
+		})
+	case strings.HasPrefix(line, "Error!") || strings.HasPrefix(line, "ERROR!"):
+		t.errorLine = line
+		logger.Install.Debug("SteamCMD: " + line)
+		updateProgress(func(p *Progress) { p.Message = line })
+	case strings.HasPrefix(line, "Success"):
+		logger.Install.Debug("SteamCMD: " + line)
+		updateProgress(func(p *Progress) { p.Message = line })
+	}
+}
+
+// progressState maps SteamCMD's phase names to the progress states
+func progressState(phase string) string {
+	switch {
+	case strings.Contains(phase, "verifying"), strings.Contains(phase, "validating"):
+		return ProgressValidating
+	case strings.Contains(phase, "preallocating"):
+		return ProgressPreallocating
+	case strings.Contains(phase, "committing"):
+		return ProgressCommitting
+	case strings.Contains(phase, "downloading"):
+		return ProgressDownloading
+	default:
+		return phase
+	}
+}
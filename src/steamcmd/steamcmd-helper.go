@@ -17,6 +17,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
 )
 
@@ -44,13 +45,23 @@ func isSymlinkInsideRoot(name, link, root string) bool {
 	return !strings.HasPrefix(rel, "..") && !strings.HasPrefix(abs, string(os.PathSeparator))
 }
 
-func isPathInsideRoot(path, root string) bool {
-	cleanPath := filepath.Clean(path)
-	rel, err := filepath.Rel(root, cleanPath)
-	if err != nil {
-		return false
+// steamCMDEnv returns the environment with HOME replaced by home. SteamCMD keeps its cached login there on Linux.
+func steamCMDEnv(home string) []string {
+	env := os.Environ()
+	newEnv := make([]string, 0, len(env)+1)
+	foundHome := false
+	for _, e := range env {
+		if !strings.HasPrefix(e, "HOME=") {
+			newEnv = append(newEnv, e)
+		} else {
+			newEnv = append(newEnv, "HOME="+home)
+			foundHome = true
+		}
+	}
+	if !foundHome {
+		newEnv = append(newEnv, "HOME="+home)
 	}
-	return !strings.HasPrefix(rel, "..") && !strings.HasPrefix(cleanPath, string(os.PathSeparator))
+	return newEnv
 }
 
 // createSteamCMDDirectory creates the SteamCMD directory.
@@ -192,7 +203,7 @@ func untar(dest string, r io.Reader) error {
 				return fmt.Errorf("failed to create directory %s: %v", target, err)
 			}
 		case tar.TypeReg:
-			if !isPathInsideRoot(target, dest) {
+			if !security.IsPathInsideRoot(target, dest) {
 				return fmt.Errorf("invalid file path attempts to write outside root directory: %s", target)
 			}
 			outFile, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, os.FileMode(header.Mode))
@@ -242,7 +253,7 @@ func Unzip(zipReader io.ReaderAt, size int64, dest string) error {
 		fpath := filepath.Join(dest, f.Name)
 
 		// Ensure the file path is within the destination directory
-		if !isPathInsideRoot(fpath, dest) {
+		if !security.IsPathInsideRoot(fpath, dest) {
 			return fmt.Errorf("invalid file path attempts to write outside root directory: %s", fpath)
 		}
 		relPath, err := filepath.Rel(dest, fpath)
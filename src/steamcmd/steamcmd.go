@@ -105,29 +105,17 @@ func runSteamCMD(steamCMDDir string) (int, error) {
 	logger.Install.Info("✅ Install directory: " + installDir)
 
 	// Build SteamCMD command
-	cmd := buildSteamCMDCommand(steamCMDDir, installDir)
+	cmd, err := buildSteamCMDCommand(steamCMDDir, installDir)
+	if err != nil {
+		logger.Install.Error("❌ " + err.Error())
+		return -1, err
+	}
 
-	// Set output to stdout and stderr
-	cmd.Stdout = os.Stdout
+	// Set output to stderr, stdout is set by runWithLogin
 	cmd.Stderr = os.Stderr
 
 	if runtime.GOOS == "linux" {
-		env := os.Environ()
-		// Replace or set HOME
-		newEnv := make([]string, 0, len(env)+1)
-		foundHome := false
-		for _, e := range env {
-			if !strings.HasPrefix(e, "HOME=") {
-				newEnv = append(newEnv, e)
-			} else {
-				newEnv = append(newEnv, "HOME="+currentDir)
-				foundHome = true
-			}
-		}
-		if !foundHome {
-			newEnv = append(newEnv, "HOME="+currentDir)
-		}
-		cmd.Env = newEnv
+		cmd.Env = steamCMDEnv(currentDir)
 	}
 
 	if config.GetSkipSteamCMD() {
@@ -142,7 +130,9 @@ func runSteamCMD(steamCMDDir string) (int, error) {
 	} else {
 		logger.Install.Info("🕑 Running SteamCMD...")
 	}
-	err = cmd.Run()
+	tracker := startProgress("app_update")
+	err = runWithLogin(cmd, io.MultiWriter(os.Stdout, tracker))
+	tracker.finish(err)
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			logger.Install.Error("❌ SteamCMD exited unsuccessfully: " + err.Error())
@@ -156,15 +146,21 @@ func runSteamCMD(steamCMDDir string) (int, error) {
 }
 
 // buildSteamCMDCommand constructs the SteamCMD command based on the OS.
-func buildSteamCMDCommand(steamCMDDir, installDir string) *exec.Cmd {
+func buildSteamCMDCommand(steamCMDDir, installDir string) (*exec.Cmd, error) {
 	//print the config.GameBranch and config.GameServerAppID
 	logger.Install.Info("🔍 SSUI Runfile Identifier: " + runfile.CurrentRunfile.Meta.Name)
 	logger.Install.Info("🔍 Game Branch: " + config.GetGameBranch())
 	logger.Install.Info("🔍 Game Server App ID: " + runfile.CurrentRunfile.SteamAppID)
 	steamAppID := runfile.CurrentRunfile.SteamAppID
+	login, err := loginArgs(serverLoginRequired())
+	if err != nil {
+		return nil, err
+	}
 
+	args := append([]string{"+force_install_dir", installDir}, login...)
+	args = append(args, "+app_update", steamAppID, "-beta", config.GetGameBranch(), "validate", "+quit")
 	if runtime.GOOS == "windows" {
-		return exec.Command(filepath.Join(steamCMDDir, "steamcmd.exe"), "+force_install_dir", installDir, "+login", "anonymous", "+app_update", steamAppID, "-beta", config.GetGameBranch(), "validate", "+quit")
+		return exec.Command(filepath.Join(steamCMDDir, "steamcmd.exe"), args...), nil
 	}
-	return exec.Command(filepath.Join(steamCMDDir, "steamcmd.sh"), "+force_install_dir", installDir, "+login", "anonymous", "+app_update", steamAppID, "-beta", config.GetGameBranch(), "validate", "+quit")
+	return exec.Command(filepath.Join(steamCMDDir, "steamcmd.sh"), args...), nil
 }
@@ -0,0 +1,269 @@
+package steamcmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/runfile"
+)
+
+/*
+Steam Login
+- Runfiles with SteamLoginRequired (or workshop.login_required for Workshop downloads) log in with the
+  configured Steam account, all others stay anonymous
+- The password is never put on the command line: SteamCMD is started with "+login <user>" and the password
+  is typed into its prompt, so it doesn't show up in the process list or the logs
+- When SteamCMD asks for a Steam Guard code, the request is kept as pending until a code is submitted
+  through the API or steamGuardTimeout passes. SteamCMD caches the login in its HOME, later runs don't ask again
+- Background runs nobody is watching (app info poll, pre-restart Workshop update) use runUnattended, which stops
+  SteamCMD with ErrSteamGuardRequired instead of waiting for a code
+*/
+
+const steamGuardTimeout = 5 * time.Minute
+
+// ErrSteamGuardRequired is returned by unattended runs when the cached login expired and Steam asks for a code
+var ErrSteamGuardRequired = errors.New("the Steam login needs a Steam Guard code, run an update from the UI to enter it")
+
+// ErrNoPendingSteamGuard is returned when a code is submitted while SteamCMD isn't waiting for one
+var ErrNoPendingSteamGuard = errors.New("SteamCMD is not waiting for a Steam Guard code")
+
+// SteamGuardRequest describes a Steam Guard prompt of a running SteamCMD
+type SteamGuardRequest struct {
+	Username    string    `json:"username"`
+	Kind        string    `json:"kind"` // "email" for a code sent by mail, "twofactor" for the mobile authenticator
+	RequestedAt time.Time `json:"requested_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+var (
+	steamGuardMu      sync.Mutex
+	pendingSteamGuard *SteamGuardRequest
+	steamGuardCodes   chan string
+)
+
+// PendingSteamGuard returns the Steam Guard prompt SteamCMD is waiting on, or nil
+func PendingSteamGuard() *SteamGuardRequest {
+	steamGuardMu.Lock()
+	defer steamGuardMu.Unlock()
+	if pendingSteamGuard == nil {
+		return nil
+	}
+	request := *pendingSteamGuard
+	return &request
+}
+
+// SubmitSteamGuardCode passes a code to the waiting SteamCMD
+func SubmitSteamGuardCode(code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" || len(code) > 10 || strings.ContainsAny(code, " \t\r\n") {
+		return fmt.Errorf("invalid Steam Guard code")
+	}
+	steamGuardMu.Lock()
+	defer steamGuardMu.Unlock()
+	if pendingSteamGuard == nil {
+		return ErrNoPendingSteamGuard
+	}
+	pendingSteamGuard = nil
+	steamGuardCodes <- code // buffered, SteamCMD's watcher picks it up
+	return nil
+}
+
+// serverLoginRequired tells whether the current runfile's server can only be downloaded with a Steam account
+func serverLoginRequired() bool {
+	return runfile.CurrentRunfile != nil && runfile.CurrentRunfile.SteamLoginRequired
+}
+
+// loginArgs returns the +login arguments, anonymous unless an account is required
+func loginArgs(accountRequired bool) ([]string, error) {
+	if !accountRequired {
+		return []string{"+login", "anonymous"}, nil
+	}
+	username, _ := config.GetSteamCredentials()
+	if username == "" {
+		return nil, fmt.Errorf("the runfile requires a Steam login, but no Steam account is configured")
+	}
+	return []string{"+login", username}, nil
+}
+
+// steamPassword returns the plain configured password
+func steamPassword() (string, error) {
+	_, stored := config.GetSteamCredentials()
+	if stored == "" || !security.IsEncryptedSecret(stored) {
+		return stored, nil
+	}
+	return security.DecryptSecret(stored)
+}
+
+// promptWatcher passes SteamCMD's output on and answers its login prompts through stdin.
+// Prompts don't end with a newline, so the current line is checked after every write.
+type promptWatcher struct {
+	out         io.Writer
+	stdin       io.WriteCloser
+	cmd         *exec.Cmd
+	line        []byte
+	passwordAsk int
+	guardAsk    int
+	failure     error
+	unattended  bool          // nobody can enter a Steam Guard code
+	done        chan struct{} // closed when SteamCMD exited
+	mu          sync.Mutex
+}
+
+func (p *promptWatcher) Write(data []byte) (int, error) {
+	n, err := p.out.Write(data)
+	if err != nil {
+		return n, err
+	}
+	p.mu.Lock()
+	for _, b := range data {
+		if b == '\n' || b == '\r' {
+			p.line = p.line[:0]
+			continue
+		}
+		p.line = append(p.line, b)
+		if len(p.line) > 512 {
+			p.line = p.line[len(p.line)-512:]
+		}
+	}
+	prompt := strings.ToLower(strings.TrimSpace(string(p.line)))
+	kind := ""
+	switch {
+	case strings.HasSuffix(prompt, "password:"):
+		kind = "password"
+	case strings.HasSuffix(prompt, "steam guard code:"):
+		kind = "email"
+	case strings.HasSuffix(prompt, "two-factor code:"):
+		kind = "twofactor"
+	}
+	if kind != "" {
+		p.line = p.line[:0]
+	}
+	p.mu.Unlock()
+
+	switch kind {
+	case "password":
+		p.answerPassword()
+	case "email", "twofactor":
+		if p.unattended {
+			p.fail(ErrSteamGuardRequired)
+			break
+		}
+		// waiting for the code must not block SteamCMD's output
+		go p.answerSteamGuard(kind)
+	}
+	return len(data), nil
+}
+
+func (p *promptWatcher) answer(value string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	io.WriteString(p.stdin, value+"\n")
+}
+
+func (p *promptWatcher) answerPassword() {
+	p.mu.Lock()
+	p.passwordAsk++
+	attempt := p.passwordAsk
+	p.mu.Unlock()
+	if attempt > 1 {
+		p.fail(fmt.Errorf("Steam rejected the password of the configured account"))
+		return
+	}
+	password, err := steamPassword()
+	if err != nil || password == "" {
+		p.fail(fmt.Errorf("SteamCMD asked for a password, but no usable Steam password is configured"))
+		return
+	}
+	p.answer(password)
+}
+
+func (p *promptWatcher) answerSteamGuard(kind string) {
+	p.mu.Lock()
+	p.guardAsk++
+	attempt := p.guardAsk
+	p.mu.Unlock()
+	if attempt > 3 {
+		p.fail(fmt.Errorf("Steam rejected the Steam Guard code three times"))
+		return
+	}
+
+	username, _ := config.GetSteamCredentials()
+	now := time.Now()
+	steamGuardMu.Lock()
+	pendingSteamGuard = &SteamGuardRequest{Username: username, Kind: kind, RequestedAt: now, ExpiresAt: now.Add(steamGuardTimeout)}
+	steamGuardCodes = make(chan string, 1)
+	codes := steamGuardCodes
+	steamGuardMu.Unlock()
+	updateProgress(func(p *Progress) { p.State, p.Message = ProgressSteamGuard, "Waiting for a Steam Guard code" })
+	logger.Install.Warn(fmt.Sprintf("🔑 SteamCMD needs a Steam Guard code (%s) for %s, submit it in the UI or via POST /api/v2/steam/guard within %s", kind, username, steamGuardTimeout))
+
+	select {
+	case code := <-codes:
+		logger.Install.Info("🔑 Steam Guard code submitted, passing it to SteamCMD")
+		updateProgress(func(p *Progress) { p.State, p.Message = ProgressLoggingIn, "Steam Guard code submitted" })
+		p.answer(code)
+	case <-p.done:
+		steamGuardMu.Lock()
+		if steamGuardCodes == codes {
+			pendingSteamGuard = nil
+		}
+		steamGuardMu.Unlock()
+	case <-time.After(steamGuardTimeout):
+		steamGuardMu.Lock()
+		if steamGuardCodes == codes {
+			pendingSteamGuard = nil
+		}
+		steamGuardMu.Unlock()
+		p.fail(fmt.Errorf("no Steam Guard code was submitted within %s", steamGuardTimeout))
+	}
+}
+
+// fail records why the login failed and stops SteamCMD, which would otherwise wait on its prompt forever
+func (p *promptWatcher) fail(err error) {
+	p.mu.Lock()
+	if p.failure == nil {
+		p.failure = err
+	}
+	p.mu.Unlock()
+	logger.Install.Error("❌ " + err.Error())
+	if p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+	}
+}
+
+// runWithLogin runs a SteamCMD command, answering its login prompts. A login failure is returned instead of the exit error.
+func runWithLogin(cmd *exec.Cmd, stdout io.Writer) error {
+	return runPromptWatched(cmd, stdout, false)
+}
+
+// runUnattended is runWithLogin for background runs, a Steam Guard prompt stops SteamCMD with ErrSteamGuardRequired
+func runUnattended(cmd *exec.Cmd, stdout io.Writer) error {
+	return runPromptWatched(cmd, stdout, true)
+}
+
+func runPromptWatched(cmd *exec.Cmd, stdout io.Writer, unattended bool) error {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	watcher := &promptWatcher{out: stdout, stdin: stdin, cmd: cmd, unattended: unattended, done: make(chan struct{})}
+	cmd.Stdout = watcher
+	err = cmd.Run()
+	close(watcher.done)
+
+	watcher.mu.Lock()
+	failure := watcher.failure
+	watcher.mu.Unlock()
+	if failure != nil {
+		return failure
+	}
+	return err
+}
@@ -0,0 +1,563 @@
+package steamcmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/gamemgr"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/runfile"
+)
+
+/*
+Steam Workshop
+- Runfiles declare Workshop support with the Workshop app ID and where the items go, see runfile.WorkshopConfig
+- The item IDs of an instance are kept in run<Identifier>.workshop.json next to the runfile
+- Items are downloaded into the gameserver directory's steamapps/workshop with +workshop_download_item, all in
+  one SteamCMD run under steamMu, then linked (or copied) into the mods directory
+- Installed versions and sizes are read from SteamCMD's appworkshop_<appid>.acf
+- With UpdateBeforeRestart, automatic restarts update the items while the server is stopped. That update is
+  limited to workshopRestartTimeout and skipped if SteamCMD is busy or Steam asks for a Steam Guard code
+*/
+
+// workshopRestartTimeout bounds the Workshop update of an automatic restart, the server stays down meanwhile
+const workshopRestartTimeout = 15 * time.Minute
+
+// ErrWorkshopUnsupported is returned when the current runfile declares no Workshop support
+var ErrWorkshopUnsupported = errors.New("the current runfile has no Workshop support")
+
+// ErrInvalidWorkshopRequest wraps errors caused by the request itself, like unknown or malformed item IDs
+var ErrInvalidWorkshopRequest = errors.New("invalid Workshop request")
+
+// WorkshopItem is an item the instance uses
+type WorkshopItem struct {
+	ID      string    `json:"id"`
+	AddedAt time.Time `json:"added_at"`
+	AddedBy string    `json:"added_by,omitempty"`
+}
+
+type workshopFile struct {
+	Items               []WorkshopItem `json:"items"`
+	UpdateBeforeRestart bool           `json:"update_before_restart"`
+	LastUpdate          time.Time      `json:"last_update,omitzero"`
+}
+
+// WorkshopItemStatus describes an item and its installed version
+type WorkshopItemStatus struct {
+	WorkshopItem
+	Installed   bool      `json:"installed"`
+	TimeUpdated time.Time `json:"time_updated,omitzero"` // version of the installed item, when it was last changed in the Workshop
+	Manifest    string    `json:"manifest,omitempty"`
+	Size        int64     `json:"size"`
+	Path        string    `json:"path"` // relative to the gameserver directory
+	Placed      bool      `json:"placed"`
+}
+
+// WorkshopStatus describes the Workshop items of the instance
+type WorkshopStatus struct {
+	AppID               string               `json:"app_id"`
+	ModsDir             string               `json:"mods_dir"`
+	Mode                string               `json:"mode"`
+	UpdateBeforeRestart bool                 `json:"update_before_restart"`
+	LastUpdate          time.Time            `json:"last_update,omitzero"`
+	Items               []WorkshopItemStatus `json:"items"`
+}
+
+// installedWorkshopItem is an entry of WorkshopItemsInstalled in appworkshop_<appid>.acf
+type installedWorkshopItem struct {
+	Size        int64
+	TimeUpdated time.Time
+	Manifest    string
+}
+
+var (
+	workshopMu          sync.Mutex // guards the workshop file
+	workshopIDPattern   = regexp.MustCompile(`^\d{1,20}$`)
+	acfInstalledPattern = regexp.MustCompile(`"WorkshopItemsInstalled"\s*\{((?:\s*"\d+"\s*\{[^{}]*\})*)\s*\}`)
+	acfItemPattern      = regexp.MustCompile(`"(\d+)"\s*\{([^{}]*)\}`)
+	acfValuePattern     = regexp.MustCompile(`"(\w+)"\s*"([^"]*)"`)
+	downloadedPattern   = regexp.MustCompile(`Success\. Downloaded item (\d+)`)
+	downloadFailPattern = regexp.MustCompile(`ERROR! Download item (\d+) failed \(([^)]*)\)`)
+)
+
+func workshopConfig() (*runfile.WorkshopConfig, error) {
+	cfg := runfile.GetWorkshopConfig()
+	if cfg == nil {
+		return nil, ErrWorkshopUnsupported
+	}
+	return cfg, nil
+}
+
+func workshopPath() string {
+	return filepath.Join(config.GetRunFilesFolder(), fmt.Sprintf("run%s.workshop.json", config.GetRunfileIdentifier()))
+}
+
+func loadWorkshopFile() (*workshopFile, error) {
+	file := &workshopFile{Items: []WorkshopItem{}}
+	data, err := os.ReadFile(workshopPath())
+	if os.IsNotExist(err) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workshop items: %w", err)
+	}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse workshop items: %w", err)
+	}
+	return file, nil
+}
+
+func saveWorkshopFile(file *workshopFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize workshop items: %w", err)
+	}
+	path := workshopPath()
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write workshop items: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace workshop items: %w", err)
+	}
+	return nil
+}
+
+// gameServerDir is the directory SteamCMD installs the gameserver to, see runSteamCMD
+func gameServerDir() (string, error) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(currentDir, config.GetRunfileIdentifier()), nil
+}
+
+func workshopContentDir(installDir, appID, id string) string {
+	return filepath.Join(installDir, "steamapps", "workshop", "content", appID, id)
+}
+
+// workshopItemPath returns where an item is placed, relative to the gameserver directory
+func workshopItemPath(cfg *runfile.WorkshopConfig, id string) string {
+	name := cfg.DirName
+	if name == "" {
+		name = "{id}"
+	}
+	return filepath.Join(filepath.FromSlash(cfg.ModsDir), strings.ReplaceAll(name, "{id}", id))
+}
+
+func workshopMode(cfg *runfile.WorkshopConfig) string {
+	// creating symlinks needs extra privileges on Windows
+	if cfg.Mode == "copy" || runtime.GOOS == "windows" {
+		return "copy"
+	}
+	return "link"
+}
+
+// readInstalledWorkshopItems parses the items SteamCMD has installed from appworkshop_<appid>.acf
+func readInstalledWorkshopItems(installDir, appID string) map[string]installedWorkshopItem {
+	installed := make(map[string]installedWorkshopItem)
+	data, err := os.ReadFile(filepath.Join(installDir, "steamapps", "workshop", "appworkshop_"+appID+".acf"))
+	if err != nil {
+		return installed
+	}
+	section := acfInstalledPattern.FindSubmatch(data)
+	if section == nil {
+		return installed
+	}
+	for _, match := range acfItemPattern.FindAllSubmatch(section[1], -1) {
+		var item installedWorkshopItem
+		for _, value := range acfValuePattern.FindAllSubmatch(match[2], -1) {
+			switch strings.ToLower(string(value[1])) {
+			case "size":
+				item.Size, _ = strconv.ParseInt(string(value[2]), 10, 64)
+			case "timeupdated":
+				if unix, err := strconv.ParseInt(string(value[2]), 10, 64); err == nil && unix > 0 {
+					item.TimeUpdated = time.Unix(unix, 0).UTC()
+				}
+			case "manifest":
+				item.Manifest = string(value[2])
+			}
+		}
+		installed[string(match[1])] = item
+	}
+	return installed
+}
+
+// GetWorkshopStatus lists the Workshop items of the instance with their installed versions
+func GetWorkshopStatus() (*WorkshopStatus, error) {
+	cfg, err := workshopConfig()
+	if err != nil {
+		return nil, err
+	}
+	installDir, err := gameServerDir()
+	if err != nil {
+		return nil, err
+	}
+	workshopMu.Lock()
+	file, err := loadWorkshopFile()
+	workshopMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	installed := readInstalledWorkshopItems(installDir, cfg.AppID)
+	status := &WorkshopStatus{
+		AppID:               cfg.AppID,
+		ModsDir:             cfg.ModsDir,
+		Mode:                workshopMode(cfg),
+		UpdateBeforeRestart: file.UpdateBeforeRestart,
+		LastUpdate:          file.LastUpdate,
+		Items:               make([]WorkshopItemStatus, 0, len(file.Items)),
+	}
+	for _, item := range file.Items {
+		itemStatus := WorkshopItemStatus{WorkshopItem: item, Path: filepath.ToSlash(workshopItemPath(cfg, item.ID))}
+		if info, ok := installed[item.ID]; ok {
+			itemStatus.TimeUpdated, itemStatus.Manifest, itemStatus.Size = info.TimeUpdated, info.Manifest, info.Size
+		}
+		if _, err := os.Stat(workshopContentDir(installDir, cfg.AppID, item.ID)); err == nil {
+			itemStatus.Installed = true
+		}
+		if _, err := os.Lstat(filepath.Join(installDir, workshopItemPath(cfg, item.ID))); err == nil {
+			itemStatus.Placed = true
+		}
+		status.Items = append(status.Items, itemStatus)
+	}
+	return status, nil
+}
+
+// AddWorkshopItems adds items to the instance, they are downloaded by the next update
+func AddWorkshopItems(ids []string, user string) error {
+	if _, err := workshopConfig(); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if !workshopIDPattern.MatchString(id) {
+			return fmt.Errorf("%w: invalid Workshop item ID %q", ErrInvalidWorkshopRequest, id)
+		}
+	}
+	workshopMu.Lock()
+	defer workshopMu.Unlock()
+	file, err := loadWorkshopFile()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if slices.ContainsFunc(file.Items, func(item WorkshopItem) bool { return item.ID == id }) {
+			continue
+		}
+		file.Items = append(file.Items, WorkshopItem{ID: id, AddedAt: time.Now().UTC(), AddedBy: user})
+	}
+	return saveWorkshopFile(file)
+}
+
+// RemoveWorkshopItems removes items from the instance, from the mods directory and their downloaded content
+func RemoveWorkshopItems(ids []string) error {
+	cfg, err := workshopConfig()
+	if err != nil {
+		return err
+	}
+	installDir, err := gameServerDir()
+	if err != nil {
+		return err
+	}
+	workshopMu.Lock()
+	defer workshopMu.Unlock()
+	file, err := loadWorkshopFile()
+	if err != nil {
+		return err
+	}
+	kept := file.Items[:0]
+	for _, item := range file.Items {
+		if !slices.Contains(ids, item.ID) {
+			kept = append(kept, item)
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(installDir, workshopItemPath(cfg, item.ID))); err != nil {
+			logger.Install.Warn(fmt.Sprintf("Failed to remove Workshop item %s from the mods directory: %s", item.ID, err.Error()))
+		}
+		if err := os.RemoveAll(workshopContentDir(installDir, cfg.AppID, item.ID)); err != nil {
+			logger.Install.Warn(fmt.Sprintf("Failed to remove downloaded Workshop item %s: %s", item.ID, err.Error()))
+		}
+	}
+	file.Items = kept
+	return saveWorkshopFile(file)
+}
+
+// SetWorkshopUpdateBeforeRestart enables updating the items during automatic restarts
+func SetWorkshopUpdateBeforeRestart(enabled bool) error {
+	if _, err := workshopConfig(); err != nil {
+		return err
+	}
+	workshopMu.Lock()
+	defer workshopMu.Unlock()
+	file, err := loadWorkshopFile()
+	if err != nil {
+		return err
+	}
+	file.UpdateBeforeRestart = enabled
+	return saveWorkshopFile(file)
+}
+
+// UpdateWorkshopItems downloads or updates the given items, all items of the instance if ids is empty, and places them into the mods directory.
+// Items that failed to download are reported in the error, the others are placed anyway.
+func UpdateWorkshopItems(ids []string) (*WorkshopStatus, error) {
+	return updateWorkshopItems(context.Background(), ids, false)
+}
+
+// updateWorkshopItems is UpdateWorkshopItems, SteamCMD is killed when ctx ends. Unattended runs don't wait for SteamCMD or a Steam Guard code.
+func updateWorkshopItems(ctx context.Context, ids []string, unattended bool) (*WorkshopStatus, error) {
+	cfg, err := workshopConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config.GetSkipSteamCMD() {
+		return nil, fmt.Errorf("%w: SteamCMD is disabled", ErrInvalidWorkshopRequest)
+	}
+	installDir, err := gameServerDir()
+	if err != nil {
+		return nil, err
+	}
+	workshopMu.Lock()
+	file, err := loadWorkshopFile()
+	workshopMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, item := range file.Items {
+		if len(ids) == 0 || slices.Contains(ids, item.ID) {
+			targets = append(targets, item.ID)
+		}
+	}
+	for _, id := range ids {
+		if !slices.Contains(targets, id) {
+			return nil, fmt.Errorf("%w: Workshop item %s is not part of this instance, add it first", ErrInvalidWorkshopRequest, id)
+		}
+	}
+	if len(targets) == 0 {
+		return GetWorkshopStatus()
+	}
+
+	if gamemgr.InternalIsServerRunning() {
+		logger.Install.Warn("The gameserver is running, updated Workshop items are used after its next restart")
+	}
+	downloaded, failed, err := downloadWorkshopItems(ctx, cfg, installDir, targets, unattended)
+	if err != nil {
+		return nil, err
+	}
+
+	// items removed while SteamCMD was running must not be linked again, so membership is checked under workshopMu
+	var placeErrors []string
+	placed := 0
+	workshopMu.Lock()
+	if file, err := loadWorkshopFile(); err != nil {
+		placeErrors = append(placeErrors, err.Error())
+	} else {
+		for _, id := range downloaded {
+			if !slices.ContainsFunc(file.Items, func(item WorkshopItem) bool { return item.ID == id }) {
+				logger.Install.Info(fmt.Sprintf("Workshop item %s was removed during the update, it was not placed", id))
+				continue
+			}
+			if err := placeWorkshopItem(cfg, installDir, id); err != nil {
+				placeErrors = append(placeErrors, fmt.Sprintf("%s: %s", id, err.Error()))
+				continue
+			}
+			placed++
+		}
+		file.LastUpdate = time.Now().UTC()
+		if err := saveWorkshopFile(file); err != nil {
+			logger.Install.Warn(err.Error())
+		}
+	}
+	workshopMu.Unlock()
+
+	status, err := GetWorkshopStatus()
+	if err != nil {
+		return nil, err
+	}
+	logger.Install.Info(fmt.Sprintf("✅ Updated %d of %d Workshop items", placed, len(targets)))
+	if len(failed) > 0 || len(placeErrors) > 0 {
+		return status, fmt.Errorf("some Workshop items were not updated: %s", strings.Join(append(failed, placeErrors...), ", "))
+	}
+	return status, nil
+}
+
+// UpdateWorkshopBeforeRestart is registered as a gamemgr pre-restart hook
+func UpdateWorkshopBeforeRestart() error {
+	if runfile.GetWorkshopConfig() == nil {
+		return nil
+	}
+	workshopMu.Lock()
+	file, err := loadWorkshopFile()
+	workshopMu.Unlock()
+	if err != nil {
+		return err
+	}
+	if !file.UpdateBeforeRestart || len(file.Items) == 0 {
+		return nil
+	}
+	logger.Install.Info(fmt.Sprintf("🔄 Updating %d Workshop items before the restart...", len(file.Items)))
+	ctx, cancel := context.WithTimeout(context.Background(), workshopRestartTimeout)
+	defer cancel()
+	_, err = updateWorkshopItems(ctx, nil, true)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("Workshop update took longer than %s and was stopped, the server restarts with the items it had", workshopRestartTimeout)
+	}
+	if errors.Is(err, ErrSteamGuardRequired) {
+		return fmt.Errorf("Workshop update skipped, Steam asked for a Steam Guard code: update the items from the UI")
+	}
+	return err
+}
+
+// downloadWorkshopItems runs SteamCMD once for all items and returns the downloaded ones and the failures.
+// SteamCMD is killed when ctx ends, unattended runs skip instead of waiting for a busy SteamCMD or a Steam Guard code.
+func downloadWorkshopItems(ctx context.Context, cfg *runfile.WorkshopConfig, installDir string, ids []string, unattended bool) ([]string, []string, error) {
+	if steamMu.TryLock() {
+		logger.Core.Debug("🔄 Locking SteamMu for Workshop download...")
+	} else if unattended {
+		return nil, nil, fmt.Errorf("SteamCMD is busy, the Workshop items were not updated")
+	} else {
+		logger.Core.Warn("🔄 SteamMu is currently locked, waiting for it to be unlocked and then continuing...")
+		steamMu.Lock()
+		logger.Core.Debug("🔄 Locking SteamMu for Workshop download...")
+	}
+	defer steamMu.Unlock()
+	defer logger.Core.Debug("🔄 Unlocking SteamMu after Workshop download...")
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return nil, nil, err
+	}
+	steamcmddir := SteamCMDLinuxDir
+	executable := "steamcmd.sh"
+	if runtime.GOOS == "windows" {
+		steamcmddir = SteamCMDWindowsDir
+		executable = "steamcmd.exe"
+	}
+
+	login, err := loginArgs(cfg.LoginRequired || serverLoginRequired())
+	if err != nil {
+		return nil, nil, err
+	}
+	args := append([]string{"+force_install_dir", installDir}, login...)
+	for _, id := range ids {
+		args = append(args, "+workshop_download_item", cfg.AppID, id, "validate")
+	}
+	args = append(args, "+quit")
+	cmd := exec.CommandContext(ctx, filepath.Join(steamcmddir, executable), args...)
+	cmd.Stderr = os.Stderr
+	if runtime.GOOS == "linux" {
+		cmd.Env = steamCMDEnv(currentDir)
+	}
+
+	logger.Install.Info(fmt.Sprintf("🕑 Downloading %d Workshop items with SteamCMD...", len(ids)))
+	var output bytes.Buffer
+	tracker := startProgress("workshop")
+	run := runWithLogin
+	if unattended {
+		run = runUnattended
+	}
+	runErr := run(cmd, io.MultiWriter(os.Stdout, &output, tracker))
+	if ctx.Err() != nil {
+		runErr = ctx.Err()
+	}
+
+	var downloaded, failed []string
+	for _, match := range downloadedPattern.FindAllStringSubmatch(output.String(), -1) {
+		if slices.Contains(ids, match[1]) && !slices.Contains(downloaded, match[1]) {
+			downloaded = append(downloaded, match[1])
+		}
+	}
+	for _, match := range downloadFailPattern.FindAllStringSubmatch(output.String(), -1) {
+		failed = append(failed, fmt.Sprintf("%s: %s", match[1], match[2]))
+	}
+	if runErr != nil && len(downloaded) == 0 {
+		tracker.finish(runErr)
+		logger.Install.Error("❌ Workshop download failed: " + runErr.Error())
+		return nil, nil, fmt.Errorf("workshop download failed: %w", runErr)
+	}
+	for _, id := range ids {
+		if !slices.Contains(downloaded, id) && !slices.ContainsFunc(failed, func(f string) bool { return strings.HasPrefix(f, id+":") }) {
+			failed = append(failed, id+": not downloaded")
+		}
+	}
+	if len(failed) > 0 {
+		tracker.finish(fmt.Errorf("%d of %d items failed", len(failed), len(ids)))
+	} else {
+		tracker.finish(nil)
+	}
+	return downloaded, failed, nil
+}
+
+// placeWorkshopItem links or copies a downloaded item into the mods directory, replacing what was there
+func placeWorkshopItem(cfg *runfile.WorkshopConfig, installDir, id string) error {
+	source := workshopContentDir(installDir, cfg.AppID, id)
+	if _, err := os.Stat(source); err != nil {
+		return fmt.Errorf("downloaded content is missing: %w", err)
+	}
+	target := filepath.Join(installDir, workshopItemPath(cfg, id))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", target, err)
+	}
+	if workshopMode(cfg) == "link" {
+		return os.Symlink(source, target)
+	}
+	return copyDir(source, target)
+}
+
+func copyDir(source, target string) error {
+	return filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(target, rel)
+		if d.IsDir() {
+			return os.MkdirAll(dest, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
@@ -0,0 +1,253 @@
+// configfile.go
+package configfile
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+Structured Config Files
+- Reads and changes single values of json, ini, xml and yaml files by key path, so a typo can't break the whole file
+- Key paths are dot separated, [n] selects the n-th element of a list or of repeated xml elements/ini keys,
+  a literal dot in a key is escaped as \. and xml attributes are addressed as Element.@attribute
+- Only the changed value is rewritten, comments, ordering and formatting of json, ini and xml stay as they are.
+  yaml keeps comments and ordering, but is re-indented by the encoder
+- Set only changes existing keys and keeps the type of the old value, "30" can't replace a boolean
+- "text" files have no keys, Validate accepts any content for them
+- Empty files are valid for every type and have no keys
+*/
+
+// Entry is a single value of a config file
+type Entry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type"` // string, number, bool, null or for xml/ini just "string"
+}
+
+// ErrKeyNotFound is returned when a key path doesn't exist in the file
+type ErrKeyNotFound struct {
+	Key string
+}
+
+func (e ErrKeyNotFound) Error() string {
+	return fmt.Sprintf("key %s not found", e.Key)
+}
+
+// format is implemented once per file type
+type format interface {
+	validate(content []byte) error
+	entries(content []byte) ([]Entry, error)
+	set(content []byte, path []segment, value string) ([]byte, error)
+}
+
+var formats = map[string]format{
+	"json": jsonFormat{},
+	"ini":  iniFormat{},
+	"xml":  xmlFormat{},
+	"yaml": yamlFormat{},
+}
+
+func formatFor(fileType string) (format, error) {
+	f, ok := formats[strings.ToLower(fileType)]
+	if !ok {
+		return nil, fmt.Errorf("file type %s does not support key access", fileType)
+	}
+	return f, nil
+}
+
+// Supports reports whether values of a file type can be read and set by key
+func Supports(fileType string) bool {
+	_, err := formatFor(fileType)
+	return err == nil
+}
+
+// Validate checks the syntax of content for a file type
+func Validate(fileType string, content []byte) error {
+	if strings.EqualFold(fileType, "text") {
+		return nil
+	}
+	f, err := formatFor(fileType)
+	if err != nil {
+		return err
+	}
+	if isEmpty(content) {
+		return nil
+	}
+	return f.validate(content)
+}
+
+// Entries lists every value of the file with its key path, in file order
+func Entries(fileType string, content []byte) ([]Entry, error) {
+	f, err := formatFor(fileType)
+	if err != nil {
+		return nil, err
+	}
+	if isEmpty(content) {
+		return []Entry{}, nil
+	}
+	if err := f.validate(content); err != nil {
+		return nil, err
+	}
+	return f.entries(content)
+}
+
+func isEmpty(content []byte) bool {
+	return len(bytes.TrimSpace(content)) == 0
+}
+
+// Get returns the value at a key path
+func Get(fileType string, content []byte, key string) (Entry, error) {
+	path, err := parsePath(key)
+	if err != nil {
+		return Entry{}, err
+	}
+	entries, err := Entries(fileType, content)
+	if err != nil {
+		return Entry{}, err
+	}
+	want := formatPath(path)
+	for _, entry := range entries {
+		if entry.Key == want || entry.Key == key {
+			return entry, nil
+		}
+	}
+	// entries only show [n] for repeated keys, so "a[0]" has to match "a" as well
+	for _, entry := range entries {
+		if entryPath, err := parsePath(entry.Key); err == nil && samePath(entryPath, path) {
+			return entry, nil
+		}
+	}
+	return Entry{}, ErrKeyNotFound{Key: key}
+}
+
+// Set changes the value at an existing key path and returns the new content, which is validated before it is returned
+func Set(fileType string, content []byte, key, value string) ([]byte, error) {
+	f, err := formatFor(fileType)
+	if err != nil {
+		return nil, err
+	}
+	path, err := parsePath(key)
+	if err != nil {
+		return nil, err
+	}
+	if isEmpty(content) {
+		return nil, ErrKeyNotFound{Key: key}
+	}
+	if err := f.validate(content); err != nil {
+		return nil, fmt.Errorf("file is not valid %s, fix it in the file editor first: %w", fileType, err)
+	}
+	updated, err := f.set(content, path, value)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.validate(updated); err != nil {
+		return nil, fmt.Errorf("setting %s would produce invalid %s: %w", key, fileType, err)
+	}
+	return updated, nil
+}
+
+// segment is one step of a key path, index is -1 when none was given
+type segment struct {
+	name  string
+	index int
+}
+
+func (s segment) at() int {
+	if s.index < 0 {
+		return 0
+	}
+	return s.index
+}
+
+func samePath(a, b []segment) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].name != b[i].name || a[i].at() != b[i].at() {
+			return false
+		}
+	}
+	return true
+}
+
+// parsePath splits "a.b[2].c\.d" into its segments
+func parsePath(key string) ([]segment, error) {
+	if key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+	var path []segment
+	var name strings.Builder
+	current := segment{index: -1}
+	flush := func() error {
+		current.name = name.String()
+		if current.name == "" && current.index < 0 {
+			return fmt.Errorf("invalid key %s: empty segment", key)
+		}
+		path = append(path, current)
+		name.Reset()
+		current = segment{index: -1}
+		return nil
+	}
+	for i := 0; i < len(key); i++ {
+		switch c := key[i]; c {
+		case '\\':
+			if i+1 < len(key) && (key[i+1] == '.' || key[i+1] == '[' || key[i+1] == '\\') {
+				i++
+				name.WriteByte(key[i])
+			} else {
+				name.WriteByte(c)
+			}
+		case '.':
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		case '[':
+			end := strings.IndexByte(key[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid key %s: missing ]", key)
+			}
+			index, err := strconv.Atoi(key[i+1 : i+end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid key %s: index must be a number", key)
+			}
+			if current.index >= 0 {
+				// nested lists, "a[1][2]", become an unnamed segment
+				current.name = name.String()
+				path = append(path, current)
+				name.Reset()
+				current = segment{index: -1}
+			}
+			current.index = index
+			i += end
+		default:
+			name.WriteByte(c)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return path, nil
+}
+
+// formatPath is the inverse of parsePath
+func formatPath(path []segment) string {
+	var b strings.Builder
+	for i, s := range path {
+		if i > 0 && s.name != "" {
+			b.WriteByte('.')
+		}
+		b.WriteString(escapeKey(s.name))
+		if s.index >= 0 {
+			fmt.Fprintf(&b, "[%d]", s.index)
+		}
+	}
+	return b.String()
+}
+
+func escapeKey(name string) string {
+	return strings.NewReplacer(`\`, `\\`, ".", `\.`, "[", `\[`).Replace(name)
+}
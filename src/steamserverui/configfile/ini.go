@@ -0,0 +1,136 @@
+// ini.go
+package configfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+type iniFormat struct{}
+
+// iniLine is a key=value line, valueStart and valueEnd are byte offsets of the value in the content.
+// Bare keys without = have an empty value that starts and ends after the key.
+type iniLine struct {
+	section    string
+	key        string
+	value      string
+	valueStart int
+	valueEnd   int
+	bare       bool
+}
+
+// parseINI returns the key lines of the content, keys before the first section header have an empty section
+func parseINI(content []byte) ([]iniLine, error) {
+	var lines []iniLine
+	section := ""
+	offset := 0
+	for number, raw := range strings.SplitAfter(string(content), "\n") {
+		start := offset
+		offset += len(raw)
+		line := strings.TrimRight(raw, "\r\n")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "", strings.HasPrefix(trimmed, ";"), strings.HasPrefix(trimmed, "#"):
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			if !strings.HasSuffix(trimmed, "]") {
+				return nil, fmt.Errorf("line %d: section header %q is missing ]", number+1, trimmed)
+			}
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			continue
+		}
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			// key-only lines are common in game configs, e.g. flags that are set by being present
+			end := len(strings.TrimRight(line, " \t"))
+			lines = append(lines, iniLine{section: section, key: trimmed, valueStart: start + end, valueEnd: start + end, bare: true})
+			continue
+		}
+		key := strings.TrimSpace(line[:eq])
+		if key == "" {
+			return nil, fmt.Errorf("line %d: key is missing before =", number+1)
+		}
+		valueStart := eq + 1
+		for valueStart < len(line) && (line[valueStart] == ' ' || line[valueStart] == '\t') {
+			valueStart++
+		}
+		valueEnd := len(strings.TrimRight(line, " \t"))
+		if valueEnd < valueStart {
+			valueEnd = valueStart
+		}
+		lines = append(lines, iniLine{
+			section:    section,
+			key:        key,
+			value:      line[valueStart:valueEnd],
+			valueStart: start + valueStart,
+			valueEnd:   start + valueEnd,
+		})
+	}
+	return lines, nil
+}
+
+func (iniFormat) validate(content []byte) error {
+	_, err := parseINI(content)
+	return err
+}
+
+// iniPath is "section.key", or just "key" outside of any section. Repeated keys, like +Key= lines of Unreal configs, get an index.
+func iniPath(lines []iniLine, i int) []segment {
+	count, index := 0, 0
+	for j, other := range lines {
+		if other.section == lines[i].section && other.key == lines[i].key {
+			if j < i {
+				index++
+			}
+			count++
+		}
+	}
+	s := segment{name: lines[i].key, index: -1}
+	if count > 1 {
+		s.index = index
+	}
+	if lines[i].section == "" {
+		return []segment{s}
+	}
+	return []segment{{name: lines[i].section, index: -1}, s}
+}
+
+func (iniFormat) entries(content []byte) ([]Entry, error) {
+	lines, err := parseINI(content)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(lines))
+	for i, line := range lines {
+		entries = append(entries, Entry{Key: formatPath(iniPath(lines, i)), Value: line.value, Type: "string"})
+	}
+	return entries, nil
+}
+
+func (iniFormat) set(content []byte, path []segment, value string) ([]byte, error) {
+	if strings.ContainsAny(value, "\r\n") {
+		return nil, fmt.Errorf("ini values can't contain line breaks")
+	}
+	lines, err := parseINI(content)
+	if err != nil {
+		return nil, err
+	}
+	// section names may contain dots, so the whole path except the key is tried as section name too
+	if len(path) > 2 {
+		sectionPath := formatPath(path[:len(path)-1])
+		path = []segment{{name: strings.NewReplacer(`\.`, ".", `\\`, `\`, `\[`, "[").Replace(sectionPath), index: -1}, path[len(path)-1]}
+	}
+	for i, line := range lines {
+		candidate := iniPath(lines, i)
+		if samePath(candidate, path) {
+			updated := make([]byte, 0, len(content)+len(value))
+			updated = append(updated, content[:line.valueStart]...)
+			if line.bare {
+				updated = append(updated, '=')
+			}
+			updated = append(updated, value...)
+			return append(updated, content[line.valueEnd:]...), nil
+		}
+	}
+	return nil, ErrKeyNotFound{Key: formatPath(path)}
+}
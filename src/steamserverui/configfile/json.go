@@ -0,0 +1,244 @@
+// json.go
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type jsonFormat struct{}
+
+// jsonNode is a value in the raw content, start and end are byte offsets
+type jsonNode struct {
+	kind     byte // '{', '[', '"', 'n' (number), 'b' (bool), 'z' (null)
+	start    int
+	end      int
+	keys     []string // object keys, in file order
+	children []*jsonNode
+}
+
+func (jsonFormat) validate(content []byte) error {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err == nil {
+		return fmt.Errorf("unexpected content after the top-level value")
+	}
+	return nil
+}
+
+// parseJSON builds the node tree of valid json
+func parseJSON(content []byte) *jsonNode {
+	p := &jsonParser{data: content}
+	return p.value()
+}
+
+type jsonParser struct {
+	data []byte
+	pos  int
+}
+
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.data) && strings.IndexByte(" \t\r\n", p.data[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *jsonParser) value() *jsonNode {
+	p.skipSpace()
+	node := &jsonNode{start: p.pos}
+	switch c := p.data[p.pos]; c {
+	case '{', '[':
+		node.kind = c
+		closing := byte('}')
+		if c == '[' {
+			closing = ']'
+		}
+		p.pos++
+		for {
+			p.skipSpace()
+			if p.data[p.pos] == closing {
+				p.pos++
+				break
+			}
+			if p.data[p.pos] == ',' {
+				p.pos++
+				p.skipSpace()
+			}
+			if c == '{' {
+				keyNode := p.value()
+				var key string
+				json.Unmarshal(p.data[keyNode.start:keyNode.end], &key)
+				node.keys = append(node.keys, key)
+				p.skipSpace()
+				p.pos++ // ':'
+			}
+			node.children = append(node.children, p.value())
+		}
+	case '"':
+		node.kind = '"'
+		p.pos++
+		for p.data[p.pos] != '"' {
+			if p.data[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		p.pos++
+	default:
+		for p.pos < len(p.data) && strings.IndexByte(",}] \t\r\n", p.data[p.pos]) < 0 {
+			p.pos++
+		}
+		switch p.data[node.start] {
+		case 't', 'f':
+			node.kind = 'b'
+		case 'n':
+			node.kind = 'z'
+		default:
+			node.kind = 'n'
+		}
+	}
+	node.end = p.pos
+	return node
+}
+
+func jsonType(kind byte) string {
+	switch kind {
+	case '"':
+		return "string"
+	case 'b':
+		return "bool"
+	case 'z':
+		return "null"
+	default:
+		return "number"
+	}
+}
+
+func jsonDisplayValue(content []byte, node *jsonNode) string {
+	raw := content[node.start:node.end]
+	if node.kind == '"' {
+		var value string
+		json.Unmarshal(raw, &value)
+		return value
+	}
+	return string(raw)
+}
+
+func (f jsonFormat) entries(content []byte) ([]Entry, error) {
+	var entries []Entry
+	var walk func(node *jsonNode, path []segment)
+	walk = func(node *jsonNode, path []segment) {
+		switch node.kind {
+		case '{':
+			counts := make(map[string]int)
+			for _, key := range node.keys {
+				counts[key]++
+			}
+			seen := make(map[string]int)
+			for i, child := range node.children {
+				s := segment{name: node.keys[i], index: -1}
+				if counts[s.name] > 1 {
+					s.index = seen[s.name]
+				}
+				seen[s.name]++
+				walk(child, append(path[:len(path):len(path)], s))
+			}
+		case '[':
+			for i, child := range node.children {
+				walk(child, appendIndex(path, i))
+			}
+		default:
+			entries = append(entries, Entry{Key: formatPath(path), Value: jsonDisplayValue(content, node), Type: jsonType(node.kind)})
+		}
+	}
+	walk(parseJSON(content), nil)
+	return entries, nil
+}
+
+// appendIndex adds a list index to a path, a second index on the same segment becomes its own unnamed segment
+func appendIndex(path []segment, index int) []segment {
+	extended := append([]segment{}, path...)
+	if len(extended) > 0 && extended[len(extended)-1].index < 0 {
+		extended[len(extended)-1].index = index
+		return extended
+	}
+	return append(extended, segment{index: index})
+}
+
+// findJSON follows a path through the node tree, an object key with an index selects the n-th duplicate or the n-th list element
+func findJSON(root *jsonNode, path []segment) *jsonNode {
+	node := root
+	for _, s := range path {
+		if s.name != "" {
+			if node.kind != '{' {
+				return nil
+			}
+			var matches []*jsonNode
+			for i, key := range node.keys {
+				if key == s.name {
+					matches = append(matches, node.children[i])
+				}
+			}
+			switch {
+			case len(matches) == 0:
+				return nil
+			case len(matches) > 1:
+				if s.at() >= len(matches) {
+					return nil
+				}
+				node = matches[s.at()]
+				continue
+			default:
+				node = matches[0]
+			}
+		}
+		if s.index >= 0 {
+			if node.kind != '[' || s.index >= len(node.children) {
+				return nil
+			}
+			node = node.children[s.index]
+		}
+	}
+	return node
+}
+
+func (f jsonFormat) set(content []byte, path []segment, value string) ([]byte, error) {
+	node := findJSON(parseJSON(content), path)
+	if node == nil {
+		return nil, ErrKeyNotFound{Key: formatPath(path)}
+	}
+	var encoded []byte
+	switch node.kind {
+	case '{', '[':
+		return nil, fmt.Errorf("%s is an object or list, set its values one by one", formatPath(path))
+	case '"':
+		encoded, _ = json.Marshal(value)
+	case 'b':
+		if value != "true" && value != "false" {
+			return nil, fmt.Errorf("%s is a boolean, value must be true or false", formatPath(path))
+		}
+		encoded = []byte(value)
+	case 'n':
+		var number json.Number
+		if err := json.Unmarshal([]byte(value), &number); err != nil || value[0] == '"' || strings.TrimSpace(value) != value {
+			return nil, fmt.Errorf("%s is a number, %q is not", formatPath(path), value)
+		}
+		encoded = []byte(value)
+	case 'z':
+		// null has no type to keep, take json literals as they are and anything else as a string
+		if json.Valid([]byte(value)) {
+			encoded = []byte(strings.TrimSpace(value))
+		} else {
+			encoded, _ = json.Marshal(value)
+		}
+	}
+	updated := make([]byte, 0, len(content)+len(encoded))
+	updated = append(updated, content[:node.start]...)
+	updated = append(updated, encoded...)
+	return append(updated, content[node.end:]...), nil
+}
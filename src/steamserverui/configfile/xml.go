@@ -0,0 +1,187 @@
+// xml.go
+package configfile
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+type xmlFormat struct{}
+
+// xmlElement is an element in the raw content, offsets are bytes
+type xmlElement struct {
+	name       string // local name, used in key paths
+	rawName    string // name as written, with prefix
+	index      int    // position among siblings with the same name
+	parent     *xmlElement
+	counts     map[string]int
+	tagStart   int // start of the start tag
+	tagEnd     int // end of the start tag
+	closeStart int // start of the end tag, equal to tagEnd for <empty/> elements
+	selfClosed bool
+	hasChild   bool
+	attrs      []xml.Attr
+}
+
+func (xmlFormat) validate(content []byte) error {
+	_, err := parseXML(content)
+	return err
+}
+
+// parseXML returns all elements in document order
+func parseXML(content []byte) ([]*xmlElement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	var elements []*xmlElement
+	var stack []*xmlElement
+	roots := 0
+	root := &xmlElement{counts: make(map[string]int)}
+	for {
+		start := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		end := int(decoder.InputOffset())
+		switch t := token.(type) {
+		case xml.StartElement:
+			parent := root
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			} else {
+				roots++
+			}
+			parent.hasChild = true
+			tag := string(content[start:end])
+			element := &xmlElement{
+				name:       t.Name.Local,
+				rawName:    strings.TrimLeft(strings.FieldsFunc(tag, func(r rune) bool { return strings.ContainsRune(" \t\r\n/>", r) })[0], "<"),
+				index:      parent.counts[t.Name.Local],
+				parent:     parent,
+				counts:     make(map[string]int),
+				tagStart:   int(start),
+				tagEnd:     end,
+				selfClosed: strings.HasSuffix(tag, "/>"),
+				attrs:      t.Attr,
+			}
+			parent.counts[t.Name.Local]++
+			elements = append(elements, element)
+			stack = append(stack, element)
+		case xml.EndElement:
+			element := stack[len(stack)-1]
+			element.closeStart = int(start)
+			if element.selfClosed {
+				element.closeStart = element.tagEnd
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if roots != 1 {
+		return nil, fmt.Errorf("document must have exactly one root element, found %d", roots)
+	}
+	return elements, nil
+}
+
+func (e *xmlElement) path() []segment {
+	var path []segment
+	for element := e; element.parent != nil; element = element.parent {
+		s := segment{name: element.name, index: -1}
+		if element.parent.counts[element.name] > 1 {
+			s.index = element.index
+		}
+		path = append([]segment{s}, path...)
+	}
+	return path
+}
+
+// text returns the character data of a leaf element
+func (e *xmlElement) text(content []byte) string {
+	var value string
+	decoder := xml.NewDecoder(bytes.NewReader(content[e.tagStart:]))
+	decoder.DecodeElement(&value, nil)
+	return value
+}
+
+func (xmlFormat) entries(content []byte) ([]Entry, error) {
+	elements, err := parseXML(content)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, element := range elements {
+		path := element.path()
+		for _, attr := range element.attrs {
+			if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+				continue
+			}
+			entries = append(entries, Entry{Key: formatPath(append(path, segment{name: "@" + attr.Name.Local, index: -1})), Value: attr.Value, Type: "string"})
+		}
+		if !element.hasChild {
+			entries = append(entries, Entry{Key: formatPath(path), Value: element.text(content), Type: "string"})
+		}
+	}
+	return entries, nil
+}
+
+func escapeXML(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+func (xmlFormat) set(content []byte, path []segment, value string) ([]byte, error) {
+	elements, err := parseXML(content)
+	if err != nil {
+		return nil, err
+	}
+	attribute := ""
+	if last := path[len(path)-1]; strings.HasPrefix(last.name, "@") {
+		attribute = strings.TrimPrefix(last.name, "@")
+		path = path[:len(path)-1]
+	}
+	var target *xmlElement
+	for _, element := range elements {
+		if samePath(element.path(), path) {
+			target = element
+			break
+		}
+	}
+	if target == nil {
+		return nil, ErrKeyNotFound{Key: formatPath(path)}
+	}
+
+	var start, end int
+	var replacement string
+	if attribute != "" {
+		tag := content[target.tagStart:target.tagEnd]
+		match := regexp.MustCompile(`[\s]` + regexp.QuoteMeta(attribute) + `\s*=\s*("[^"]*"|'[^']*')`).FindSubmatchIndex(tag)
+		if match == nil {
+			return nil, ErrKeyNotFound{Key: formatPath(path) + ".@" + attribute}
+		}
+		// keep the quotes, replace what is between them
+		start, end = target.tagStart+match[2]+1, target.tagStart+match[3]-1
+		replacement = escapeXML(value)
+	} else {
+		if target.hasChild {
+			return nil, fmt.Errorf("%s has child elements, set them one by one", formatPath(path))
+		}
+		start, end = target.tagEnd, target.closeStart
+		replacement = escapeXML(value)
+		if target.selfClosed {
+			// <Name/> becomes <Name>value</Name>
+			tag := string(content[target.tagStart:target.tagEnd])
+			start, end = target.tagStart, target.tagEnd
+			replacement = strings.TrimRight(strings.TrimSuffix(tag, "/>"), " \t\r\n") + ">" + replacement + "</" + target.rawName + ">"
+		}
+	}
+	updated := make([]byte, 0, len(content)+len(replacement))
+	updated = append(updated, content[:start]...)
+	updated = append(updated, replacement...)
+	return append(updated, content[end:]...), nil
+}
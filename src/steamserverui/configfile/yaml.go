@@ -0,0 +1,173 @@
+// yaml.go
+package configfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type yamlFormat struct{}
+
+func (yamlFormat) validate(content []byte) error {
+	_, err := parseYAML(content)
+	return err
+}
+
+// parseYAML returns all documents of the content, only the first one is addressed by key paths
+func parseYAML(content []byte) ([]*yaml.Node, error) {
+	var documents []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, &document)
+	}
+	if len(documents) == 0 || len(documents[0].Content) == 0 {
+		return nil, fmt.Errorf("yaml document is empty")
+	}
+	return documents, nil
+}
+
+func yamlType(node *yaml.Node) string {
+	switch node.ShortTag() {
+	case "!!int", "!!float":
+		return "number"
+	case "!!bool":
+		return "bool"
+	case "!!null":
+		return "null"
+	default:
+		return "string"
+	}
+}
+
+func (yamlFormat) entries(content []byte) ([]Entry, error) {
+	documents, err := parseYAML(content)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	var walk func(node *yaml.Node, path []segment)
+	walk = func(node *yaml.Node, path []segment) {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				walk(node.Content[i+1], append(path[:len(path):len(path)], segment{name: node.Content[i].Value, index: -1}))
+			}
+		case yaml.SequenceNode:
+			for i, child := range node.Content {
+				walk(child, appendIndex(path, i))
+			}
+		case yaml.ScalarNode:
+			entries = append(entries, Entry{Key: formatPath(path), Value: node.Value, Type: yamlType(node)})
+		}
+		// aliases point to values listed elsewhere and are left out
+	}
+	walk(documents[0].Content[0], nil)
+	return entries, nil
+}
+
+func findYAML(node *yaml.Node, path []segment) *yaml.Node {
+	for _, s := range path {
+		if s.name != "" {
+			if node.Kind != yaml.MappingNode {
+				return nil
+			}
+			var next *yaml.Node
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == s.name {
+					next = node.Content[i+1]
+					break
+				}
+			}
+			if next == nil {
+				return nil
+			}
+			node = next
+		}
+		if s.index >= 0 {
+			if node.Kind != yaml.SequenceNode || s.index >= len(node.Content) {
+				return nil
+			}
+			node = node.Content[s.index]
+		}
+	}
+	return node
+}
+
+// yamlIndent guesses the indentation of the content, so re-encoding doesn't reformat the whole file
+func yamlIndent(content []byte) int {
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if indent := len(line) - len(trimmed); indent > 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return max(indent, 2)
+		}
+	}
+	return 2
+}
+
+func (yamlFormat) set(content []byte, path []segment, value string) ([]byte, error) {
+	documents, err := parseYAML(content)
+	if err != nil {
+		return nil, err
+	}
+	node := findYAML(documents[0].Content[0], path)
+	if node == nil {
+		return nil, ErrKeyNotFound{Key: formatPath(path)}
+	}
+	if node.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("%s is not a single value, set its values one by one", formatPath(path))
+	}
+
+	switch node.ShortTag() {
+	case "!!int":
+		if _, err := strconv.ParseInt(value, 0, 64); err != nil {
+			return nil, fmt.Errorf("%s is an integer, %q is not", formatPath(path), value)
+		}
+	case "!!float":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("%s is a number, %q is not", formatPath(path), value)
+		}
+	case "!!bool":
+		if value != "true" && value != "false" {
+			return nil, fmt.Errorf("%s is a boolean, value must be true or false", formatPath(path))
+		}
+	case "!!null":
+		// no type to keep, let yaml resolve the new value
+		node.Tag = ""
+	default:
+		// keep it a string even if the value looks like a number, plain style would change its type
+		if node.Style == 0 {
+			var probe any
+			if yaml.Unmarshal([]byte(value), &probe) != nil || fmt.Sprint(probe) != value {
+				node.Style = yaml.DoubleQuotedStyle
+			} else if _, isString := probe.(string); !isString {
+				node.Style = yaml.DoubleQuotedStyle
+			}
+		}
+	}
+	node.Value = value
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(yamlIndent(content))
+	for _, document := range documents {
+		if err := encoder.Encode(document); err != nil {
+			return nil, fmt.Errorf("failed to encode yaml: %w", err)
+		}
+	}
+	encoder.Close()
+	return out.Bytes(), nil
+}
@@ -1,6 +1,7 @@
 package gallery
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,6 +15,7 @@ import (
 	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/core/loader"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/managers/bundlemgr"
 )
 
 // GalleryPlugin represents a plugin in the gallery
@@ -26,6 +28,7 @@ type GalleryPlugin struct {
 	LogoURL              string `json:"logo_url"`
 	SupportedOS          string `json:"supported_os"`
 	MinVersion           string `json:"min_version"`
+	Integrity
 }
 
 // pluginCache stores the parsed and filtered plugin list
@@ -34,6 +37,11 @@ var (
 	pluginCacheMutex sync.Mutex
 )
 
+// bundled plugins are checked like gallery downloads
+func init() {
+	bundlemgr.RegisterPluginVerifier(VerifyPluginFile)
+}
+
 // GetPluginGallery fetches the list of available plugins from GitHub Pages
 func GetPluginGallery(forceUpdate bool) ([]GalleryPlugin, error) {
 	pluginCacheMutex.Lock()
@@ -164,16 +172,19 @@ func SavePluginToDisk(name string, redownload bool) error {
 		}
 	}
 
-	// Create file if it doesn't exist
-	file, err := os.Create(saveFilePath)
+	// Download next to the target first, it only replaces the plugin once it is verified
+	downloadPath := saveFilePath + ".download"
+	file, err := os.OpenFile(downloadPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
-		logger.Plugin.Error(fmt.Sprintf("Failed to create file %s: %v", saveFilePath, err))
+		logger.Plugin.Error(fmt.Sprintf("Failed to create file %s: %v", downloadPath, err))
 		return fmt.Errorf("disk's throwing a fit, can't save file")
 	}
 
-	// Copy response body to file
-	if _, err := io.Copy(file, resp.Body); err != nil {
+	// Copy response body to file, hashing it on the way
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(file, hash), resp.Body); err != nil {
 		file.Close()
+		os.Remove(downloadPath)
 		logger.Plugin.Error(fmt.Sprintf("Failed to save plugin %s: %v", filename, err))
 		return fmt.Errorf("couldn't save %s, disk's being dramatic", filename)
 	}
@@ -181,14 +192,27 @@ func SavePluginToDisk(name string, redownload bool) error {
 	// Flush writes to disk and close file
 	if err := file.Sync(); err != nil {
 		file.Close()
-		logger.Plugin.Error(fmt.Sprintf("Failed to sync plugin file %s: %v", saveFilePath, err))
+		os.Remove(downloadPath)
+		logger.Plugin.Error(fmt.Sprintf("Failed to sync plugin file %s: %v", downloadPath, err))
 		return fmt.Errorf("couldn't sync %s to disk", filename)
 	}
 	if err := file.Close(); err != nil {
-		logger.Plugin.Error(fmt.Sprintf("Failed to close plugin file %s: %v", saveFilePath, err))
+		os.Remove(downloadPath)
+		logger.Plugin.Error(fmt.Sprintf("Failed to close plugin file %s: %v", downloadPath, err))
 		return fmt.Errorf("couldn't close %s", filename)
 	}
 
+	if err := verifyDownload(name, &plugin.Integrity, hash.Sum(nil), true); err != nil {
+		logger.Plugin.Error(fmt.Sprintf("Plugin %s refused: %v", name, err))
+		quarantineFile(filename, downloadPath)
+		return err
+	}
+	if err := os.Rename(downloadPath, saveFilePath); err != nil {
+		os.Remove(downloadPath)
+		logger.Plugin.Error(fmt.Sprintf("Failed to move plugin %s into place: %v", saveFilePath, err))
+		return fmt.Errorf("couldn't replace %s, is the plugin still running?", filename)
+	}
+
 	// Brief delay to ensure filesystem releases the file
 	time.Sleep(100 * time.Millisecond)
 
@@ -206,3 +230,22 @@ func SavePluginToDisk(name string, redownload bool) error {
 	loader.ReloadBackend()
 	return nil
 }
+
+// VerifyPluginFile checks a plugin executable obtained outside the gallery, like from a bundle,
+// against the signed digest of the gallery plugin with the same executable name for this OS
+func VerifyPluginFile(filename string, digest []byte) error {
+	plugins, err := GetPluginGallery(false)
+	if err != nil {
+		return err
+	}
+	for _, plugin := range plugins {
+		executableURL := plugin.LinuxExecutableURL
+		if runtime.GOOS == "windows" {
+			executableURL = plugin.WindowsExecutableURL
+		}
+		if executableURL != "" && filepath.Base(executableURL) == filename {
+			return verifyDownload(plugin.Name, &plugin.Integrity, digest, true)
+		}
+	}
+	return fmt.Errorf("%w: %s is not a plugin of the gallery", ErrVerificationFailed, filename)
+}
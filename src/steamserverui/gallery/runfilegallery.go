@@ -1,20 +1,18 @@
 package gallery
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/core/loader"
 	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/runfile"
 )
 
 // SettingValue represents a setting with a single value type
@@ -34,25 +32,32 @@ type Plugin struct {
 
 // GalleryRunfile represents a runfile in the gallery
 type GalleryRunfile struct {
-	Name                string         `json:"name"`
-	Filename            string         `json:"filename"`
-	Version             string         `json:"version"`
-	BackgroundURL       string         `json:"background_url"`
-	LogoURL             string         `json:"logo_url"`
-	SupportedOS         string         `json:"supported_os"`
-	CurrentOS           string         `json:"current_os"`
-	MinVersion          string         `json:"min_version"`
-	RecommendedSettings []SettingValue `json:"recommended_settings,omitempty"`
-	RecommendedPlugins  []Plugin       `json:"recommended_plugins,omitempty"`
+	Name                string           `json:"name"`
+	Filename            string           `json:"filename"`
+	Version             string           `json:"version"`
+	BackgroundURL       string           `json:"background_url"`
+	LogoURL             string           `json:"logo_url"`
+	SupportedOS         string           `json:"supported_os"`
+	CurrentOS           string           `json:"current_os"`
+	MinVersion          string           `json:"min_version"`
+	RecommendedSettings []SettingValue   `json:"recommended_settings,omitempty"`
+	RecommendedPlugins  []Plugin         `json:"recommended_plugins,omitempty"`
+	Changelog           []ChangelogEntry `json:"changelog,omitempty"`
+	Integrity
+	Source string   `json:"source"`            // gallery source the runfile is installed from
+	AlsoIn []string `json:"also_in,omitempty"` // lower priority sources offering the same runfile
 }
 
-// galleryCache stores the parsed and filtered runfile list
+// galleryCache stores the merged and filtered runfile list of all sources
 var (
-	galleryCache []GalleryRunfile
-	cacheMutex   sync.Mutex
+	galleryCache        []GalleryRunfile
+	galleryCacheSources []config.GallerySource          // the sources the cache was built from
+	galleryEntries      map[string]map[string]Integrity // source name to runfile name, including shadowed entries
+	sourceStatus        []SourceStatus
+	cacheMutex          sync.Mutex
 )
 
-// GetRunfileGallery fetches the list of available runfiles from GitHub Pages
+// GetRunfileGallery fetches and merges the runfile manifests of all enabled gallery sources
 func GetRunfileGallery(forceUpdate bool) ([]GalleryRunfile, error) {
 	cacheMutex.Lock()
 	defer cacheMutex.Unlock()
@@ -64,67 +69,50 @@ func GetRunfileGallery(forceUpdate bool) ([]GalleryRunfile, error) {
 		return nil, fmt.Errorf("unsupported operating system: %s", currentOS)
 	}
 
-	// Return cached results if not forcing an update and cache is populated
-	if !forceUpdate && len(galleryCache) > 0 {
+	// Return cached results if not forcing an update, the cache is populated and the sources didn't change
+	sources := config.GetRunfileGallerySources()
+	if !forceUpdate && len(galleryCache) > 0 && reflect.DeepEqual(sources, galleryCacheSources) {
 		logger.Runfile.Debug("Serving runfile gallery from cache")
 		return appendOStoRunfiles(galleryCache, currentOS), nil
 	}
 
-	// Fetch manifest from GitHub Pages
-	const manifestURL = "https://steamserverui.github.io/runfiles/manifest.ssui"
-	logger.Runfile.Debug("Fetching runfile gallery from " + manifestURL)
-	resp, err := http.Get(manifestURL)
+	merged, entries, status, err := fetchAllManifests(sources)
 	if err != nil {
-		logger.Runfile.Error(fmt.Sprintf("Failed to fetch manifest: %v", err))
-		return nil, fmt.Errorf("couldn't reach the gallery, network's playing hide and seek")
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		logger.Runfile.Error(fmt.Sprintf("Manifest fetch failed with status: %d", resp.StatusCode))
-		return nil, fmt.Errorf("gallery failed to fetch manifest from GitHub, status: %d", resp.StatusCode)
-	}
-
-	// Parse manifest
-	var runfiles []GalleryRunfile
-	if err := json.NewDecoder(resp.Body).Decode(&runfiles); err != nil {
-		logger.Runfile.Error(fmt.Sprintf("Failed to parse manifest: %v", err))
-		return nil, fmt.Errorf("manifest is gibberish, can't make sense of it")
+		return nil, err
 	}
 
 	// Filter by backend version and OS
 	currentVersion := config.GetVersion()
 	var filtered []GalleryRunfile
-	for _, rf := range runfiles {
-		if compareVersions(rf.MinVersion, currentVersion) <= 0 {
-			// Filter recommended settings and plugins by OS
-			var filteredSettings []SettingValue
-			for _, setting := range rf.RecommendedSettings {
-				if isOSCompatible(setting.SupportedOS, currentOS) {
-					filteredSettings = append(filteredSettings, setting)
-				}
+	for _, rf := range merged {
+		// Filter recommended settings and plugins by OS
+		var filteredSettings []SettingValue
+		for _, setting := range rf.RecommendedSettings {
+			if isOSCompatible(setting.SupportedOS, currentOS) {
+				filteredSettings = append(filteredSettings, setting)
 			}
+		}
 
-			var filteredPlugins []Plugin
-			for _, plugin := range rf.RecommendedPlugins {
-				if isOSCompatible(plugin.SupportedOS, currentOS) {
-					filteredPlugins = append(filteredPlugins, plugin)
-				}
+		var filteredPlugins []Plugin
+		for _, plugin := range rf.RecommendedPlugins {
+			if isOSCompatible(plugin.SupportedOS, currentOS) {
+				filteredPlugins = append(filteredPlugins, plugin)
 			}
-
-			// Create a new runfile manifest entry with filtered settings and plugins
-			filteredRunfileManifest := rf
-			filteredRunfileManifest.RecommendedSettings = filteredSettings
-			filteredRunfileManifest.RecommendedPlugins = filteredPlugins
-			filtered = append(filtered, filteredRunfileManifest)
-		} else {
-			logger.Runfile.Debug(fmt.Sprintf("Skipping runfile %s, requires version %s, current is %s", rf.Name, rf.MinVersion, currentVersion))
 		}
+
+		// Create a new runfile manifest entry with filtered settings and plugins
+		filteredRunfileManifest := rf
+		filteredRunfileManifest.RecommendedSettings = filteredSettings
+		filteredRunfileManifest.RecommendedPlugins = filteredPlugins
+		filtered = append(filtered, filteredRunfileManifest)
 	}
 
 	// Update cache
 	galleryCache = filtered
-	logger.Runfile.Info(fmt.Sprintf("Fetched and cached %d runfiles", len(filtered)))
+	galleryCacheSources = sources
+	galleryEntries = entries
+	sourceStatus = status
+	logger.Runfile.Info(fmt.Sprintf("Fetched and cached %d runfiles from %d gallery sources", len(filtered), len(status)))
 
 	if len(filtered) == 0 {
 		logger.Runfile.Warn("No runfiles compatible with backend version " + currentVersion)
@@ -151,29 +139,79 @@ func isOSCompatible(supportedOS, currentOS string) bool {
 	return supportedOS == currentOS
 }
 
-// saveRunfileToDisk downloads a runfile by identifier and saves it to RunfilesDir
-func SaveRunfileToDisk(identifier string, redownload bool) error {
+// fetchRunfile downloads a runfile by identifier, from the named source or, if empty, from the source the gallery lists it under
+func fetchRunfile(identifier, sourceName string) ([]byte, string, error) {
 	// Validate identifier: reject if contains path separators or ".."
 	if strings.Contains(identifier, "/") || strings.Contains(identifier, "\\") || strings.Contains(identifier, "..") {
-		return fmt.Errorf("invalid identifier: path traversal or separator detected")
+		return nil, "", fmt.Errorf("invalid identifier: path traversal or separator detected")
 	}
 	filename := fmt.Sprintf("run%s.ssui", identifier)
-	baseURL := "https://steamserverui.github.io/runfiles"
-	fileURL := fmt.Sprintf("%s/%s", baseURL, filename)
 
-	logger.Runfile.Debug("Fetching runfile from " + fileURL)
-	resp, err := http.Get(fileURL)
+	candidates, err := runfileSources(identifier, sourceName)
+	if err != nil {
+		return nil, "", err
+	}
+	var lastErr error
+	for _, source := range candidates {
+		logger.Runfile.Debug(fmt.Sprintf("Fetching runfile %s from gallery source %s", filename, source.Name))
+		data, err := readFromSource(source, filename)
+		if err == nil {
+			if err := verifyData(identifier, manifestEntry(source.Name, identifier), data); err != nil {
+				logger.Runfile.Error(fmt.Sprintf("Runfile %s from %s refused: %v", filename, source.Name, err))
+				quarantineData(filename, data)
+				return nil, "", err
+			}
+			return data, source.Name, nil
+		}
+		logger.Runfile.Error(fmt.Sprintf("Failed to fetch runfile %s from %s: %v", filename, source.Name, err))
+		lastErr = err
+	}
+	if lastErr == nil {
+		return nil, "", fmt.Errorf("no gallery source is enabled")
+	}
+	return nil, "", fmt.Errorf("couldn't grab %s: %w", filename, lastErr)
+}
+
+// manifestEntry returns the integrity data a source's manifest lists for a runfile, nil if it isn't listed
+func manifestEntry(sourceName, identifier string) *Integrity {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	if entry, ok := galleryEntries[sourceName][identifier]; ok {
+		return &entry
+	}
+	return nil
+}
+
+// PreviewRunfileUpdate downloads a runfile and compares it with the installed one and its local overrides, without changing anything.
+// source picks a gallery source, empty uses the one the gallery lists the runfile under.
+func PreviewRunfileUpdate(identifier, source string) (*runfile.UpdatePlan, error) {
+	data, _, err := fetchRunfile(identifier, source)
+	if err != nil {
+		return nil, err
+	}
+	return runfile.PlanRunfileUpdate(config.GetRunfilesFolder(), identifier, data)
+}
+
+// SaveRunfileToDisk downloads a runfile by identifier and saves it to RunfilesDir.
+// Local overrides are rebased onto the new runfile, conflicting ones keep the local value unless listed in takeUpstream.
+// source picks a gallery source, empty uses the one the gallery lists the runfile under.
+func SaveRunfileToDisk(identifier, source string, redownload bool, takeUpstream []string) (*runfile.UpdatePlan, error) {
+	plan, err := writeGalleryRunfile(identifier, source, redownload, takeUpstream)
 	if err != nil {
-		logger.Runfile.Error(fmt.Sprintf("Failed to fetch runfile %s: %v", filename, err))
-		return fmt.Errorf("couldn't grab %s, network's being a jerk", filename)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	loader.InitRunfile(identifier)
+	return plan, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		logger.Runfile.Error(fmt.Sprintf("Runfile %s fetch failed with status: %d", filename, resp.StatusCode))
-		return fmt.Errorf("%s is playing hard to get, status: %d", filename, resp.StatusCode)
+// writeGalleryRunfile fetches, verifies and writes a gallery runfile without loading it
+func writeGalleryRunfile(identifier, source string, redownload bool, takeUpstream []string) (*runfile.UpdatePlan, error) {
+	data, sourceName, err := fetchRunfile(identifier, source)
+	if err != nil {
+		return nil, err
 	}
 
+	filename := fmt.Sprintf("run%s.ssui", identifier)
 	saveFilePath := filepath.Join(config.GetRunfilesFolder(), filename)
 	logger.Runfile.Debug("Saving runfile to " + saveFilePath)
 
@@ -182,53 +220,25 @@ func SaveRunfileToDisk(identifier string, redownload bool) error {
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			logger.Runfile.Error(fmt.Sprintf("Failed to create runfiles directory %s: %v", dir, err))
-			return fmt.Errorf("couldn't create directory")
+			return nil, fmt.Errorf("couldn't create directory")
 		}
 	}
 
 	// Check if file already exists
 	if _, err := os.Stat(saveFilePath); err == nil && !redownload {
 		logger.Plugin.Debug(fmt.Sprintf("Runfile %s already exists at %s", identifier, saveFilePath))
-		return fmt.Errorf("runfile %s already exists as %s", identifier, saveFilePath)
-	}
-
-	// Check if file already exists
-	if _, err := os.Stat(saveFilePath); err == nil && redownload {
-		// create old folder if it doesn't exist
-		oldDir := filepath.Join(filepath.Dir(saveFilePath), "old")
-		if err := os.MkdirAll(oldDir, 0755); err != nil {
-			logger.Runfile.Error(fmt.Sprintf("Failed to create old directory %s: %v", oldDir, err))
-			return fmt.Errorf("couldn't create old directory %s, disk's being dramatic", oldDir)
-		}
-
-		// create backup filename in old folder
-		backupFilename := fmt.Sprintf("%s-old-%s.bak", filepath.Base(saveFilePath), time.Now().Format("2006-01-02_15-04-05"))
-		newfp := filepath.Join(oldDir, backupFilename)
-
-		// rename old file to old folder
-		if err := os.Rename(saveFilePath, newfp); err != nil {
-			logger.Runfile.Error(fmt.Sprintf("Failed to rename old runfile %s to %s: %v", saveFilePath, newfp, err))
-			return fmt.Errorf("couldn't rename %s to %s, disk's being dramatic", saveFilePath, newfp)
-		}
+		return nil, fmt.Errorf("runfile %s already exists as %s", identifier, saveFilePath)
 	}
 
-	// Create the file
-	file, err := os.Create(saveFilePath)
+	// Rebases the local overrides, backs up the old runfile and writes the new one, rolling back on failure
+	plan, err := runfile.ApplyRunfileUpdate(dir, identifier, data, takeUpstream)
 	if err != nil {
-		logger.Runfile.Error(fmt.Sprintf("Failed to create file %s: %v", saveFilePath, err))
-		return fmt.Errorf("disk's throwing a fit, can't save file")
-	}
-	defer file.Close()
-
-	// Copy response body to file
-	if _, err := io.Copy(file, resp.Body); err != nil {
-		logger.Runfile.Error(fmt.Sprintf("Failed to save runfile %s: %v", filename, err))
-		return fmt.Errorf("couldn't save %s, disk's being dramatic", filename)
+		logger.Runfile.Error(fmt.Sprintf("Failed to update runfile %s: %v", filename, err))
+		return nil, fmt.Errorf("couldn't update %s with your local settings: %w", filename, err)
 	}
 
-	logger.Runfile.Debug("Successfully saved runfile " + filename)
-	loader.InitRunfile(identifier)
-	return nil
+	logger.Runfile.Info(fmt.Sprintf("Saved runfile %s from gallery source %s", filename, sourceName))
+	return plan, nil
 }
 
 // compareVersions compares two semantic version strings (x.y.z)
@@ -0,0 +1,225 @@
+package gallery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+/*
+Runfile Gallery Sources
+- Sources are configured in RunfileGallerySources, each is a HTTP(S) base URL or a local directory
+  holding manifest.ssui and the run<Identifier>.ssui files
+- Manifests are read in order of priority, highest first, sources with the same priority keep their configured order
+- When several sources offer the same runfile name, the first one wins and the others are listed in also_in,
+  a specific source can still be picked when installing
+- Entries that need a newer SSUI are dropped before merging, so a compatible entry of a lower priority source can win
+- A source that can't be read is skipped and reported in its status, the gallery only fails if every source does
+*/
+
+const (
+	manifestFilename  = "manifest.ssui"
+	maxSourceFileSize = 16 << 20
+)
+
+// galleryClient has a timeout so an unreachable self-hosted source doesn't stall the whole gallery
+var galleryClient = &http.Client{Timeout: 30 * time.Second}
+
+// SourceStatus is a configured gallery source with the result of the last manifest fetch
+type SourceStatus struct {
+	config.GallerySource
+	Runfiles  int       `json:"runfiles"`
+	Error     string    `json:"error,omitempty"`
+	FetchedAt time.Time `json:"fetched_at,omitzero"`
+}
+
+// isURLLocation reports whether a source location is fetched over HTTP(S)
+func isURLLocation(location string) bool {
+	lower := strings.ToLower(location)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// baseLocation accepts a location pointing at the manifest itself and returns the directory it lives in
+func baseLocation(location string) string {
+	location = strings.TrimSuffix(strings.TrimSuffix(location, manifestFilename), "/")
+	if !isURLLocation(location) {
+		location = strings.TrimPrefix(location, "file://")
+	}
+	return location
+}
+
+// enabledSources returns the enabled sources, highest priority first
+func enabledSources(sources []config.GallerySource) []config.GallerySource {
+	var enabled []config.GallerySource
+	for _, source := range sources {
+		if !source.Disabled {
+			enabled = append(enabled, source)
+		}
+	}
+	sort.SliceStable(enabled, func(i, j int) bool {
+		return enabled[i].Priority > enabled[j].Priority
+	})
+	return enabled
+}
+
+// readFromSource reads a file from a gallery source
+func readFromSource(source config.GallerySource, filename string) ([]byte, error) {
+	base := baseLocation(source.Location)
+	if !isURLLocation(base) {
+		path := filepath.Join(base, filename)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s not found in %s", filename, base)
+		}
+		if info.Size() > maxSourceFileSize {
+			return nil, fmt.Errorf("%s is larger than %d bytes", filename, maxSourceFileSize)
+		}
+		return os.ReadFile(path)
+	}
+
+	resp, err := galleryClient.Get(base + "/" + filename)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't reach %s, network's playing hide and seek: %w", base, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s is playing hard to get, status: %d", filename, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSourceFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't download %s: %w", filename, err)
+	}
+	if len(data) > maxSourceFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", filename, maxSourceFileSize)
+	}
+	return data, nil
+}
+
+// fetchAllManifests reads the manifest of every enabled source and merges them by runfile name
+func fetchAllManifests(sources []config.GallerySource) ([]GalleryRunfile, map[string]map[string]Integrity, []SourceStatus, error) {
+	enabled := enabledSources(sources)
+	if len(enabled) == 0 {
+		return nil, nil, nil, fmt.Errorf("no gallery source is enabled")
+	}
+
+	currentVersion := config.GetVersion()
+	var merged []GalleryRunfile
+	index := make(map[string]int)
+	entries := make(map[string]map[string]Integrity)
+	status := make([]SourceStatus, 0, len(enabled))
+	reachable := 0
+	for _, source := range enabled {
+		sourceState := SourceStatus{GallerySource: source, FetchedAt: time.Now()}
+		logger.Runfile.Debug(fmt.Sprintf("Fetching runfile gallery from source %s (%s)", source.Name, source.Location))
+		data, err := readFromSource(source, manifestFilename)
+		if err == nil {
+			var runfiles []GalleryRunfile
+			if jsonErr := json.Unmarshal(data, &runfiles); jsonErr != nil {
+				err = fmt.Errorf("manifest is gibberish, can't make sense of it: %w", jsonErr)
+			} else {
+				reachable++
+				entries[source.Name] = make(map[string]Integrity)
+				for _, rf := range runfiles {
+					if rf.Name == "" {
+						continue
+					}
+					if compareVersions(rf.MinVersion, currentVersion) > 0 {
+						logger.Runfile.Debug(fmt.Sprintf("Skipping runfile %s from %s, requires version %s, current is %s", rf.Name, source.Name, rf.MinVersion, currentVersion))
+						continue
+					}
+					if _, duplicate := entries[source.Name][rf.Name]; !duplicate {
+						entries[source.Name][rf.Name] = rf.Integrity
+					}
+					sourceState.Runfiles++
+					if i, exists := index[rf.Name]; exists {
+						if merged[i].Source != source.Name {
+							logger.Runfile.Debug(fmt.Sprintf("Runfile %s from %s is shadowed by %s", rf.Name, source.Name, merged[i].Source))
+							merged[i].AlsoIn = append(merged[i].AlsoIn, source.Name)
+						}
+						continue
+					}
+					rf.Source, rf.AlsoIn = source.Name, nil
+					index[rf.Name] = len(merged)
+					merged = append(merged, rf)
+				}
+			}
+		}
+		if err != nil {
+			logger.Runfile.Warn(fmt.Sprintf("Gallery source %s skipped: %v", source.Name, err))
+			sourceState.Error = err.Error()
+		}
+		status = append(status, sourceState)
+	}
+
+	if reachable == 0 {
+		return nil, nil, status, fmt.Errorf("couldn't reach any gallery source, network's playing hide and seek")
+	}
+	return merged, entries, status, nil
+}
+
+// runfileSources returns the sources to download a runfile from, in the order they are tried
+func runfileSources(identifier, sourceName string) ([]config.GallerySource, error) {
+	sources := config.GetRunfileGallerySources()
+	byName := make(map[string]config.GallerySource)
+	for _, source := range sources {
+		byName[source.Name] = source
+	}
+
+	// loads the manifests too, their entries are needed to verify the download
+	runfiles, galleryErr := GetRunfileGallery(false)
+
+	if sourceName != "" {
+		source, ok := byName[sourceName]
+		if !ok {
+			return nil, fmt.Errorf("gallery source %s is not configured", sourceName)
+		}
+		if source.Disabled {
+			return nil, fmt.Errorf("gallery source %s is disabled", sourceName)
+		}
+		return []config.GallerySource{source}, nil
+	}
+
+	// the source the gallery lists the runfile under, shadowed copies are only used when picked explicitly
+	if galleryErr == nil {
+		for _, rf := range runfiles {
+			if rf.Name != identifier {
+				continue
+			}
+			if source, ok := byName[rf.Source]; ok {
+				return []config.GallerySource{source}, nil
+			}
+		}
+	}
+
+	// not in any manifest, every source is asked by priority
+	return enabledSources(sources), nil
+}
+
+// GetGallerySourceStatus returns the configured sources with the result of the last manifest fetch
+func GetGallerySourceStatus() []SourceStatus {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	sources := config.GetRunfileGallerySources()
+	result := make([]SourceStatus, 0, len(sources))
+	for _, source := range sources {
+		state := SourceStatus{GallerySource: source}
+		for _, fetched := range sourceStatus {
+			if fetched.GallerySource == source {
+				state = fetched
+				break
+			}
+		}
+		result = append(result, state)
+	}
+	return result
+}
@@ -0,0 +1,172 @@
+package gallery
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/discord/discordbot"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/runfile"
+)
+
+/*
+Runfile Update Checks
+- The installed runfile's Meta.Version is compared with the gallery entry of the same name
+- A background check refreshes the gallery every runfileUpdateCheckInterval and posts to Discord once per new version
+- Upgrading redownloads the runfile from its gallery source: local overrides are rebased onto the new version
+  and the previous runfile is moved to runfiles/old. Unlike installing, it doesn't stop the server or run SteamCMD
+*/
+
+const runfileUpdateCheckInterval = 6 * time.Hour
+
+// ChangelogEntry describes the changes of one runfile version in a manifest
+type ChangelogEntry struct {
+	Version string   `json:"version"`
+	Date    string   `json:"date,omitempty"`
+	Changes []string `json:"changes"`
+}
+
+// RunfileUpdateStatus tells whether the gallery has a newer version of the installed runfile
+type RunfileUpdateStatus struct {
+	Identifier       string           `json:"identifier"`
+	InstalledVersion string           `json:"installed_version"`
+	AvailableVersion string           `json:"available_version,omitempty"`
+	Source           string           `json:"source,omitempty"`
+	Listed           bool             `json:"listed"` // false for runfiles the gallery doesn't offer
+	UpdateAvailable  bool             `json:"update_available"`
+	Changelog        []ChangelogEntry `json:"changelog,omitempty"` // versions between the installed and the available one, newest first
+	CheckedAt        time.Time        `json:"checked_at"`
+}
+
+var (
+	updateCheckerOnce   sync.Once
+	notifiedMutex       sync.Mutex
+	lastNotifiedVersion string // identifier@version the last Discord notice was sent for
+)
+
+// CheckRunfileUpdate compares the installed runfile with the gallery, refresh refetches the manifests first
+func CheckRunfileUpdate(refresh bool) (*RunfileUpdateStatus, error) {
+	identifier := config.GetRunfileIdentifier()
+	installed, err := runfile.GetCurrentMeta("version")
+	if identifier == "" || err != nil {
+		return nil, fmt.Errorf("no runfile is loaded")
+	}
+
+	runfiles, err := GetRunfileGallery(refresh)
+	if err != nil {
+		return nil, err
+	}
+	status := &RunfileUpdateStatus{Identifier: identifier, InstalledVersion: installed, CheckedAt: time.Now()}
+	for _, rf := range runfiles {
+		if rf.Name != identifier {
+			continue
+		}
+		status.Listed = true
+		status.AvailableVersion = rf.Version
+		status.Source = rf.Source
+		status.UpdateAvailable = compareVersions(rf.Version, installed) > 0
+		if status.UpdateAvailable {
+			status.Changelog = changelogBetween(rf.Changelog, installed, rf.Version)
+		}
+		break
+	}
+	return status, nil
+}
+
+// changelogBetween returns the entries newer than installed up to available, newest first
+func changelogBetween(changelog []ChangelogEntry, installed, available string) []ChangelogEntry {
+	var entries []ChangelogEntry
+	for _, entry := range changelog {
+		if compareVersions(entry.Version, installed) > 0 && compareVersions(entry.Version, available) <= 0 {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return compareVersions(entries[i].Version, entries[j].Version) > 0
+	})
+	return entries
+}
+
+// UpgradeRunfile installs the gallery version of the current runfile, keeping local values.
+// Conflicting overrides keep the local value unless listed in takeUpstream. The gameserver keeps running,
+// the caller reloads the backend and the new version is used from the next server start.
+func UpgradeRunfile(takeUpstream []string) (*runfile.UpdatePlan, *RunfileUpdateStatus, error) {
+	status, err := CheckRunfileUpdate(false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !status.Listed {
+		return nil, status, fmt.Errorf("runfile %s is not offered by any gallery source", status.Identifier)
+	}
+	if !status.UpdateAvailable {
+		return nil, status, fmt.Errorf("runfile %s is already up to date at version %s", status.Identifier, status.InstalledVersion)
+	}
+
+	logger.Runfile.Info(fmt.Sprintf("Upgrading runfile %s from %s to %s", status.Identifier, status.InstalledVersion, status.AvailableVersion))
+	plan, err := writeGalleryRunfile(status.Identifier, status.Source, true, takeUpstream)
+	if err != nil {
+		return nil, status, err
+	}
+	return plan, status, nil
+}
+
+// StartRunfileUpdateChecker checks for runfile updates in the background, it is only started once
+func StartRunfileUpdateChecker() {
+	updateCheckerOnce.Do(func() {
+		go runfileUpdateLoop()
+	})
+}
+
+func runfileUpdateLoop() {
+	// give the backend time to settle before the first check
+	time.Sleep(time.Minute)
+	checkAndNotify()
+	ticker := time.NewTicker(runfileUpdateCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		checkAndNotify()
+	}
+}
+
+func checkAndNotify() {
+	if !config.GetIsRunfileUpdateCheckEnabled() {
+		return
+	}
+	status, err := CheckRunfileUpdate(true)
+	if err != nil {
+		logger.Runfile.Debug("Runfile update check skipped: " + err.Error())
+		return
+	}
+	if !status.UpdateAvailable {
+		logger.Runfile.Debug(fmt.Sprintf("Runfile %s is up to date", status.Identifier))
+		return
+	}
+
+	notifiedMutex.Lock()
+	defer notifiedMutex.Unlock()
+	if lastNotifiedVersion == status.Identifier+"@"+status.AvailableVersion {
+		return
+	}
+	lastNotifiedVersion = status.Identifier + "@" + status.AvailableVersion
+
+	logger.Runfile.Info(fmt.Sprintf("Runfile update available for %s: %s -> %s", status.Identifier, status.InstalledVersion, status.AvailableVersion))
+	var message strings.Builder
+	fmt.Fprintf(&message, "📦 Runfile update available for **%s**: %s → %s (from %s)", status.Identifier, status.InstalledVersion, status.AvailableVersion, status.Source)
+	for _, entry := range status.Changelog {
+		// Discord messages are limited to 2000 characters, the full changelog is in the API
+		if message.Len() > 1600 {
+			message.WriteString("\n…")
+			break
+		}
+		fmt.Fprintf(&message, "\n**%s**", entry.Version)
+		for _, change := range entry.Changes {
+			fmt.Fprintf(&message, "\n- %s", change)
+		}
+	}
+	message.WriteString("\nUpgrade from the runfile gallery in SSUI, your settings are kept.")
+	discordbot.SendMessageToStatusChannel(message.String())
+}
@@ -0,0 +1,135 @@
+package gallery
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+/*
+Gallery Download Verification
+- Manifest entries carry the SHA-256 digest of the download, an ed25519 signature and the id of the publisher who signed it
+- The signature covers "<name>\n<sha256 hex>", so a signed digest can't be moved to another entry
+- Publisher keys are configured in TrustedPublisherKeys, GalleryVerificationMode decides about incomplete entries:
+  verify checks whatever an entry provides and warns about the rest, require refuses entries without a digest
+  and a valid signature of a trusted publisher
+- Once any publisher key is configured, verify refuses incomplete entries as well, and plugins are always
+  verified as in require mode, they run as their own process
+- A wrong digest or an invalid signature is always refused, the download is kept in SSUI/quarantine for inspection
+*/
+
+// ErrVerificationFailed is returned when a gallery download doesn't match its manifest entry
+var ErrVerificationFailed = errors.New("gallery download failed verification")
+
+// Integrity is the part of a manifest entry a download is verified against
+type Integrity struct {
+	SHA256    string `json:"sha256,omitempty"`
+	Signature string `json:"signature,omitempty"` // base64 ed25519 signature
+	Publisher string `json:"publisher,omitempty"` // id of the key in TrustedPublisherKeys
+}
+
+// signedMessage is what a publisher signs for an entry
+func signedMessage(name string, digest []byte) []byte {
+	return []byte(name + "\n" + hex.EncodeToString(digest))
+}
+
+// verifyDownload checks the digest of a download against its manifest entry. entry is nil if no manifest lists the download.
+func verifyDownload(name string, entry *Integrity, digest []byte, plugin bool) error {
+	require := plugin || config.GetGalleryVerificationMode() == "require" || len(config.GetTrustedPublisherKeys()) > 0
+	if entry == nil {
+		if require {
+			return fmt.Errorf("%w: %s is not listed in a manifest, so it can't be verified", ErrVerificationFailed, name)
+		}
+		logger.Core.Warn(fmt.Sprintf("Gallery download %s is not listed in a manifest and was not verified", name))
+		return nil
+	}
+
+	if entry.SHA256 == "" {
+		if require || entry.Signature != "" {
+			return fmt.Errorf("%w: manifest entry %s has no sha256 digest", ErrVerificationFailed, name)
+		}
+		logger.Core.Warn(fmt.Sprintf("Gallery download %s has no digest and signature in its manifest and was not verified", name))
+		return nil
+	}
+	if !strings.EqualFold(strings.TrimSpace(entry.SHA256), hex.EncodeToString(digest)) {
+		return fmt.Errorf("%w: sha256 of %s is %x, the manifest expects %s", ErrVerificationFailed, name, digest, entry.SHA256)
+	}
+
+	if entry.Signature == "" {
+		if require {
+			return fmt.Errorf("%w: manifest entry %s is not signed", ErrVerificationFailed, name)
+		}
+		logger.Core.Warn(fmt.Sprintf("Gallery download %s matches its digest but is not signed", name))
+		return nil
+	}
+	encodedKey, trusted := config.GetTrustedPublisherKeys()[entry.Publisher]
+	if !trusted {
+		if require {
+			return fmt.Errorf("%w: %s is signed by %q, which is not a trusted publisher", ErrVerificationFailed, name, entry.Publisher)
+		}
+		logger.Core.Warn(fmt.Sprintf("Gallery download %s matches its digest, the signature of untrusted publisher %q was not checked", name, entry.Publisher))
+		return nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: the configured key of publisher %s is invalid", ErrVerificationFailed, entry.Publisher)
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(entry.Signature))
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(key), signedMessage(name, digest), signature) {
+		return fmt.Errorf("%w: signature of %s by %s is invalid", ErrVerificationFailed, name, entry.Publisher)
+	}
+	logger.Core.Debug(fmt.Sprintf("Gallery download %s is signed by trusted publisher %s", name, entry.Publisher))
+	return nil
+}
+
+// verifyData verifies a runfile download held in memory
+func verifyData(name string, entry *Integrity, data []byte) error {
+	digest := sha256.Sum256(data)
+	return verifyDownload(name, entry, digest[:], false)
+}
+
+func quarantinePath(filename string) (string, error) {
+	dir := filepath.Join(config.GetSSUIFolder(), "quarantine")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s-%s.quarantined", time.Now().Format("2006-01-02_15-04-05.000"), filename)), nil
+}
+
+// quarantineData keeps a refused download for inspection, it is written without execute permission
+func quarantineData(filename string, data []byte) {
+	path, err := quarantinePath(filename)
+	if err == nil {
+		err = os.WriteFile(path, data, 0600)
+	}
+	if err != nil {
+		logger.Core.Error(fmt.Sprintf("Failed to quarantine refused download %s: %v", filename, err))
+		return
+	}
+	logger.Core.Warn(fmt.Sprintf("Refused download %s was quarantined as %s", filename, path))
+}
+
+// quarantineFile moves a refused download from disk into quarantine
+func quarantineFile(filename, source string) {
+	path, err := quarantinePath(filename)
+	if err == nil {
+		os.Chmod(source, 0600)
+		err = os.Rename(source, path)
+	}
+	if err != nil {
+		os.Remove(source)
+		logger.Core.Error(fmt.Sprintf("Failed to quarantine refused download %s, it was deleted: %v", filename, err))
+		return
+	}
+	logger.Core.Warn(fmt.Sprintf("Refused download %s was quarantined as %s", filename, path))
+}
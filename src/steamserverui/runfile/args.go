@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -52,21 +53,26 @@ func (e ErrValidation) Error() string {
 }
 
 type GameArg struct {
-	Flag          string `json:"flag"`
-	Value         string `json:"value"`
-	RuntimeValue  string `json:"-"`
-	Required      bool   `json:"required"`
-	RequiresValue bool   `json:"requires_value"`
-	Description   string `json:"description"`
-	Type          string `json:"type"`
-	Special       string `json:"special,omitempty"`
-	UILabel       string `json:"ui_label"`
-	UIGroup       string `json:"ui_group"`
-	Weight        int    `json:"weight"`
-	Min           int    `json:"min,omitempty"`
-	Max           int    `json:"max,omitempty"`
-	Disabled      bool   `json:"disabled,omitempty"`
-	Os            string `json:"os,omitempty"` // OS restriction ("", "linux", "windows")
+	Flag          string   `json:"flag"`
+	Value         string   `json:"value"`
+	RuntimeValue  string   `json:"-"`
+	NeedsReentry  bool     `json:"-"` // secret that couldn't be decrypted on load, see secrets.go
+	Required      bool     `json:"required"`
+	RequiresValue bool     `json:"requires_value"`
+	Description   string   `json:"description"`
+	Type          string   `json:"type"`
+	Special       string   `json:"special,omitempty"`
+	UILabel       string   `json:"ui_label"`
+	UIGroup       string   `json:"ui_group"`
+	Weight        int      `json:"weight"`
+	Min           float64  `json:"min,omitempty"`     // lower bound for int, float and port args
+	Max           float64  `json:"max,omitempty"`     // upper bound for int, float and port args, 0 means unbounded
+	Options       []string `json:"options,omitempty"` // allowed values for enum args
+	Disabled      bool     `json:"disabled,omitempty"`
+	Os            string   `json:"os,omitempty"`             // OS restriction ("", "linux", "windows")
+	DependsOn     []string `json:"depends_on,omitempty"`     // flags that must be set for this arg to apply
+	ConflictsWith []string `json:"conflicts_with,omitempty"` // flags that can't be set together with this arg
+	EnabledWhen   string   `json:"enabled_when,omitempty"`   // expression over other args, see dependencies.go
 }
 
 type File struct {
@@ -76,6 +82,19 @@ type File struct {
 	Description string `json:"description"`
 }
 
+// AccessListFile declares where and in which format the gameserver reads a player access list
+type AccessListFile struct {
+	Filepath string `json:"filepath"` // relative to the gameserver directory
+	Format   string `json:"format"`   // "lines" (one SteamID per line), "comma" (comma separated) or "json" (array of SteamIDs)
+}
+
+// AccessListFiles maps the SSUI player access lists to the gameserver's own files
+type AccessListFiles struct {
+	Bans      *AccessListFile `json:"bans,omitempty"`
+	Whitelist *AccessListFile `json:"whitelist,omitempty"`
+	Admins    *AccessListFile `json:"admins,omitempty"`
+}
+
 type Meta struct {
 	Name    string `json:"name"`             // SSUI Specific Game Identifier, must match the one in the filename.
 	Version string `json:"version"`          // Runfile version
@@ -84,16 +103,30 @@ type Meta struct {
 	Logo    string `json:"logo,omitempty"`   // Logo to display in the UI
 }
 
+// WorkshopConfig declares Steam Workshop support, the items are downloaded with SteamCMD and placed into ModsDir
+type WorkshopConfig struct {
+	AppID         string `json:"app_id"`                   // Workshop app ID, usually the game's and not the dedicated server's
+	ModsDir       string `json:"mods_dir"`                 // relative to the gameserver directory
+	DirName       string `json:"dir_name,omitempty"`       // name of each item's directory in ModsDir, {id} is replaced by the item ID, default "{id}"
+	Mode          string `json:"mode,omitempty"`           // "link" (default, copies on Windows) or "copy"
+	LoginRequired bool   `json:"login_required,omitempty"` // the Workshop can't be downloaded anonymously
+}
+
 type RunFile struct {
+	SchemaVersion      int                  `json:"schema_version"` // runfile format version, see migrations.go
 	Meta               Meta                 `json:"meta"`
 	Architecture       string               `json:"architecture,omitempty"`
 	BackupContentDir   string               `json:"backup_content_dir,omitempty"`
 	SteamAppID         string               `json:"steam_app_id"`
 	SteamLoginRequired bool                 `json:"steam_login_required,omitempty"`
+	LogRunStartMarker  string               `json:"log_run_start_marker,omitempty"` // text of the first log line of every run, see GetLogRunStartMarker
 	WindowsExecutable  string               `json:"windows_executable"`
 	LinuxExecutable    string               `json:"linux_executable"`
 	Args               map[string][]GameArg `json:"args"`
 	Files              []File               `json:"files,omitempty"`
+	Templates          []FileTemplate       `json:"templates,omitempty"` // config files rendered from the args, see templates.go
+	AccessLists        *AccessListFiles     `json:"access_lists,omitempty"`
+	Workshop           *WorkshopConfig      `json:"workshop,omitempty"`
 }
 
 // Validate checks the RunFile state
@@ -122,8 +155,11 @@ func (rf *RunFile) Validate() error {
 		issues = append(issues, "Meta.Name is required")
 	}
 
-	// Validate args for current OS
-	for _, arg := range rf.getAllArgs() {
+	// Validate args for current OS, with placeholders resolved
+	allArgs, interpolationIssues := resolveArgs(rf.getAllArgs())
+	issues = append(issues, interpolationIssues...)
+	resolver := newArgResolver(allArgs)
+	for _, arg := range allArgs {
 		// Validate os field (already filtered by getAllArgs, but ensure consistency)
 		if arg.Os != "" && arg.Os != "linux" && arg.Os != "windows" {
 			issues = append(issues, fmt.Sprintf("invalid os value for %s: %s, must be 'linux' or 'windows'", arg.Flag, arg.Os))
@@ -131,24 +167,21 @@ func (rf *RunFile) Validate() error {
 		if arg.Disabled {
 			continue
 		}
-		if arg.Required && arg.RequiresValue && arg.RuntimeValue == "" {
+		// cleared secrets are reported by BuildCommandArgs, the runfile must stay loadable to enter them again
+		if arg.Required && arg.RequiresValue && arg.RuntimeValue == "" && !arg.NeedsReentry && (arg.Flag == "" || resolver.isActive(arg.Flag)) {
 			issues = append(issues, fmt.Sprintf("required argument %s has no value", arg.Flag))
 		}
-		switch arg.Type {
-		case "int":
-			if arg.RuntimeValue != "" {
-				if _, err := strconv.Atoi(arg.RuntimeValue); err != nil {
-					issues = append(issues, fmt.Sprintf("invalid integer value for %s: %s", arg.Flag, arg.RuntimeValue))
-				}
-			}
-		case "bool":
-			if arg.RuntimeValue != "" && arg.RuntimeValue != "true" && arg.RuntimeValue != "false" {
-				issues = append(issues, fmt.Sprintf("invalid boolean value for %s: %s", arg.Flag, arg.RuntimeValue))
-			}
+		issues = append(issues, validateArgDefinition(arg)...)
+		if issue := validateArgValue(arg, arg.RuntimeValue); issue != "" {
+			issues = append(issues, issue)
 		}
 	}
+	issues = append(issues, portConflicts(allArgs)...)
+	issues = append(issues, dependencyIssues(allArgs)...)
 
 	// Validate files
+	_, fileIssues := resolveFiles(rf)
+	issues = append(issues, fileIssues...)
 	for _, file := range rf.Files {
 		if file.Filename == "" {
 			issues = append(issues, "file name is required")
@@ -175,12 +208,58 @@ func (rf *RunFile) Validate() error {
 		}
 	}
 
+	// Validate templates
+	for _, t := range rf.Templates {
+		issues = append(issues, templateIssues(t, config.GetRunFilesFolder())...)
+	}
+
+	// Validate access list files
+	if rf.AccessLists != nil {
+		for name, list := range map[string]*AccessListFile{"bans": rf.AccessLists.Bans, "whitelist": rf.AccessLists.Whitelist, "admins": rf.AccessLists.Admins} {
+			if list == nil {
+				continue
+			}
+			if list.Filepath == "" {
+				issues = append(issues, fmt.Sprintf("access list %s requires a filepath", name))
+			} else if filepath.IsAbs(list.Filepath) || strings.HasPrefix(filepath.Clean(list.Filepath), "..") {
+				issues = append(issues, fmt.Sprintf("access list %s filepath must be relative to the gameserver directory, got %s", name, list.Filepath))
+			}
+			if list.Format != "lines" && list.Format != "comma" && list.Format != "json" {
+				issues = append(issues, fmt.Sprintf("invalid access list format %s for %s, must be 'lines', 'comma' or 'json'", list.Format, name))
+			}
+		}
+	}
+
+	if rf.Workshop != nil {
+		issues = append(issues, workshopIssues(rf.Workshop)...)
+	}
+
 	if len(issues) > 0 {
 		return ErrValidation{Issues: issues}
 	}
 	return nil
 }
 
+// workshopIssues checks a workshop declaration, shared by Validate and the linter
+func workshopIssues(w *WorkshopConfig) []string {
+	var issues []string
+	if _, err := strconv.Atoi(w.AppID); err != nil {
+		issues = append(issues, fmt.Sprintf("workshop app_id must be numeric, got %q", w.AppID))
+	}
+	if w.ModsDir == "" {
+		issues = append(issues, "workshop requires a mods_dir")
+	} else if escapesGameDir(w.ModsDir) {
+		issues = append(issues, fmt.Sprintf("workshop mods_dir %q escapes the gameserver directory", w.ModsDir))
+	}
+	if w.DirName != "" && (!strings.Contains(w.DirName, "{id}") || strings.ContainsAny(w.DirName, `/\`) || strings.Contains(w.DirName, "..")) {
+		issues = append(issues, fmt.Sprintf("workshop dir_name %q must contain {id} and no path separators", w.DirName))
+	}
+	if !slices.Contains(schemaEnums["WorkshopConfig.mode"], w.Mode) {
+		issues = append(issues, fmt.Sprintf("invalid workshop mode %q, must be 'link' or 'copy'", w.Mode))
+	}
+	return issues
+}
+
 // getAllArgs returns all GameArgs for the current OS
 func (rf *RunFile) getAllArgs() []GameArg {
 	var allArgs []GameArg
@@ -245,12 +324,34 @@ func LoadRunfile(gameName, runFilesFolder string) error {
 		return fmt.Errorf("failed to read runfile: %w", err)
 	}
 
+	// Upgrade older runfile formats before parsing, refuse formats from newer SSUI versions
+	fileData, err = upgradeRunfileOnDisk(filePath, fileData)
+	if err != nil {
+		logger.Runfile.Error(fmt.Sprintf("failed to migrate runfile: path=%s, error=%v", filePath, err))
+		return err
+	}
+
 	var runfile RunFile
 	if err := json.Unmarshal(fileData, &runfile); err != nil {
 		logger.Runfile.Error(fmt.Sprintf("failed to parse runfile: path=%s, error=%v", filePath, err))
 		return fmt.Errorf("failed to parse runfile: %w", err)
 	}
 
+	// Keep the upstream runfile as published and merge the local changes into a copy of it
+	var upstream RunFile
+	if err := json.Unmarshal(fileData, &upstream); err != nil {
+		return fmt.Errorf("failed to parse runfile: %w", err)
+	}
+	overridesPath := OverridesPath(runFilesFolder, gameName)
+	overrides, _, err := loadOverrides(overridesPath)
+	if err != nil {
+		logger.Runfile.Error(fmt.Sprintf("failed to load overrides: path=%s, error=%v", overridesPath, err))
+		return err
+	}
+	for _, warning := range applyOverrides(&runfile, overrides) {
+		logger.Runfile.Warn(warning)
+	}
+
 	// dev debugging
 	//for category, args := range runfile.Args {
 	//	for _, arg := range args {
@@ -268,12 +369,17 @@ func LoadRunfile(gameName, runFilesFolder string) error {
 	for category := range runfile.Args {
 		for i := range runfile.Args[category] {
 			runfile.Args[category][i].RuntimeValue = runfile.Args[category][i].Value
-			logger.Runfile.Debug(fmt.Sprintf("initialized arg: flag=%s, value=%s, runtime=%s",
-				runfile.Args[category][i].Flag,
-				runfile.Args[category][i].Value,
-				runfile.Args[category][i].RuntimeValue))
 		}
 	}
+	plainSecrets := decryptSecretArgs(&runfile)
+	registerSecretRedactions(&runfile)
+	for _, arg := range runfile.getAllArgs() {
+		if arg.IsSecret() {
+			logger.Runfile.Debug(fmt.Sprintf("initialized arg: flag=%s, value=%s", arg.Flag, logger.RedactedValue))
+			continue
+		}
+		logger.Runfile.Debug(fmt.Sprintf("initialized arg: flag=%s, value=%s, runtime=%s", arg.Flag, arg.Value, arg.RuntimeValue))
+	}
 
 	// Validate runfile
 	if err := runfile.Validate(); err != nil {
@@ -283,7 +389,20 @@ func LoadRunfile(gameName, runFilesFolder string) error {
 	}
 
 	CurrentRunfile = &runfile
+	upstreamRunfile = &upstream
+	currentOverrides = overrides
 	logger.Runfile.Debug(fmt.Sprintf("runfile loaded: path=%s", filePath))
+	warnOutOfRangeArgs(&runfile)
+
+	// Secrets in plain text were added by hand or by an older SSUI, encrypt them right away.
+	// Plain defaults of the upstream runfile stay as they are, it is never written.
+	if plainSecrets && hasPlainSecretOverrides(upstreamRunfile, overrides) {
+		if err := saveRunfileLocked(); err != nil {
+			logger.Runfile.Warn("failed to encrypt plain text secrets in runfile: " + err.Error())
+		} else {
+			logger.Runfile.Info("Encrypted plain text secret arguments in runfile")
+		}
+	}
 	return nil
 }
 
@@ -291,20 +410,33 @@ func LoadRunfile(gameName, runFilesFolder string) error {
 func SaveRunfile() error {
 	runfileMutex.Lock()
 	defer runfileMutex.Unlock()
+	return saveRunfileLocked()
+}
 
+// saveRunfileLocked must be called with runfileMutex held
+func saveRunfileLocked() error {
 	if CurrentRunfile == nil {
 		err := ErrRunfileNotLoaded{Msg: "runfile not loaded"}
 		logger.Runfile.Error(err.Error())
 		return err
 	}
 
-	// Build filepath
-	filePath := filepath.Join(config.GetRunFilesFolder(), fmt.Sprintf("run%s.ssui", config.GetRunfileIdentifier()))
-	logger.Runfile.Debug(fmt.Sprintf("saving runfile: path=%s", filePath))
+	if upstreamRunfile == nil {
+		err := ErrRunfileNotLoaded{Msg: "upstream runfile not loaded"}
+		logger.Runfile.Error(err.Error())
+		return err
+	}
+
+	// Only the local changes are saved, the upstream runfile stays as published
+	filePath := OverridesPath(config.GetRunFilesFolder(), config.GetRunfileIdentifier())
+	logger.Runfile.Debug(fmt.Sprintf("saving runfile overrides: path=%s", filePath))
 
 	// Update Value from RuntimeValue
 	for category := range CurrentRunfile.Args {
 		for i := range CurrentRunfile.Args[category] {
+			if CurrentRunfile.Args[category][i].IsSecret() {
+				continue // stored encrypted, see encryptSecretArgs
+			}
 			CurrentRunfile.Args[category][i].Value = CurrentRunfile.Args[category][i].RuntimeValue
 		}
 	}
@@ -314,9 +446,16 @@ func SaveRunfile() error {
 		logger.Runfile.Error(fmt.Sprintf("runfile validation failed: path=%s, error=%v", filePath, err))
 		return err
 	}
+	CurrentRunfile.SchemaVersion = CurrentSchemaVersion
+	if err := encryptSecretArgs(CurrentRunfile); err != nil {
+		logger.Runfile.Error(fmt.Sprintf("failed to save runfile: path=%s, error=%v", filePath, err))
+		return err
+	}
+	registerSecretRedactions(CurrentRunfile)
 
 	// Serialize to JSON
-	data, err := json.MarshalIndent(CurrentRunfile, "", "  ")
+	overrides := buildOverrides(CurrentRunfile, upstreamRunfile, currentOverrides)
+	data, err := json.MarshalIndent(overrides, "", "  ")
 	if err != nil {
 		logger.Runfile.Error(fmt.Sprintf("failed to serialize runfile: path=%s, error=%v", filePath, err))
 		return fmt.Errorf("failed to serialize runfile: %w", err)
@@ -336,6 +475,7 @@ func SaveRunfile() error {
 		}
 		break
 	}
+	currentOverrides = overrides
 
 	logger.Runfile.Debug(fmt.Sprintf("runfile saved: path=%s", filePath))
 	return nil
@@ -363,32 +503,59 @@ func SetArgValue(flag string, value string) error {
 
 			// Validate value
 			arg := CurrentRunfile.Args[category][i]
-			switch arg.Type {
-			case "int":
-				if _, err := strconv.Atoi(value); err != nil {
-					err := ErrValidation{Issues: []string{fmt.Sprintf("invalid integer value for %s: %s", flag, value)}}
-					logger.Runfile.Error(fmt.Sprintf("validation failed: flag=%s, value=%s, error=%v", flag, value, err))
-					return err
+			logValue := value
+			if arg.IsSecret() {
+				logValue = logger.RedactedValue
+			}
+			candidate := arg
+			candidate.RuntimeValue = value
+			others := []GameArg{candidate}
+			for _, other := range CurrentRunfile.getAllArgs() {
+				if other.Flag != flag {
+					others = append(others, other)
 				}
-			case "bool":
-				if value != "true" && value != "false" {
-					err := ErrValidation{Issues: []string{fmt.Sprintf("invalid boolean value for %s: %s", flag, value)}}
-					logger.Runfile.Error(fmt.Sprintf("validation failed: flag=%s, value=%s, error=%v", flag, value, err))
-					return err
+			}
+
+			// Placeholders are kept in the runfile, the resolved value is what has to be valid
+			issue := ""
+			resolvedValue, err := newInterpolator(others).expand(value)
+			if err != nil {
+				issue = fmt.Sprintf("failed to resolve value of %s: %v", flag, err)
+			}
+			if issue == "" {
+				issue = validateArgValue(arg, resolvedValue)
+			}
+			if issue == "" {
+				issue = checkArgBounds(arg, resolvedValue)
+			}
+			if issue == "" {
+				issue = checkPathExists(arg, resolvedValue)
+			}
+			if issue == "" && arg.Type == ArgTypePort {
+				resolved, _ := resolveArgs(others)
+				if conflicts := portConflicts(resolved); len(conflicts) > 0 {
+					issue = conflicts[0]
 				}
 			}
+			if issue != "" {
+				err := ErrValidation{Issues: []string{issue}}
+				logger.Runfile.Error(fmt.Sprintf("validation failed: flag=%s, value=%s, error=%v", flag, logValue, err))
+				return err
+			}
 
 			// Transactional update
 			originalValue := arg.RuntimeValue // Clone state
 			CurrentRunfile.Args[category][i].RuntimeValue = value
+			CurrentRunfile.Args[category][i].NeedsReentry = false
 			if err := SaveRunfile(); err != nil {
 				// Rollback on failure
 				CurrentRunfile.Args[category][i].RuntimeValue = originalValue
-				logger.Runfile.Error(fmt.Sprintf("failed to save runfile: flag=%s, value=%s, error=%v", flag, value, err))
+				CurrentRunfile.Args[category][i].NeedsReentry = arg.NeedsReentry
+				logger.Runfile.Error(fmt.Sprintf("failed to save runfile: flag=%s, value=%s, error=%v", flag, logValue, err))
 				return fmt.Errorf("failed to save runfile: %w", err)
 			}
 
-			logger.Runfile.Debug(fmt.Sprintf("set arg: flag=%s, value=%s", flag, value))
+			logger.Runfile.Debug(fmt.Sprintf("set arg: flag=%s, value=%s", flag, logValue))
 			return nil
 		}
 	}
@@ -405,15 +572,40 @@ func BuildCommandArgs() ([]string, error) {
 		logger.Runfile.Error(err.Error())
 		return nil, err
 	}
+	return CurrentRunfile.buildCommandArgs()
+}
 
+// buildCommandArgs builds the command-line arguments of rf
+func (rf *RunFile) buildCommandArgs() ([]string, error) {
 	// Validate before building
-	if err := CurrentRunfile.Validate(); err != nil {
+	if err := rf.Validate(); err != nil {
 		logger.Runfile.Error(fmt.Sprintf("runfile validation failed: error=%v", err))
 		return nil, err
 	}
 
+	// Validate already reported placeholders that can't be resolved
 	var args []string
-	allArgs := CurrentRunfile.getAllArgs()
+	allArgs, _ := resolveArgs(rf.getAllArgs())
+
+	// Paths may have been removed since they were set, the server would fail on them anyway
+	resolver := newArgResolver(allArgs)
+	var missing []string
+	for _, arg := range allArgs {
+		if arg.Disabled || (arg.Flag != "" && !resolver.isActive(arg.Flag)) {
+			continue
+		}
+		if issue := checkPathExists(arg, arg.RuntimeValue); issue != "" {
+			missing = append(missing, issue)
+		}
+		if arg.NeedsReentry {
+			missing = append(missing, fmt.Sprintf("secret argument %s could not be decrypted and has to be entered again", arg.Flag))
+		}
+	}
+	if len(missing) > 0 {
+		err := ErrValidation{Issues: missing}
+		logger.Runfile.Error(fmt.Sprintf("runfile validation failed: error=%v", err))
+		return nil, err
+	}
 
 	// Sort by weight (primary) and UIGroup (secondary)
 	sort.Slice(allArgs, func(i, j int) bool {
@@ -427,6 +619,10 @@ func BuildCommandArgs() ([]string, error) {
 		if arg.Disabled || (!arg.Required && arg.RequiresValue && arg.RuntimeValue == "") {
 			continue
 		}
+		if arg.Flag != "" && !resolver.isActive(arg.Flag) {
+			logger.Runfile.Debug(fmt.Sprintf("skipping inactive arg: %s", resolver.issues[arg.Flag]))
+			continue
+		}
 
 		// Handle space_delimited: split and append non-empty parts
 		if arg.Special == "space_delimited" {
@@ -0,0 +1,195 @@
+// argtypes.go
+package runfile
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+/*
+Argument Types
+- string (default), int, bool: the original types
+- float: decimal number with optional min/max
+- enum: value must be one of options
+- port: 1-65535 with optional min/max, checked for conflicts with other port args and the SSUI port
+- min/max are enforced for values set through the API. Runfiles and overrides from before they existed still
+  load and start with out of range values, those are only logged and linted as warnings
+- path: relative paths are resolved against the gameserver directory and must exist before the server starts
+- secret: free text that the UI renders as a password field
+*/
+
+const (
+	ArgTypeString = "string"
+	ArgTypeInt    = "int"
+	ArgTypeBool   = "bool"
+	ArgTypeFloat  = "float"
+	ArgTypeEnum   = "enum"
+	ArgTypePort   = "port"
+	ArgTypePath   = "path"
+	ArgTypeSecret = "secret"
+)
+
+// knownArgTypes are the arg types validateArgValue understands, "" is treated as string
+var knownArgTypes = []string{"", ArgTypeString, ArgTypeInt, ArgTypeBool, ArgTypeFloat, ArgTypeEnum, ArgTypePort, ArgTypePath, ArgTypeSecret}
+
+// hasBounds reports whether min or max are set on the arg
+func (arg GameArg) hasBounds() bool {
+	return arg.Min != 0 || arg.Max != 0
+}
+
+// checkBounds returns an error message if value is outside of the args min/max, a max of 0 means no upper bound
+func (arg GameArg) checkBounds(value float64) string {
+	if !arg.hasBounds() {
+		return ""
+	}
+	if value < arg.Min || (arg.Max != 0 && value > arg.Max) {
+		return fmt.Sprintf("value for %s must be between %s and %s, got %s", arg.Flag, formatBound(arg.Min), formatBound(arg.Max), formatBound(value))
+	}
+	return ""
+}
+
+func formatBound(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// validateArgValue checks a value against the args type and returns a description of the problem, or "" if the value is fine.
+// Empty values are always fine here, required checks are done by the callers.
+func validateArgValue(arg GameArg, value string) string {
+	if value == "" {
+		return ""
+	}
+	switch arg.Type {
+	case ArgTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Sprintf("invalid integer value for %s: %s", arg.Flag, value)
+		}
+	case ArgTypeBool:
+		if value != "true" && value != "false" {
+			return fmt.Sprintf("invalid boolean value for %s: %s", arg.Flag, value)
+		}
+	case ArgTypeFloat:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return fmt.Sprintf("invalid decimal value for %s: %s", arg.Flag, value)
+		}
+	case ArgTypeEnum:
+		if !slices.Contains(arg.Options, value) {
+			return fmt.Sprintf("invalid value for %s: %s, must be one of %s", arg.Flag, value, strings.Join(arg.Options, ", "))
+		}
+	case ArgTypePort:
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Sprintf("invalid port for %s: %s, must be between 1 and 65535", arg.Flag, value)
+		}
+	case ArgTypePath:
+		if strings.ContainsRune(value, 0) {
+			return fmt.Sprintf("invalid path for %s", arg.Flag)
+		}
+	}
+	return ""
+}
+
+// checkArgBounds returns an error message if a valid numeric value is outside of the args min/max
+func checkArgBounds(arg GameArg, value string) string {
+	if arg.Type != ArgTypeInt && arg.Type != ArgTypeFloat && arg.Type != ArgTypePort {
+		return ""
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return ""
+	}
+	return arg.checkBounds(number)
+}
+
+// warnOutOfRangeArgs warns once per load about values outside of min/max. min/max are new, values from before still
+// start the server, so Validate doesn't report them.
+func warnOutOfRangeArgs(rf *RunFile) {
+	allArgs, _ := resolveArgs(rf.getAllArgs())
+	for _, arg := range allArgs {
+		if arg.Disabled {
+			continue
+		}
+		if issue := checkArgBounds(arg, arg.RuntimeValue); issue != "" {
+			logger.Runfile.Warn(issue + ", please change it, out of range values can't be saved anymore")
+		}
+	}
+}
+
+// validateArgDefinition checks the type specific fields of an arg, independent of its value
+func validateArgDefinition(arg GameArg) []string {
+	var issues []string
+	if arg.Type == ArgTypeEnum && len(arg.Options) == 0 {
+		issues = append(issues, fmt.Sprintf("enum argument %s has no options", arg.Flag))
+	}
+	if arg.Type != ArgTypeEnum && len(arg.Options) > 0 {
+		issues = append(issues, fmt.Sprintf("options are only supported for enum arguments, %s is %q", arg.Flag, arg.Type))
+	}
+	if arg.Max != 0 && arg.Min > arg.Max {
+		issues = append(issues, fmt.Sprintf("min %s is greater than max %s for %s", formatBound(arg.Min), formatBound(arg.Max), arg.Flag))
+	}
+	return issues
+}
+
+// ResolveArgPath resolves a path arg value, relative paths are relative to the gameserver directory
+func ResolveArgPath(value string) string {
+	if value == "" || filepath.IsAbs(value) {
+		return value
+	}
+	return filepath.Join(config.GetRunfileIdentifier(), value)
+}
+
+// checkPathExists returns an error message if a path args value does not exist on disk
+func checkPathExists(arg GameArg, value string) string {
+	if arg.Type != ArgTypePath || value == "" {
+		return ""
+	}
+	if _, err := os.Stat(ResolveArgPath(value)); err != nil {
+		return fmt.Sprintf("path for %s does not exist: %s", arg.Flag, ResolveArgPath(value))
+	}
+	return ""
+}
+
+// portConflicts returns an issue for every port used by more than one enabled port arg or by SSUI itself
+func portConflicts(args []GameArg) []string {
+	var issues []string
+	used := make(map[string]string) // port -> flag
+	for _, arg := range args {
+		if arg.Type != ArgTypePort || arg.Disabled || arg.RuntimeValue == "" {
+			continue
+		}
+		if other, ok := used[arg.RuntimeValue]; ok {
+			issues = append(issues, fmt.Sprintf("port %s is used by both %s and %s", arg.RuntimeValue, other, arg.Flag))
+			continue
+		}
+		used[arg.RuntimeValue] = arg.Flag
+		if arg.RuntimeValue == config.GetBackendEndpointPort() {
+			issues = append(issues, fmt.Sprintf("port %s for %s conflicts with the SSUI web interface port", arg.RuntimeValue, arg.Flag))
+		}
+	}
+	return issues
+}
+
+// GetPorts returns the ports configured through port args for the current OS, keyed by flag
+func GetPorts() map[string]int {
+	ports := make(map[string]int)
+	if CurrentRunfile == nil {
+		return ports
+	}
+	for _, arg := range CurrentRunfile.getAllArgs() {
+		if arg.Type != ArgTypePort || arg.Disabled {
+			continue
+		}
+		if port, err := strconv.Atoi(arg.RuntimeValue); err == nil {
+			ports[arg.Flag] = port
+		}
+	}
+	return ports
+}
@@ -0,0 +1,155 @@
+// configfiles.go
+package runfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/steamserverui/configfile"
+)
+
+/*
+Key-Level File Access
+- Reads and sets single values of the files a runfile declares under "files", using the parser for the file's type
+- Writes are validated before they reach the disk and replace the file atomically, a broken file is never written
+- Every write is kept as a revision, see revisions.go
+- Used by the file API and anything else (like the Discord bot) that changes a single setting
+*/
+
+// findFile returns the declared file with the given name, files in the SSUI directory are off limits
+func findFile(filename string) (File, error) {
+	for _, file := range GetFiles() {
+		if file.Filename != filename {
+			continue
+		}
+		if strings.HasPrefix(file.Filepath, "./SSUI") {
+			return File{}, fmt.Errorf("access to a file %s in the SSUI subdirectory is forbidden", filename)
+		}
+		return file, nil
+	}
+	return File{}, fmt.Errorf("file %s not found in runfile", filename)
+}
+
+// GetFileEntries lists every value of a declared file by key path
+func GetFileEntries(filename string) ([]configfile.Entry, error) {
+	file, err := findFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := CaptureFileChanges(filename); err != nil {
+		logger.Runfile.Warn(err.Error())
+	}
+	content, err := os.ReadFile(file.Filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+	return configfile.Entries(file.Type, content)
+}
+
+// GetFileValue returns a single value of a declared file
+func GetFileValue(filename, key string) (configfile.Entry, error) {
+	file, err := findFile(filename)
+	if err != nil {
+		return configfile.Entry{}, err
+	}
+	if err := CaptureFileChanges(filename); err != nil {
+		logger.Runfile.Warn(err.Error())
+	}
+	content, err := os.ReadFile(file.Filepath)
+	if err != nil {
+		return configfile.Entry{}, fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+	return configfile.Get(file.Type, content, key)
+}
+
+// SetFileValue changes a single existing value of a declared file on behalf of user and returns the value it replaced
+func SetFileValue(filename, key, value, user string) (configfile.Entry, error) {
+	file, err := findFile(filename)
+	if err != nil {
+		return configfile.Entry{}, err
+	}
+	previous, err := setFileValueLocked(file, key, value, user)
+	if err != nil {
+		return configfile.Entry{}, err
+	}
+	if target := templateTargetOf(file.Filepath); target != "" {
+		logger.Runfile.Warn(fmt.Sprintf("%s is rendered from the runfile template for %s, the change to %s is overwritten on the next server start", filename, target, key))
+	}
+	logger.Runfile.Info(fmt.Sprintf("Set %s in %s", key, filename))
+	return previous, nil
+}
+
+// setFileValueLocked reads, changes and records the file under revisionsMutex, so a concurrent capture can't take the change for an outside one
+func setFileValueLocked(file File, key, value, user string) (configfile.Entry, error) {
+	revisionsMutex.Lock()
+	defer revisionsMutex.Unlock()
+	if err := captureFileLocked(file); err != nil {
+		logger.Runfile.Warn(err.Error())
+	}
+	content, err := os.ReadFile(file.Filepath)
+	if err != nil {
+		return configfile.Entry{}, fmt.Errorf("failed to read file %s: %w", file.Filename, err)
+	}
+	previous, err := configfile.Get(file.Type, content, key)
+	if err != nil {
+		return configfile.Entry{}, err
+	}
+	updated, err := configfile.Set(file.Type, content, key, value)
+	if err != nil {
+		return configfile.Entry{}, err
+	}
+	if err := writeFileAtomic(file.Filepath, updated); err != nil {
+		return configfile.Entry{}, err
+	}
+	if _, err := addRevision(file.Filename, updated, user, RevisionSourceKey, "set "+key); err != nil {
+		logger.Runfile.Warn(fmt.Sprintf("failed to record revision of %s: %v", file.Filename, err))
+	}
+	return previous, nil
+}
+
+// ValidateFileContent checks content against the syntax of a declared file's type before it is saved
+func ValidateFileContent(filename string, content []byte) error {
+	file, err := findFile(filename)
+	if err != nil {
+		return err
+	}
+	if err := configfile.Validate(file.Type, content); err != nil {
+		return fmt.Errorf("content is not valid %s: %w", file.Type, err)
+	}
+	return nil
+}
+
+// templateTargetOf returns the template target a file path is rendered to, or "" if it isn't a template target
+func templateTargetOf(path string) string {
+	runfileMutex.Lock()
+	defer runfileMutex.Unlock()
+	if CurrentRunfile == nil {
+		return ""
+	}
+	for _, t := range CurrentRunfile.Templates {
+		if samePath(path, filepath.Join(config.GetRunfileIdentifier(), t.Target)) {
+			return t.Target
+		}
+	}
+	return ""
+}
+
+func writeFileAtomic(path string, content []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+// dependencies.go
+package runfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Conditional Arguments
+- depends_on: flags that must be active and set for this arg to apply, e.g. a password that needs -private
+- conflicts_with: flags that must not be active at the same time as this arg
+- enabled_when: a small expression over other args, e.g. "-mode == pvp && !-lan" or "-visibility != public || -whitelist"
+  terms: "<flag>" (set and not false), "!<flag>", "<flag> == <value>", "<flag> != <value>", joined by && (binds stronger) and ||
+- Inactive args are left out by BuildCommandArgs and hidden by the UI, Validate reports broken references, cycles and conflicts
+*/
+
+// condition is a single term of an enabled_when expression
+type condition struct {
+	Flag   string
+	Op     string // "set", "unset", "==" or "!="
+	Value  string
+	Source string
+}
+
+// parseEnabledWhen parses an enabled_when expression into OR groups of AND conditions
+func parseEnabledWhen(expr string) ([][]condition, error) {
+	var groups [][]condition
+	for _, orPart := range strings.Split(expr, "||") {
+		var group []condition
+		for _, term := range strings.Split(orPart, "&&") {
+			term = strings.TrimSpace(term)
+			if term == "" {
+				return nil, fmt.Errorf("empty term in expression %q", expr)
+			}
+			cond := condition{Source: term}
+			switch op, at := firstComparison(term); {
+			case at >= 0:
+				// split at the first operator only, the value may contain = and ! itself
+				flag, value := term[:at], term[at+len(op):]
+				cond.Flag, cond.Op, cond.Value = strings.TrimSpace(flag), op, unquote(strings.TrimSpace(value))
+			case strings.HasPrefix(term, "!"):
+				cond.Flag, cond.Op = strings.TrimSpace(strings.TrimPrefix(term, "!")), "unset"
+			default:
+				cond.Flag, cond.Op = term, "set"
+			}
+			if cond.Flag == "" || strings.ContainsAny(cond.Flag, " \t=!") {
+				return nil, fmt.Errorf("invalid term %q in expression %q", term, expr)
+			}
+			group = append(group, cond)
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// firstComparison returns the first == or != operator in a term and its index, or -1 if there is none
+func firstComparison(term string) (string, int) {
+	equal, notEqual := strings.Index(term, "=="), strings.Index(term, "!=")
+	switch {
+	case equal < 0 && notEqual < 0:
+		return "", -1
+	case notEqual < 0 || (equal >= 0 && equal < notEqual):
+		return "==", equal
+	default:
+		return "!=", notEqual
+	}
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// referencedFlags returns all flags an arg depends on in any way
+func (arg GameArg) referencedFlags() []string {
+	flags := append([]string{}, arg.DependsOn...)
+	flags = append(flags, arg.ConflictsWith...)
+	if arg.EnabledWhen != "" {
+		if groups, err := parseEnabledWhen(arg.EnabledWhen); err == nil {
+			for _, group := range groups {
+				for _, cond := range group {
+					flags = append(flags, cond.Flag)
+				}
+			}
+		}
+	}
+	return flags
+}
+
+// argResolver decides which args are active, memoizing results and detecting cycles
+type argResolver struct {
+	args     map[string]GameArg
+	active   map[string]bool
+	visiting map[string]bool
+	issues   map[string]string // flag -> why it is inactive or broken
+	cycles   []string
+}
+
+func newArgResolver(args []GameArg) *argResolver {
+	r := &argResolver{
+		args:     make(map[string]GameArg),
+		active:   make(map[string]bool),
+		visiting: make(map[string]bool),
+		issues:   make(map[string]string),
+	}
+	for _, arg := range args {
+		if arg.Flag != "" {
+			r.args[arg.Flag] = arg
+		}
+	}
+	return r
+}
+
+// isSet reports whether an arg is active and carries a truthy value, this is what depends_on and "<flag>" terms check
+func (r *argResolver) isSet(flag string) bool {
+	if !r.isActive(flag) {
+		return false
+	}
+	arg := r.args[flag]
+	if !arg.RequiresValue {
+		return true
+	}
+	return arg.RuntimeValue != "" && arg.RuntimeValue != "false"
+}
+
+// isActive reports whether an arg is enabled and all of its conditions are met
+func (r *argResolver) isActive(flag string) bool {
+	if active, ok := r.active[flag]; ok {
+		return active
+	}
+	arg, ok := r.args[flag]
+	if !ok || arg.Disabled {
+		return false
+	}
+	if r.visiting[flag] {
+		r.cycles = append(r.cycles, flag)
+		return false
+	}
+	r.visiting[flag] = true
+	defer delete(r.visiting, flag)
+
+	active := true
+	for _, dependency := range arg.DependsOn {
+		if !r.isSet(dependency) {
+			r.issues[flag] = fmt.Sprintf("%s depends on %s, which is not enabled", flag, dependency)
+			active = false
+			break
+		}
+	}
+	if active && arg.EnabledWhen != "" {
+		enabled, err := r.evaluate(arg.EnabledWhen)
+		if err != nil {
+			r.issues[flag] = fmt.Sprintf("invalid enabled_when for %s: %v", flag, err)
+		} else if !enabled {
+			r.issues[flag] = fmt.Sprintf("%s is only enabled when %s", flag, arg.EnabledWhen)
+		}
+		active = err == nil && enabled
+	}
+	r.active[flag] = active
+	return active
+}
+
+func (r *argResolver) evaluate(expr string) (bool, error) {
+	groups, err := parseEnabledWhen(expr)
+	if err != nil {
+		return false, err
+	}
+	for _, group := range groups {
+		matched := true
+		for _, cond := range group {
+			if !r.check(cond) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *argResolver) check(cond condition) bool {
+	value := ""
+	if r.isActive(cond.Flag) {
+		value = r.args[cond.Flag].RuntimeValue
+	}
+	switch cond.Op {
+	case "set":
+		return r.isSet(cond.Flag)
+	case "unset":
+		return !r.isSet(cond.Flag)
+	case "==":
+		return value == cond.Value
+	default: // "!="
+		return value != cond.Value
+	}
+}
+
+// ArgState describes whether an arg is currently active and why not, for the UI
+type ArgState struct {
+	Active bool   `json:"active"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// GetArgStates returns the state of every arg for the current OS, keyed by flag
+func GetArgStates() map[string]ArgState {
+	states := make(map[string]ArgState)
+	if CurrentRunfile == nil {
+		return states
+	}
+	args, _ := resolveArgs(CurrentRunfile.getAllArgs())
+	r := newArgResolver(args)
+	for flag := range r.args {
+		states[flag] = ArgState{Active: r.isActive(flag), Reason: r.issues[flag]}
+	}
+	return states
+}
+
+// dependencyIssues validates references, cycles, required args that can't be active and conflicts
+func dependencyIssues(args []GameArg) []string {
+	var issues []string
+	r := newArgResolver(args)
+
+	for _, arg := range args {
+		if arg.EnabledWhen != "" {
+			if _, err := parseEnabledWhen(arg.EnabledWhen); err != nil {
+				issues = append(issues, fmt.Sprintf("invalid enabled_when for %s: %v", arg.Flag, err))
+			}
+		}
+		for _, flag := range arg.referencedFlags() {
+			if _, ok := r.args[flag]; !ok {
+				issues = append(issues, fmt.Sprintf("%s references unknown argument %s", arg.Flag, flag))
+			}
+		}
+	}
+
+	for _, arg := range args {
+		if arg.Flag == "" || arg.Disabled {
+			continue
+		}
+		// inactive args are left out of the command, required ones included, see Validate
+		if !r.isActive(arg.Flag) {
+			continue
+		}
+		for _, other := range arg.ConflictsWith {
+			// report each pair once
+			if r.isSet(other) && r.isSet(arg.Flag) && (arg.Flag < other || !conflictsBothWays(r.args[other], arg.Flag)) {
+				issues = append(issues, fmt.Sprintf("%s conflicts with %s, only one of them can be enabled", arg.Flag, other))
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, flag := range r.cycles {
+		if !seen[flag] {
+			issues = append(issues, fmt.Sprintf("dependency cycle involving %s", flag))
+			seen[flag] = true
+		}
+	}
+	return issues
+}
+
+func conflictsBothWays(arg GameArg, flag string) bool {
+	for _, other := range arg.ConflictsWith {
+		if other == flag {
+			return true
+		}
+	}
+	return false
+}
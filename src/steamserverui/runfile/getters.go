@@ -17,13 +17,59 @@ func GetAllArgs() []GameArg {
 	return CurrentRunfile.getAllArgs()
 }
 
-// GetFiles returns all Files from the runfile
+// GetFiles returns all Files from the runfile with their paths resolved
 func GetFiles() []File {
 	if CurrentRunfile == nil {
 		logger.Runfile.Error("runfile not loaded")
 		return nil
 	}
-	return CurrentRunfile.Files
+	if CurrentRunfile.Files == nil {
+		return nil
+	}
+	files, issues := resolveFiles(CurrentRunfile)
+	for _, issue := range issues {
+		logger.Runfile.Warn(issue)
+	}
+	return files
+}
+
+// GetAccessListFiles returns the access list file declarations from the runfile, or nil if the runfile declares none
+func GetAccessListFiles() *AccessListFiles {
+	if CurrentRunfile == nil {
+		return nil
+	}
+	return CurrentRunfile.AccessLists
+}
+
+// GetWorkshopConfig returns the workshop declaration from the runfile, or nil if the game has no workshop support
+func GetWorkshopConfig() *WorkshopConfig {
+	if CurrentRunfile == nil {
+		return nil
+	}
+	return CurrentRunfile.Workshop
+}
+
+// defaultLogRunStartMarker is the engine banner Unity gameservers write first on every start
+const defaultLogRunStartMarker = "Initialize engine version"
+
+// GetLogRunStartMarker returns the text that marks the start of a new run in the gameserver log
+func GetLogRunStartMarker() string {
+	runfileMutex.Lock()
+	defer runfileMutex.Unlock()
+	if CurrentRunfile == nil || CurrentRunfile.LogRunStartMarker == "" {
+		return defaultLogRunStartMarker
+	}
+	return CurrentRunfile.LogRunStartMarker
+}
+
+// GetCurrentMeta returns a meta field of the loaded runfile, see GetMeta
+func GetCurrentMeta(field string) (string, error) {
+	runfileMutex.Lock()
+	defer runfileMutex.Unlock()
+	if CurrentRunfile == nil {
+		return "", fmt.Errorf("no runfile is loaded")
+	}
+	return CurrentRunfile.GetMeta(field)
 }
 
 // GetUIGroups returns all unique UIGroup values from the runfile
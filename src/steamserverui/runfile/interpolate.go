@@ -0,0 +1,290 @@
+// interpolate.go
+package runfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+)
+
+/*
+Variable Interpolation
+- Arg values and file paths may contain placeholders, so one runfile works across hosts and containers
+  ${env:SSUI_VAR_NAME}   environment variable of the SSUI process, only names starting with SSUI_VAR_ so
+                         the runfile can't read SSUI's own secrets (Steam password, Discord token, JWT key)
+  ${arg:-flag}           the (resolved) value of another arg for the current OS
+  ${ssui:instance_name}  the SSUI backend name, or the hostname if none is set
+  ${ssui:game_dir}       absolute path of the gameserver directory
+  ${date:2006-01-02}     the current time, formatted with a Go time layout
+- Any placeholder takes a default after a pipe, e.g. ${env:SSUI_VAR_PORT|27016}, used when the value is unset or empty
+- $${ is written as a literal ${
+- The runfile keeps the placeholders, only the values handed to the server and the file manager are resolved
+- Values taken from the environment or from secret args are never shown resolved by the API, see ArgUsesEnv and ArgUsesSecret
+*/
+
+// envPlaceholderPrefix is the prefix environment variables need to be used in a runfile
+const envPlaceholderPrefix = "SSUI_VAR_"
+
+type placeholder struct {
+	Kind       string
+	Name       string
+	Default    string
+	HasDefault bool
+}
+
+// HasPlaceholders reports whether value needs to be resolved before use
+func HasPlaceholders(value string) bool {
+	return strings.Contains(value, "${")
+}
+
+func parsePlaceholder(expr string) (placeholder, error) {
+	kind, name, ok := strings.Cut(expr, ":")
+	if !ok || name == "" {
+		return placeholder{}, fmt.Errorf("invalid placeholder ${%s}, expected ${kind:name}", expr)
+	}
+	p := placeholder{Kind: kind}
+	p.Name, p.Default, p.HasDefault = strings.Cut(name, "|")
+	if p.Name == "" {
+		return placeholder{}, fmt.Errorf("invalid placeholder ${%s}, name is empty", expr)
+	}
+	switch kind {
+	case "env":
+		if !strings.HasPrefix(p.Name, envPlaceholderPrefix) {
+			return placeholder{}, fmt.Errorf("environment variable %s can't be used in ${%s}, only names starting with %s are allowed", p.Name, expr, envPlaceholderPrefix)
+		}
+	case "arg", "date":
+	case "ssui":
+		if p.Name != "instance_name" && p.Name != "game_dir" {
+			return placeholder{}, fmt.Errorf("unknown ssui variable %q in ${%s}, known are instance_name and game_dir", p.Name, expr)
+		}
+	default:
+		return placeholder{}, fmt.Errorf("unknown placeholder kind %q in ${%s}, known are env, arg, ssui and date", kind, expr)
+	}
+	return p, nil
+}
+
+// walkPlaceholders replaces every placeholder in value with the result of resolve
+func walkPlaceholders(value string, resolve func(p placeholder) (string, error)) (string, error) {
+	if !HasPlaceholders(value) {
+		return value, nil
+	}
+	var b strings.Builder
+	rest := value
+	for {
+		start := strings.Index(rest, "${")
+		if start < 0 {
+			b.WriteString(rest)
+			return b.String(), nil
+		}
+		if start > 0 && rest[start-1] == '$' {
+			b.WriteString(rest[:start-1] + "${")
+			rest = rest[start+2:]
+			continue
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated placeholder in %q", value)
+		}
+		p, err := parsePlaceholder(rest[start+2 : start+end])
+		if err != nil {
+			return "", err
+		}
+		resolved, err := resolve(p)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(rest[:start] + resolved)
+		rest = rest[start+end+1:]
+	}
+}
+
+// checkPlaceholderSyntax reports malformed placeholders without resolving anything, for the linter
+func checkPlaceholderSyntax(value string) error {
+	_, err := walkPlaceholders(value, func(placeholder) (string, error) { return "", nil })
+	return err
+}
+
+// interpolator resolves placeholders against a set of args, memoizing arg values and detecting reference cycles
+type interpolator struct {
+	args       map[string]GameArg
+	resolved   map[string]string
+	resolving  map[string]bool
+	now        time.Time
+	envUsed    bool // an environment variable was resolved
+	secretUsed bool // the value of a secret arg was read
+}
+
+func newInterpolator(args []GameArg) *interpolator {
+	ip := &interpolator{
+		args:      make(map[string]GameArg),
+		resolved:  make(map[string]string),
+		resolving: make(map[string]bool),
+		now:       time.Now(),
+	}
+	for _, arg := range args {
+		if arg.Flag != "" {
+			ip.args[arg.Flag] = arg
+		}
+	}
+	return ip
+}
+
+func (ip *interpolator) expand(value string) (string, error) {
+	return walkPlaceholders(value, ip.lookup)
+}
+
+func (ip *interpolator) lookup(p placeholder) (string, error) {
+	var value string
+	switch p.Kind {
+	case "env":
+		var set bool
+		value, set = os.LookupEnv(p.Name)
+		ip.envUsed = true
+		if !set && !p.HasDefault {
+			return "", fmt.Errorf("environment variable %s is not set", p.Name)
+		}
+	case "arg":
+		var found bool
+		var err error
+		value, found, err = ip.argValue(p.Name)
+		if err != nil {
+			return "", err
+		}
+		if !found && !p.HasDefault {
+			return "", fmt.Errorf("referenced argument %s does not exist", p.Name)
+		}
+	case "ssui":
+		var err error
+		value, err = ssuiVariable(p.Name)
+		if err != nil && !p.HasDefault {
+			return "", err
+		}
+	case "date":
+		value = ip.now.Format(p.Name)
+	}
+	if value == "" && p.HasDefault {
+		return p.Default, nil
+	}
+	return value, nil
+}
+
+func (ip *interpolator) argValue(flag string) (string, bool, error) {
+	arg, ok := ip.args[flag]
+	if ok && arg.IsSecret() {
+		ip.secretUsed = true
+	}
+	if value, cached := ip.resolved[flag]; cached {
+		return value, true, nil
+	}
+	if !ok {
+		return "", false, nil
+	}
+	if ip.resolving[flag] {
+		return "", true, fmt.Errorf("reference cycle involving %s", flag)
+	}
+	ip.resolving[flag] = true
+	defer delete(ip.resolving, flag)
+
+	value, err := ip.expand(arg.RuntimeValue)
+	if err != nil {
+		return "", true, err
+	}
+	ip.resolved[flag] = value
+	return value, true, nil
+}
+
+func ssuiVariable(name string) (string, error) {
+	switch name {
+	case "instance_name":
+		if backendName := config.GetBackendName(); backendName != "" {
+			return backendName, nil
+		}
+		return os.Hostname()
+	case "game_dir":
+		identifier := config.GetRunfileIdentifier()
+		if identifier == "" {
+			return "", fmt.Errorf("game_dir is unknown, no runfile identifier is set")
+		}
+		return filepath.Abs(identifier)
+	}
+	return "", fmt.Errorf("unknown ssui variable %s", name)
+}
+
+// resolveArgs returns copies of args with all placeholders in their runtime values resolved.
+// Args that fail to resolve keep their raw value and are reported, disabled args are resolved but not reported.
+func resolveArgs(args []GameArg) ([]GameArg, []string) {
+	ip := newInterpolator(args)
+	resolved := make([]GameArg, len(args))
+	var issues []string
+	for i, arg := range args {
+		resolved[i] = arg
+		if !HasPlaceholders(arg.RuntimeValue) {
+			continue
+		}
+		value, err := ip.expand(arg.RuntimeValue)
+		if err != nil {
+			if !arg.Disabled {
+				issues = append(issues, fmt.Sprintf("failed to resolve value of %s: %v", arg.Flag, err))
+			}
+			continue
+		}
+		resolved[i].RuntimeValue = value
+	}
+	return resolved, issues
+}
+
+// resolveFiles returns copies of files with their paths resolved, files that fail to resolve keep their raw path
+func resolveFiles(rf *RunFile) ([]File, []string) {
+	ip := newInterpolator(rf.getAllArgs())
+	files := make([]File, len(rf.Files))
+	var issues []string
+	for i, file := range rf.Files {
+		files[i] = file
+		path, err := ip.expand(file.Filepath)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("failed to resolve path of %s: %v", file.Filename, err))
+			continue
+		}
+		files[i].Filepath = path
+	}
+	return files, issues
+}
+
+// ResolveArgValue returns the value of an arg for the current OS with all placeholders resolved
+func ResolveArgValue(flag string) (string, error) {
+	if CurrentRunfile == nil {
+		return "", ErrRunfileNotLoaded{Msg: "runfile not loaded"}
+	}
+	value, found, err := newInterpolator(CurrentRunfile.getAllArgs()).argValue(flag)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", ErrArgNotFound{Flag: flag}
+	}
+	return value, nil
+}
+
+// ArgUsesEnv reports whether the value of an arg comes from the environment, directly or through other args
+func ArgUsesEnv(flag string) bool {
+	if CurrentRunfile == nil {
+		return false
+	}
+	ip := newInterpolator(CurrentRunfile.getAllArgs())
+	ip.argValue(flag)
+	return ip.envUsed
+}
+
+// ArgUsesSecret reports whether the value of an arg is or includes the value of a secret arg, directly or through other args
+func ArgUsesSecret(flag string) bool {
+	if CurrentRunfile == nil {
+		return false
+	}
+	ip := newInterpolator(CurrentRunfile.getAllArgs())
+	ip.argValue(flag)
+	return ip.secretUsed
+}
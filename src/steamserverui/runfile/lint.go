@@ -0,0 +1,431 @@
+// lint.go
+package runfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+)
+
+/*
+Offline Runfile Linter
+- Used by runfile authors via --lint-runfile before publishing to the gallery
+- Unlike Validate, it checks the arg variants for every OS, not just the one SSUI runs on
+- Reports errors (the runfile won't load or will build a broken command line) and
+  warnings (probably a mistake, like a ui_group that differs from another one only by case)
+- Doesn't need a loaded config or runfile and never writes anything
+*/
+
+var lintOSes = []string{"linux", "windows"}
+
+// LintIssue is a single finding of the linter
+type LintIssue struct {
+	Severity string `json:"severity"` // "error" or "warning"
+	Message  string `json:"message"`
+}
+
+func (i LintIssue) String() string {
+	return fmt.Sprintf("[%s] %s", strings.ToUpper(i.Severity), i.Message)
+}
+
+type linter struct {
+	issues []LintIssue
+}
+
+func (l *linter) errorf(format string, args ...any) {
+	l.issues = append(l.issues, LintIssue{Severity: "error", Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) warnf(format string, args ...any) {
+	l.issues = append(l.issues, LintIssue{Severity: "warning", Message: fmt.Sprintf(format, args...)})
+}
+
+// HasLintErrors reports whether any issue is an error
+func HasLintErrors(issues []LintIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == "error" {
+			return true
+		}
+	}
+	return false
+}
+
+// LintRunfile checks the runfile at path and returns all findings
+func LintRunfile(path string) []LintIssue {
+	l := &linter{}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		l.errorf("failed to read runfile: %v", err)
+		return l.issues
+	}
+
+	// Migrate in memory only, so older runfiles are linted the way LoadRunfile would see them
+	migrated, version, changed, err := migrateRunfileData(data)
+	if err != nil {
+		l.errorf("%v", err)
+		return l.issues
+	}
+	if changed {
+		l.warnf("runfile uses schema version %d and will be migrated to %d on load, consider publishing it as %d", version, CurrentSchemaVersion, CurrentSchemaVersion)
+	}
+
+	// Unknown fields are ignored by LoadRunfile, which usually means a typo
+	decoder := json.NewDecoder(bytes.NewReader(migrated))
+	decoder.DisallowUnknownFields()
+	var strict RunFile
+	if err := decoder.Decode(&strict); err != nil && !strings.Contains(err.Error(), `"$schema"`) {
+		l.warnf("%v", err)
+	}
+
+	var rf RunFile
+	if err := json.Unmarshal(migrated, &rf); err != nil {
+		l.errorf("failed to parse runfile: %v", err)
+		return l.issues
+	}
+
+	l.lintMeta(&rf, path)
+	l.lintArgs(&rf)
+	l.lintUIGroups(&rf)
+	l.lintFiles(&rf)
+	l.lintTemplates(&rf, filepath.Dir(path))
+	return l.issues
+}
+
+func (l *linter) lintMeta(rf *RunFile, path string) {
+	if rf.Meta.Name == "" {
+		l.errorf("meta.name is required")
+	} else if !regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*$`).MatchString(rf.Meta.Name) {
+		l.errorf("meta.name %q must start with an uppercase letter and be alphanumeric", rf.Meta.Name)
+	} else if expected := fmt.Sprintf("run%s.ssui", rf.Meta.Name); filepath.Base(path) != expected {
+		l.warnf("file name %s does not match meta.name, expected %s", filepath.Base(path), expected)
+	}
+	if rf.SteamAppID == "" {
+		l.errorf("steam_app_id is required")
+	} else if _, err := strconv.Atoi(rf.SteamAppID); err != nil {
+		l.errorf("steam_app_id must be numeric, got %s", rf.SteamAppID)
+	}
+	if rf.WindowsExecutable == "" && rf.LinuxExecutable == "" {
+		l.errorf("at least one of windows_executable and linux_executable is required")
+	}
+	if rf.WindowsExecutable != "" && !strings.HasSuffix(strings.ToLower(rf.WindowsExecutable), ".exe") {
+		l.errorf("windows_executable must end with .exe, got %s", rf.WindowsExecutable)
+	}
+	if rf.LinuxExecutable != "" && strings.HasSuffix(strings.ToLower(rf.LinuxExecutable), ".exe") {
+		l.errorf("linux_executable must not end with .exe, got %s", rf.LinuxExecutable)
+	}
+}
+
+// lintArgs validates every arg once and checks duplicate flags per OS variant
+func (l *linter) lintArgs(rf *RunFile) {
+	if len(rf.Args) == 0 {
+		l.warnf("runfile defines no args")
+	}
+
+	categories := make([]string, 0, len(rf.Args))
+	for category := range rf.Args {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	for _, goos := range lintOSes {
+		seen := make(map[string]string) // flag -> category of first occurrence
+		for _, category := range categories {
+			for _, arg := range rf.Args[category] {
+				if arg.Flag == "" || (arg.Os != "" && arg.Os != goos) {
+					continue
+				}
+				if first, ok := seen[arg.Flag]; ok {
+					l.errorf("duplicate flag %s on %s (in %s and %s)", arg.Flag, goos, first, category)
+					continue
+				}
+				seen[arg.Flag] = category
+			}
+		}
+	}
+
+	for _, category := range categories {
+		for _, arg := range rf.Args[category] {
+			l.lintArg(category, arg)
+		}
+	}
+
+	// depends_on, conflicts_with and enabled_when with the default values, per OS since references may only exist on one
+	reported := make(map[string]bool)
+	for _, goos := range lintOSes {
+		var osArgs []GameArg
+		for _, category := range categories {
+			for _, arg := range rf.Args[category] {
+				if arg.Os == "" || arg.Os == goos {
+					arg.RuntimeValue = arg.Value
+					osArgs = append(osArgs, arg)
+				}
+			}
+		}
+		for _, issue := range append(dependencyIssues(osArgs), argReferenceIssues(osArgs)...) {
+			if !reported[issue] {
+				l.errorf("%s (on %s)", issue, goos)
+				reported[issue] = true
+			}
+		}
+	}
+}
+
+func (l *linter) lintArg(category string, arg GameArg) {
+	name := arg.Flag
+	if name == "" {
+		name = arg.UILabel
+	}
+	where := fmt.Sprintf("%s/%s", category, name)
+
+	if arg.Flag == "" && arg.Special != "space_delimited" && arg.Special != "dont_append_flag_just_value" {
+		l.errorf("%s has no flag", where)
+	}
+	if arg.Os != "" && !slices.Contains(lintOSes, arg.Os) {
+		l.errorf("%s has invalid os %q, must be linux or windows", where, arg.Os)
+	}
+	if !slices.Contains(knownSpecialValues, arg.Special) {
+		l.errorf("%s has unknown special value %q, known values are %s", where, arg.Special, strings.Join(knownSpecialValues[1:], ", "))
+	}
+	if !slices.Contains(knownArgTypes, arg.Type) {
+		l.warnf("%s has unknown type %q, it will be treated as a string", where, arg.Type)
+	}
+	if arg.Required && arg.RequiresValue && arg.Value == "" && !arg.Disabled {
+		l.warnf("%s is required but has no default value, SSUI won't start the server until it is set", where)
+	}
+	if !arg.RequiresValue && arg.Value != "" {
+		l.warnf("%s has a value but requires_value is false, the value is never passed to the server", where)
+	}
+	if arg.UILabel == "" && arg.Special != "hide_in_ui" {
+		l.warnf("%s has no ui_label", where)
+	}
+
+	// min/max only apply to numbers
+	if arg.hasBounds() && arg.Type != ArgTypeInt && arg.Type != ArgTypeFloat && arg.Type != ArgTypePort {
+		l.warnf("%s sets min/max but is of type %q, they are only checked for int, float and port", where, arg.Type)
+	}
+	for _, issue := range validateArgDefinition(arg) {
+		l.errorf("%s: %s", where, issue)
+	}
+	if HasPlaceholders(arg.Value) {
+		// the value depends on the host, only the syntax can be checked here
+		if err := checkPlaceholderSyntax(arg.Value); err != nil {
+			l.errorf("%s: default %v", where, err)
+		}
+	} else if issue := validateArgValue(arg, arg.Value); issue != "" {
+		l.errorf("%s: default %s", where, issue)
+	} else if issue := checkArgBounds(arg, arg.Value); issue != "" {
+		l.warnf("%s: default %s", where, issue)
+	}
+	if arg.Type == ArgTypeSecret && arg.Value != "" {
+		l.warnf("%s is a secret but ships with a default value", where)
+	}
+}
+
+// lintUIGroups warns about groups that look like typos of each other, e.g. "Network" and "network " or "Netwrok"
+func (l *linter) lintUIGroups(rf *RunFile) {
+	counts := make(map[string]int)
+	for _, args := range rf.Args {
+		for _, arg := range args {
+			if arg.UIGroup == "" {
+				if arg.Special != "hide_in_ui" {
+					l.warnf("%s has no ui_group", arg.Flag)
+				}
+				continue
+			}
+			counts[arg.UIGroup]++
+		}
+	}
+
+	groups := make([]string, 0, len(counts))
+	for group := range counts {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	for i, a := range groups {
+		if a != strings.TrimSpace(a) {
+			l.warnf("ui_group %q has leading or trailing whitespace", a)
+		}
+		for _, b := range groups[i+1:] {
+			if !similarGroupNames(a, b) {
+				continue
+			}
+			// the less used spelling is most likely the typo
+			typo, intended := a, b
+			if counts[a] > counts[b] {
+				typo, intended = b, a
+			}
+			l.warnf("ui_group %q (%d args) looks like a typo of %q (%d args)", typo, counts[typo], intended, counts[intended])
+		}
+	}
+}
+
+func normalizeGroupName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '_' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
+
+func similarGroupNames(a, b string) bool {
+	na, nb := normalizeGroupName(a), normalizeGroupName(b)
+	if na == nb {
+		return true
+	}
+	// only consider edit distance for names long enough to not be different words by accident
+	return len(na) >= 5 && len(nb) >= 5 && editDistance(na, nb) <= 1+boolToInt(len(na) >= 8)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// editDistance is the Damerau-Levenshtein (optimal string alignment) distance, so swapped letters count as one edit
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// lintFiles checks that managed files and access lists stay inside the gameserver directory
+func (l *linter) lintFiles(rf *RunFile) {
+	seen := make(map[string]bool)
+	for _, file := range rf.Files {
+		if file.Filename == "" {
+			l.errorf("file entry with path %q has no filename", file.Filepath)
+		}
+		// ${ssui:game_dir} is the gameserver directory itself, other placeholders can only be checked on the host
+		location := strings.ReplaceAll(file.Filepath, "${ssui:game_dir}", "./"+rf.Meta.Name)
+		if file.Filepath == "" {
+			l.errorf("file %s has no filepath", file.Filename)
+		} else if err := checkPlaceholderSyntax(file.Filepath); err != nil {
+			l.errorf("file %s path: %v", file.Filename, err)
+		} else if !HasPlaceholders(location) && !fileInGameDir(rf.Meta.Name, location) {
+			l.errorf("file %s path %q is outside of the gameserver directory ./%s", file.Filename, file.Filepath, rf.Meta.Name)
+		}
+		if file.Type == "" {
+			l.errorf("file %s has no type", file.Filename)
+		} else if !slices.Contains(schemaEnums["File.type"], file.Type) {
+			l.errorf("file %s has invalid type %q", file.Filename, file.Type)
+		}
+		if file.Description == "" {
+			l.errorf("file %s has no description", file.Filename)
+		}
+		if seen[file.Filepath] {
+			l.warnf("file path %q is listed more than once", file.Filepath)
+		}
+		seen[file.Filepath] = true
+	}
+
+	if rf.AccessLists != nil {
+		for name, list := range map[string]*AccessListFile{"bans": rf.AccessLists.Bans, "whitelist": rf.AccessLists.Whitelist, "admins": rf.AccessLists.Admins} {
+			if list == nil {
+				continue
+			}
+			if list.Filepath == "" {
+				l.errorf("access list %s has no filepath", name)
+			} else if escapesGameDir(list.Filepath) {
+				l.errorf("access list %s path %q escapes the gameserver directory", name, list.Filepath)
+			}
+			if !slices.Contains(schemaEnums["AccessListFile.format"], list.Format) {
+				l.errorf("access list %s has invalid format %q", name, list.Format)
+			}
+		}
+	}
+
+	if rf.Workshop != nil {
+		for _, issue := range workshopIssues(rf.Workshop) {
+			l.errorf("%s", issue)
+		}
+	}
+}
+
+// lintTemplates checks the template definitions, template_file paths are resolved next to the runfile
+func (l *linter) lintTemplates(rf *RunFile, templateDir string) {
+	targets := make(map[string]bool)
+	files, _ := resolveFiles(rf)
+	for _, t := range rf.Templates {
+		for _, issue := range templateIssues(t, templateDir) {
+			l.errorf("%s", issue)
+		}
+		if targets[t.Target] {
+			l.errorf("template target %s is used more than once", t.Target)
+		}
+		targets[t.Target] = true
+		for _, file := range files {
+			if samePath(file.Filepath, filepath.Join(config.GetRunfileIdentifier(), t.Target)) {
+				l.warnf("%s is both a template target and a managed file, edits in the file editor are overwritten on the next start", t.Target)
+			}
+		}
+	}
+}
+
+// argReferenceIssues reports ${arg:...} placeholders that point to args which don't exist and have no default
+func argReferenceIssues(args []GameArg) []string {
+	known := make(map[string]bool)
+	for _, arg := range args {
+		known[arg.Flag] = true
+	}
+	var issues []string
+	for _, arg := range args {
+		walkPlaceholders(arg.Value, func(p placeholder) (string, error) {
+			if p.Kind == "arg" && !p.HasDefault && !known[p.Name] {
+				issues = append(issues, fmt.Sprintf("%s references unknown argument %s in its value", arg.Flag, p.Name))
+			}
+			return "", nil
+		})
+	}
+	return issues
+}
+
+// fileInGameDir reports whether a Files path (relative to the SSUI directory) points into the gameserver directory
+func fileInGameDir(gameName, path string) bool {
+	if gameName == "" || escapesGameDir(path) {
+		return false
+	}
+	cleaned := filepath.ToSlash(filepath.Clean(strings.ReplaceAll(path, `\`, "/")))
+	return strings.HasPrefix(cleaned, gameName+"/")
+}
+
+// escapesGameDir reports whether a runfile path is absolute or points outside the gameserver directory
+func escapesGameDir(path string) bool {
+	if filepath.IsAbs(path) || strings.HasPrefix(path, "/") || strings.HasPrefix(path, `\`) || filepath.VolumeName(path) != "" {
+		return true
+	}
+	cleaned := filepath.Clean(strings.ReplaceAll(path, `\`, "/"))
+	return cleaned == ".." || strings.HasPrefix(cleaned, "../")
+}
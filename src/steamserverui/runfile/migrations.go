@@ -0,0 +1,321 @@
+// migrations.go
+package runfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+/*
+Runfile Schema Migrations
+- Every runfile carries a schema_version describing its format (files without one are version 1)
+- Migrations upgrade the raw JSON one version at a time, so they keep working when RunFile changes later
+- Numbers are kept as written (no float64 round trip) and rewritten files keep their key order
+- Runfiles the migrations don't change are left untouched, only their schema_version is upgraded in memory
+- LoadRunfile backs up the original file before writing the upgraded one in place
+- Runfiles written for a newer SSUI are rejected instead of silently dropping fields we don't know
+*/
+
+// CurrentSchemaVersion is the runfile format this SSUI build reads and writes
+const CurrentSchemaVersion = 2
+
+// ErrSchemaTooNew is returned for runfiles written for a newer SSUI
+type ErrSchemaTooNew struct {
+	Version   int
+	Supported int
+}
+
+func (e ErrSchemaTooNew) Error() string {
+	return fmt.Sprintf("runfile uses schema version %d, but this SSUI only supports up to version %d. Please update SSUI to use this runfile", e.Version, e.Supported)
+}
+
+// migration upgrades a raw runfile from schema version From to From+1
+type migration struct {
+	From        int
+	Description string
+	Apply       func(raw map[string]any) error
+}
+
+// migrations is the registry of all schema upgrades, keyed by the version they upgrade from
+var migrations = map[int]migration{}
+
+func registerMigration(m migration) {
+	if _, exists := migrations[m.From]; exists {
+		panic(fmt.Sprintf("runfile migration from schema version %d registered twice", m.From))
+	}
+	migrations[m.From] = m
+}
+
+func init() {
+	registerMigration(migration{
+		From:        1,
+		Description: "normalize arg os restrictions and type names",
+		Apply: func(raw map[string]any) error {
+			typeAliases := map[string]string{"integer": "int", "boolean": "bool", "str": "string"}
+			return forEachRawArg(raw, func(arg map[string]any) {
+				if osValue, ok := arg["os"].(string); ok {
+					arg["os"] = strings.ToLower(strings.TrimSpace(osValue))
+				}
+				if argType, ok := arg["type"].(string); ok {
+					if alias, found := typeAliases[strings.ToLower(argType)]; found {
+						arg["type"] = alias
+					}
+				}
+			})
+		},
+	})
+}
+
+// forEachRawArg calls fn for every arg object in a raw runfile
+func forEachRawArg(raw map[string]any, fn func(arg map[string]any)) error {
+	categories, ok := raw["args"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	for category, value := range categories {
+		args, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("args category %s is not a list", category)
+		}
+		for _, item := range args {
+			if arg, ok := item.(map[string]any); ok {
+				fn(arg)
+			}
+		}
+	}
+	return nil
+}
+
+// schemaVersionOf reads schema_version from a raw runfile, files without one are version 1
+func schemaVersionOf(raw map[string]any) (int, error) {
+	value, ok := raw["schema_version"]
+	if !ok {
+		return 1, nil
+	}
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("invalid schema_version %v", value)
+	}
+	version, err := strconv.Atoi(number.String())
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid schema_version %v", value)
+	}
+	return version, nil
+}
+
+// decodeRaw parses runfile JSON into generic values, keeping numbers as json.Number
+func decodeRaw(data []byte) (map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw map[string]any
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// migrateRunfileData upgrades raw runfile JSON to CurrentSchemaVersion.
+// Returns the (possibly unchanged) data, the version it started at and whether anything was migrated.
+func migrateRunfileData(data []byte) ([]byte, int, bool, error) {
+	raw, err := decodeRaw(data)
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("failed to parse runfile: %w", err)
+	}
+
+	version, err := schemaVersionOf(raw)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if version > CurrentSchemaVersion {
+		return nil, version, false, ErrSchemaTooNew{Version: version, Supported: CurrentSchemaVersion}
+	}
+	if version == CurrentSchemaVersion {
+		return data, version, false, nil
+	}
+
+	original, _ := decodeRaw(data)
+	for from := version; from < CurrentSchemaVersion; from++ {
+		step, ok := migrations[from]
+		if !ok {
+			return nil, version, false, fmt.Errorf("no migration registered from schema version %d", from)
+		}
+		if err := step.Apply(raw); err != nil {
+			return nil, version, false, fmt.Errorf("migration from schema version %d (%s) failed: %w", from, step.Description, err)
+		}
+		logger.Runfile.Debug(fmt.Sprintf("applied runfile migration: from=%d, to=%d, description=%s", from, from+1, step.Description))
+	}
+	if reflect.DeepEqual(original, raw) {
+		// nothing to upgrade in this runfile, keep the file as it was written
+		return data, version, false, nil
+	}
+	raw["schema_version"] = json.Number(strconv.Itoa(CurrentSchemaVersion))
+
+	migrated, err := marshalInOriginalOrder(raw, data)
+	if err != nil {
+		return nil, version, false, fmt.Errorf("failed to serialize migrated runfile: %w", err)
+	}
+	return migrated, version, true, nil
+}
+
+// keyOrder records the order object keys appear in a JSON document, so a rewrite keeps it
+type keyOrder struct {
+	keys     []string
+	children map[string]*keyOrder
+	items    []*keyOrder
+}
+
+// marshalInOriginalOrder encodes raw with the key order of the document it was decoded from, new keys go last
+func marshalInOriginalOrder(raw map[string]any, original []byte) ([]byte, error) {
+	order, err := readKeyOrder(json.NewDecoder(bytes.NewReader(original)))
+	if err != nil {
+		return nil, err
+	}
+	var compact bytes.Buffer
+	if err := encodeOrdered(&compact, raw, order); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func readKeyOrder(decoder *json.Decoder) (*keyOrder, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return nil, nil
+	}
+	order := &keyOrder{children: map[string]*keyOrder{}}
+	for decoder.More() {
+		if delim == '{' {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := keyToken.(string)
+			child, err := readKeyOrder(decoder)
+			if err != nil {
+				return nil, err
+			}
+			order.keys = append(order.keys, key)
+			order.children[key] = child
+			continue
+		}
+		child, err := readKeyOrder(decoder)
+		if err != nil {
+			return nil, err
+		}
+		order.items = append(order.items, child)
+	}
+	if _, err := decoder.Token(); err != nil { // closing delimiter
+		return nil, err
+	}
+	return order, nil
+}
+
+func encodeOrdered(buf *bytes.Buffer, value any, order *keyOrder) error {
+	switch v := value.(type) {
+	case map[string]any:
+		var keys []string
+		if order != nil {
+			for _, key := range order.keys {
+				if _, ok := v[key]; ok {
+					keys = append(keys, key)
+				}
+			}
+		}
+		var added []string
+		for key := range v {
+			if order == nil || !slices.Contains(order.keys, key) {
+				added = append(added, key)
+			}
+		}
+		sort.Strings(added)
+		keys = append(keys, added...)
+
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			encodedKey, _ := json.Marshal(key)
+			buf.Write(encodedKey)
+			buf.WriteByte(':')
+			var child *keyOrder
+			if order != nil {
+				child = order.children[key]
+			}
+			if err := encodeOrdered(buf, v[key], child); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []any:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			var child *keyOrder
+			if order != nil && i < len(order.items) {
+				child = order.items[i]
+			}
+			if err := encodeOrdered(buf, item, child); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+// upgradeRunfileOnDisk migrates the runfile at filePath if needed, keeping a backup of the original in the old folder
+func upgradeRunfileOnDisk(filePath string, data []byte) ([]byte, error) {
+	migrated, fromVersion, changed, err := migrateRunfileData(data)
+	if err != nil || !changed {
+		return migrated, err
+	}
+
+	oldDir := filepath.Join(filepath.Dir(filePath), "old")
+	if err := os.MkdirAll(oldDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create runfile backup directory: %w", err)
+	}
+	backupPath := filepath.Join(oldDir, fmt.Sprintf("%s-schema-v%d-%s.bak", filepath.Base(filePath), fromVersion, time.Now().Format("2006-01-02_15-04-05")))
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to back up runfile before migration: %w", err)
+	}
+
+	tmpPath := filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, migrated, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write migrated runfile: %w", err)
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to replace runfile with migrated version: %w", err)
+	}
+
+	logger.Runfile.Info(fmt.Sprintf("Runfile upgraded from schema version %d to %d, backup saved to %s", fromVersion, CurrentSchemaVersion, backupPath))
+	return migrated, nil
+}
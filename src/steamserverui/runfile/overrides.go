@@ -0,0 +1,561 @@
+// overrides.go
+package runfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+/*
+Local Overrides
+- run<Identifier>.ssui is the upstream runfile as published in the gallery, SSUI does not write user values into it anymore
+- run<Identifier>.overrides.json holds only the local changes: arg values, disabled flags and extra args
+  extra args are added by hand under "extra_args", keyed by category, and work like args of the upstream runfile
+- LoadRunfile merges both, SaveRunfile writes the difference to the upstream runfile back into the overrides file
+- Every override remembers the upstream value it replaced, so a gallery update can be merged three-way (see PlanRunfileUpdate)
+- Installs from before the split have their values in the upstream file, the first gallery update turns them into overrides
+*/
+
+const overridesSchemaVersion = 1
+
+// ArgOverride is a local change to an arg of the upstream runfile
+type ArgOverride struct {
+	Value        *string `json:"value,omitempty"`
+	Disabled     *bool   `json:"disabled,omitempty"`
+	BaseValue    string  `json:"base_value"`              // upstream value when the override was made
+	BaseDisabled bool    `json:"base_disabled,omitempty"` // upstream disabled state when the override was made
+}
+
+// Overrides is the content of the local overrides file
+type Overrides struct {
+	SchemaVersion int                    `json:"schema_version"`
+	BaseVersion   string                 `json:"base_version"`          // Meta.Version of the upstream runfile the overrides were made against
+	Args          map[string]ArgOverride `json:"args,omitempty"`        // keyed by overrideKey
+	ExtraArgs     map[string][]GameArg   `json:"extra_args,omitempty"`  // args the upstream runfile doesn't have, by category
+	LegacyBase    bool                   `json:"legacy_base,omitempty"` // the upstream file predates the overrides and may still hold local values
+}
+
+var (
+	upstreamRunfile  *RunFile   // the loaded runfile before overrides were applied
+	currentOverrides *Overrides // the overrides applied to CurrentRunfile
+)
+
+// OverridesPath returns the path of the overrides file for a runfile identifier
+func OverridesPath(runFilesFolder, identifier string) string {
+	return filepath.Join(runFilesFolder, fmt.Sprintf("run%s.overrides.json", identifier))
+}
+
+// overrideKey identifies an arg across runfile versions: its flag (or label for flagless args) and OS restriction
+func overrideKey(arg GameArg) string {
+	key := arg.Flag
+	if key == "" {
+		key = "label:" + arg.UILabel
+	}
+	if arg.Os != "" {
+		key += "@" + arg.Os
+	}
+	return key
+}
+
+func argsByKey(rf *RunFile) map[string]GameArg {
+	args := make(map[string]GameArg)
+	if rf == nil {
+		return args
+	}
+	for _, categoryArgs := range rf.Args {
+		for _, arg := range categoryArgs {
+			args[overrideKey(arg)] = arg
+		}
+	}
+	return args
+}
+
+// plainValue returns the stored value of an arg, decrypted for secrets
+func plainValue(arg GameArg, stored string) string {
+	if arg.IsSecret() {
+		if plain, err := security.DecryptSecret(stored); err == nil {
+			return plain
+		}
+	}
+	return stored
+}
+
+// loadOverrides reads an overrides file. A missing file means no local changes besides the ones an older SSUI saved into the upstream file.
+func loadOverrides(path string) (*Overrides, bool, error) {
+	overrides := &Overrides{SchemaVersion: overridesSchemaVersion, Args: make(map[string]ArgOverride)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		overrides.LegacyBase = true
+		return overrides, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read overrides: %w", err)
+	}
+	if err := json.Unmarshal(data, overrides); err != nil {
+		return nil, true, fmt.Errorf("failed to parse overrides %s: %w", path, err)
+	}
+	if overrides.SchemaVersion > overridesSchemaVersion {
+		return nil, true, fmt.Errorf("overrides %s use schema version %d, this SSUI only supports up to %d", path, overrides.SchemaVersion, overridesSchemaVersion)
+	}
+	if overrides.Args == nil {
+		overrides.Args = make(map[string]ArgOverride)
+	}
+	return overrides, true, nil
+}
+
+func writeOverrides(path string, overrides *Overrides) error {
+	data, err := json.MarshalIndent(overrides, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize overrides: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write overrides: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace overrides: %w", err)
+	}
+	return nil
+}
+
+// applyOverrides merges the local changes into an upstream runfile and returns warnings about overrides that no longer apply
+func applyOverrides(rf *RunFile, overrides *Overrides) []string {
+	if rf.Args == nil {
+		rf.Args = make(map[string][]GameArg)
+	}
+	applied := make(map[string]bool)
+	for category := range rf.Args {
+		for i := range rf.Args[category] {
+			arg := &rf.Args[category][i]
+			key := overrideKey(*arg)
+			override, ok := overrides.Args[key]
+			if !ok {
+				continue
+			}
+			applied[key] = true
+			if override.Value != nil {
+				arg.Value = *override.Value
+			}
+			if override.Disabled != nil {
+				arg.Disabled = *override.Disabled
+			}
+		}
+	}
+
+	var warnings []string
+	upstream := argsByKey(rf)
+	for category, extras := range overrides.ExtraArgs {
+		for _, extra := range extras {
+			if _, exists := upstream[overrideKey(extra)]; exists {
+				warnings = append(warnings, fmt.Sprintf("extra arg %s is now part of the runfile, the local definition is ignored", overrideKey(extra)))
+				continue
+			}
+			rf.Args[category] = append(rf.Args[category], extra)
+		}
+	}
+
+	for key := range overrides.Args {
+		if !applied[key] {
+			warnings = append(warnings, fmt.Sprintf("override for %s does not match any arg of the runfile and is ignored", key))
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// buildOverrides computes the local changes of current against the upstream runfile.
+// Overrides that don't match any upstream arg are carried over from previous, so they aren't lost while an arg is missing.
+func buildOverrides(current, upstream *RunFile, previous *Overrides) *Overrides {
+	overrides := &Overrides{
+		SchemaVersion: overridesSchemaVersion,
+		BaseVersion:   upstream.Meta.Version,
+		Args:          make(map[string]ArgOverride),
+		ExtraArgs:     make(map[string][]GameArg),
+	}
+	if previous != nil {
+		overrides.LegacyBase = previous.LegacyBase
+	}
+	upstreamArgs := argsByKey(upstream)
+
+	for category, args := range current.Args {
+		for _, arg := range args {
+			key := overrideKey(arg)
+			base, ok := upstreamArgs[key]
+			if !ok {
+				overrides.ExtraArgs[category] = append(overrides.ExtraArgs[category], arg)
+				continue
+			}
+			override := ArgOverride{BaseValue: base.Value, BaseDisabled: base.Disabled}
+			if plainValue(arg, arg.Value) != plainValue(base, base.Value) {
+				value := arg.Value
+				override.Value = &value
+			}
+			if arg.Disabled != base.Disabled {
+				disabled := arg.Disabled
+				override.Disabled = &disabled
+			}
+			if override.Value != nil || override.Disabled != nil {
+				overrides.Args[key] = override
+			}
+		}
+	}
+
+	if previous != nil {
+		for key, override := range previous.Args {
+			if _, ok := upstreamArgs[key]; !ok {
+				overrides.Args[key] = override
+			}
+		}
+	}
+	return overrides
+}
+
+// hasPlainSecretOverrides reports whether the overrides hold secret values in plain text, e.g. after editing the file by hand
+func hasPlainSecretOverrides(upstream *RunFile, overrides *Overrides) bool {
+	upstreamArgs := argsByKey(upstream)
+	for key, override := range overrides.Args {
+		if arg, ok := upstreamArgs[key]; ok && arg.IsSecret() && override.Value != nil && *override.Value != "" && !security.IsEncryptedSecret(*override.Value) {
+			return true
+		}
+	}
+	for _, extras := range overrides.ExtraArgs {
+		for _, extra := range extras {
+			if extra.IsSecret() && extra.Value != "" && !security.IsEncryptedSecret(extra.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetArgDisabled enables or disables an arg for the current OS locally and saves the overrides
+func SetArgDisabled(flag string, disabled bool) error {
+	runfileMutex.Lock()
+	defer runfileMutex.Unlock()
+
+	if CurrentRunfile == nil {
+		return ErrRunfileNotLoaded{Msg: "runfile not loaded"}
+	}
+	for category := range CurrentRunfile.Args {
+		for i := range CurrentRunfile.Args[category] {
+			arg := &CurrentRunfile.Args[category][i]
+			if arg.Flag != flag || (arg.Os != "" && strings.ToLower(arg.Os) != strings.ToLower(runtime.GOOS)) {
+				continue
+			}
+			original := arg.Disabled
+			arg.Disabled = disabled
+			if err := saveRunfileLocked(); err != nil {
+				arg.Disabled = original
+				return fmt.Errorf("failed to save runfile: %w", err)
+			}
+			logger.Runfile.Debug(fmt.Sprintf("set arg disabled: flag=%s, disabled=%t", flag, disabled))
+			return nil
+		}
+	}
+	return ErrArgNotFound{Flag: flag}
+}
+
+// UpdateChange describes how one arg differs between the installed and the new upstream runfile
+type UpdateChange struct {
+	Key      string `json:"key"`
+	Kind     string `json:"kind"` // "added", "removed", "changed" or "definition_changed"
+	Base     string `json:"base,omitempty"`
+	Upstream string `json:"upstream,omitempty"`
+	Local    string `json:"local,omitempty"`
+	Conflict bool   `json:"conflict"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// UpdatePlan is the three-way comparison of the installed runfile, the local overrides and a new upstream runfile
+type UpdatePlan struct {
+	Identifier     string         `json:"identifier"`
+	CurrentVersion string         `json:"current_version"`
+	NewVersion     string         `json:"new_version"`
+	Legacy         bool           `json:"legacy"` // the installed runfile has no overrides file, its values are treated as local
+	Changes        []UpdateChange `json:"changes"`
+	Conflicts      int            `json:"conflicts"`
+	Backup         string         `json:"backup,omitempty"` // where the replaced runfile was moved, set once the update is applied
+}
+
+// updateState is everything PlanRunfileUpdate and ApplyRunfileUpdate work with
+type updateState struct {
+	plan      *UpdatePlan
+	upstream  *RunFile
+	overrides *Overrides
+	path      string
+}
+
+func parseRunfileData(data []byte) (*RunFile, error) {
+	migrated, _, _, err := migrateRunfileData(data)
+	if err != nil {
+		return nil, err
+	}
+	var rf RunFile
+	if err := json.Unmarshal(migrated, &rf); err != nil {
+		return nil, fmt.Errorf("failed to parse runfile: %w", err)
+	}
+	return &rf, nil
+}
+
+// displayValue hides secret values in update plans
+func displayValue(arg GameArg, value string) string {
+	if arg.IsSecret() && value != "" {
+		return logger.RedactedValue
+	}
+	return value
+}
+
+func definitionChanged(before, after GameArg) bool {
+	return before.Type != after.Type || before.Min != after.Min || before.Max != after.Max ||
+		before.RequiresValue != after.RequiresValue || !slices.Equal(before.Options, after.Options)
+}
+
+func planRunfileUpdate(runFilesFolder, identifier string, newData []byte) (*updateState, error) {
+	newRunfile, err := parseRunfileData(newData)
+	if err != nil {
+		return nil, fmt.Errorf("new runfile is invalid: %w", err)
+	}
+	state := &updateState{
+		plan:     &UpdatePlan{Identifier: identifier, NewVersion: newRunfile.Meta.Version, Changes: []UpdateChange{}},
+		upstream: newRunfile,
+		path:     OverridesPath(runFilesFolder, identifier),
+	}
+
+	var installed *RunFile
+	installedData, err := os.ReadFile(filepath.Join(runFilesFolder, fmt.Sprintf("run%s.ssui", identifier)))
+	if err == nil {
+		if installed, err = parseRunfileData(installedData); err != nil {
+			return nil, fmt.Errorf("installed runfile is invalid: %w", err)
+		}
+		state.plan.CurrentVersion = installed.Meta.Version
+	}
+
+	overrides, _, err := loadOverrides(state.path)
+	if err != nil {
+		return nil, err
+	}
+	if overrides.LegacyBase && installed != nil {
+		// an older SSUI saved values into the upstream file, keep every local value that differs from the new upstream
+		state.plan.Legacy = true
+		local, err := parseRunfileData(installedData)
+		if err != nil {
+			return nil, err
+		}
+		extras := overrides.ExtraArgs
+		applyOverrides(local, &Overrides{Args: overrides.Args})
+		overrides = buildOverrides(local, newRunfile, &Overrides{Args: overrides.Args})
+		overrides.ExtraArgs = extras
+	}
+	state.overrides = overrides
+
+	oldArgs := argsByKey(installed)
+	newArgs := argsByKey(newRunfile)
+	extras := make(map[string]bool)
+	for _, categoryArgs := range overrides.ExtraArgs {
+		for _, extra := range categoryArgs {
+			extras[overrideKey(extra)] = true
+		}
+	}
+
+	keys := make([]string, 0, len(newArgs)+len(oldArgs))
+	for key := range newArgs {
+		keys = append(keys, key)
+	}
+	for key := range oldArgs {
+		if _, ok := newArgs[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		newArg, inNew := newArgs[key]
+		oldArg, inOld := oldArgs[key]
+		override, overridden := overrides.Args[key]
+		change := UpdateChange{Key: key}
+
+		switch {
+		case !inNew:
+			change.Kind = "removed"
+			change.Base = displayValue(oldArg, oldArg.Value)
+			if overridden {
+				change.Conflict = true
+				change.Detail = "the arg was removed upstream, its local override will be dropped"
+			}
+		case !inOld && installed != nil:
+			change.Kind = "added"
+			change.Upstream = displayValue(newArg, newArg.Value)
+			if extras[key] {
+				change.Conflict = true
+				change.Detail = "a local extra arg with the same flag exists, its value is kept as an override"
+			}
+		default:
+			if installed == nil {
+				continue
+			}
+			valueChanged := oldArg.Value != newArg.Value || oldArg.Disabled != newArg.Disabled
+			if !valueChanged && !definitionChanged(oldArg, newArg) {
+				continue
+			}
+			change.Kind = "changed"
+			if !valueChanged {
+				change.Kind = "definition_changed"
+			}
+			change.Base = displayValue(oldArg, oldArg.Value)
+			change.Upstream = displayValue(newArg, newArg.Value)
+			if !overridden {
+				break
+			}
+			if override.Value != nil {
+				local := plainValue(newArg, *override.Value)
+				change.Local = displayValue(newArg, local)
+				if state.plan.Legacy && local != newArg.Value {
+					change.Conflict = true
+					change.Detail = "the installed runfile has no overrides file, so SSUI can't tell a local change from an older default"
+				} else if override.BaseValue != newArg.Value && local != newArg.Value {
+					change.Conflict = true
+					change.Detail = "the upstream default changed and differs from your local value"
+				}
+				if issue := validateArgValue(newArg, local); issue != "" && !HasPlaceholders(local) {
+					change.Conflict = true
+					change.Detail = "your local value is not valid for the new definition: " + issue
+				}
+			}
+			if override.Disabled != nil && override.BaseDisabled != newArg.Disabled && *override.Disabled != newArg.Disabled {
+				change.Conflict = true
+				if change.Detail == "" {
+					change.Detail = "the upstream disabled state changed and differs from your local one"
+				}
+			}
+		}
+		if change.Kind == "" {
+			continue
+		}
+		if change.Conflict {
+			state.plan.Conflicts++
+		}
+		state.plan.Changes = append(state.plan.Changes, change)
+	}
+	return state, nil
+}
+
+// PlanRunfileUpdate compares the installed runfile, its local overrides and new upstream runfile data without changing anything
+func PlanRunfileUpdate(runFilesFolder, identifier string, newData []byte) (*UpdatePlan, error) {
+	runfileMutex.Lock()
+	defer runfileMutex.Unlock()
+
+	state, err := planRunfileUpdate(runFilesFolder, identifier, newData)
+	if err != nil {
+		return nil, err
+	}
+	return state.plan, nil
+}
+
+// ApplyRunfileUpdate rebases the local overrides onto new upstream runfile data, backs up the installed runfile
+// and writes the new one and the overrides. If either write fails, the previous runfile is restored.
+// Conflicts keep the local value unless their key is listed in takeUpstream.
+func ApplyRunfileUpdate(runFilesFolder, identifier string, newData []byte, takeUpstream []string) (*UpdatePlan, error) {
+	runfileMutex.Lock()
+	defer runfileMutex.Unlock()
+
+	state, err := planRunfileUpdate(runFilesFolder, identifier, newData)
+	if err != nil {
+		return nil, err
+	}
+
+	newArgs := argsByKey(state.upstream)
+	rebased := &Overrides{
+		SchemaVersion: overridesSchemaVersion,
+		BaseVersion:   state.upstream.Meta.Version,
+		Args:          make(map[string]ArgOverride),
+		ExtraArgs:     make(map[string][]GameArg),
+	}
+	for key, override := range state.overrides.Args {
+		newArg, exists := newArgs[key]
+		if !exists || slices.Contains(takeUpstream, key) {
+			continue
+		}
+		if override.Value != nil && plainValue(newArg, *override.Value) == newArg.Value {
+			override.Value = nil
+		}
+		if override.Disabled != nil && *override.Disabled == newArg.Disabled {
+			override.Disabled = nil
+		}
+		if override.Value == nil && override.Disabled == nil {
+			continue
+		}
+		override.BaseValue, override.BaseDisabled = newArg.Value, newArg.Disabled
+		rebased.Args[key] = override
+	}
+	for category, extras := range state.overrides.ExtraArgs {
+		for _, extra := range extras {
+			key := overrideKey(extra)
+			newArg, exists := newArgs[key]
+			if !exists {
+				rebased.ExtraArgs[category] = append(rebased.ExtraArgs[category], extra)
+				continue
+			}
+			if slices.Contains(takeUpstream, key) || extra.Value == newArg.Value {
+				continue
+			}
+			value := extra.Value
+			rebased.Args[key] = ArgOverride{Value: &value, BaseValue: newArg.Value, BaseDisabled: newArg.Disabled}
+		}
+	}
+
+	// The runfile goes first: if the overrides can't be written, the old runfile is put back and both match again
+	runfilePath := filepath.Join(runFilesFolder, fmt.Sprintf("run%s.ssui", identifier))
+	previous, err := os.ReadFile(runfilePath)
+	hadRunfile := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read installed runfile: %w", err)
+	}
+	if hadRunfile {
+		backup, err := backupRunfile(runfilePath, previous)
+		if err != nil {
+			return nil, err
+		}
+		state.plan.Backup = backup
+	}
+	if err := writeFileAtomic(runfilePath, newData); err != nil {
+		return nil, err
+	}
+	if err := writeOverrides(state.path, rebased); err != nil {
+		var restoreErr error
+		if hadRunfile {
+			restoreErr = writeFileAtomic(runfilePath, previous)
+		} else {
+			restoreErr = os.Remove(runfilePath)
+		}
+		if restoreErr != nil {
+			logger.Runfile.Error(fmt.Sprintf("Failed to restore runfile %s after the overrides could not be written, the backup is at %s: %v", runfilePath, state.plan.Backup, restoreErr))
+		}
+		return nil, err
+	}
+	logger.Runfile.Info(fmt.Sprintf("Rebased local overrides of %s onto runfile version %s (%d changes, %d conflicts)", identifier, state.plan.NewVersion, len(state.plan.Changes), state.plan.Conflicts))
+	return state.plan, nil
+}
+
+// backupRunfile copies the installed runfile into the old folder next to it and returns the backup path
+func backupRunfile(runfilePath string, data []byte) (string, error) {
+	oldDir := filepath.Join(filepath.Dir(runfilePath), "old")
+	if err := os.MkdirAll(oldDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create runfile backup directory: %w", err)
+	}
+	backup := filepath.Join(oldDir, fmt.Sprintf("%s-old-%s.bak", filepath.Base(runfilePath), time.Now().Format("2006-01-02_15-04-05")))
+	if err := os.WriteFile(backup, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to back up runfile: %w", err)
+	}
+	return backup, nil
+}
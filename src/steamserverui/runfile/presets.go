@@ -0,0 +1,435 @@
+// presets.go
+package runfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+/*
+Argument Presets
+- A preset is a named snapshot of all arg values and disabled flags of the loaded runfile, e.g. "event-weekend"
+- Presets are stored per runfile in run<Identifier>.presets.json, secret values stay encrypted
+- Applying a preset validates the resulting runfile with RunFile.Validate first and saves it as local overrides
+- A preset can be scheduled, it is then applied right before the first server start after the scheduled time
+*/
+
+// Preset is a named snapshot of arg values, keyed by overrideKey
+type Preset struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Values      map[string]string `json:"values"`
+	Disabled    map[string]bool   `json:"disabled,omitempty"`
+}
+
+// PresetSchedule applies a preset at the first server start after At
+type PresetSchedule struct {
+	Preset string    `json:"preset"`
+	At     time.Time `json:"at"`
+}
+
+// PresetDiff is one arg whose value differs between the loaded runfile and a preset
+type PresetDiff struct {
+	Key             string `json:"key"`
+	Current         string `json:"current"`
+	Preset          string `json:"preset"`
+	CurrentDisabled bool   `json:"current_disabled"`
+	PresetDisabled  bool   `json:"preset_disabled"`
+	NotInPreset     bool   `json:"not_in_preset,omitempty"` // the arg was added after the preset was made, applying keeps its current value
+}
+
+type presetFile struct {
+	Presets  []Preset         `json:"presets"`
+	Schedule []PresetSchedule `json:"schedule,omitempty"`
+}
+
+// presetsMutex guards the presets file, lock it before runfileMutex when both are needed
+var presetsMutex sync.Mutex
+
+func presetsPath() string {
+	return filepath.Join(config.GetRunFilesFolder(), fmt.Sprintf("run%s.presets.json", config.GetRunfileIdentifier()))
+}
+
+func loadPresetFile() (*presetFile, error) {
+	file := &presetFile{Presets: []Preset{}}
+	data, err := os.ReadFile(presetsPath())
+	if os.IsNotExist(err) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read presets: %w", err)
+	}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse presets: %w", err)
+	}
+	return file, nil
+}
+
+func savePresetFile(file *presetFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize presets: %w", err)
+	}
+	path := presetsPath()
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write presets: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace presets: %w", err)
+	}
+	return nil
+}
+
+func (file *presetFile) find(name string) (int, *Preset) {
+	for i := range file.Presets {
+		if strings.EqualFold(file.Presets[i].Name, name) {
+			return i, &file.Presets[i]
+		}
+	}
+	return -1, nil
+}
+
+// ListPresets returns all presets of the loaded runfile and the pending schedule
+func ListPresets() ([]Preset, []PresetSchedule, error) {
+	presetsMutex.Lock()
+	defer presetsMutex.Unlock()
+
+	if CurrentRunfile == nil {
+		return nil, nil, ErrRunfileNotLoaded{Msg: "runfile not loaded"}
+	}
+	file, err := loadPresetFile()
+	if err != nil {
+		return nil, nil, err
+	}
+	return file.Presets, file.Schedule, nil
+}
+
+// CreatePreset snapshots the current arg values of the loaded runfile under name, replacing a preset with the same name
+func CreatePreset(name, description string) (Preset, error) {
+	presetsMutex.Lock()
+	defer presetsMutex.Unlock()
+	runfileMutex.Lock()
+	defer runfileMutex.Unlock()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Preset{}, fmt.Errorf("preset name is required")
+	}
+	if CurrentRunfile == nil {
+		return Preset{}, ErrRunfileNotLoaded{Msg: "runfile not loaded"}
+	}
+
+	preset := Preset{
+		Name:        name,
+		Description: description,
+		CreatedAt:   time.Now(),
+		Values:      make(map[string]string),
+		Disabled:    make(map[string]bool),
+	}
+	for _, args := range CurrentRunfile.Args {
+		for _, arg := range args {
+			value := arg.RuntimeValue
+			if arg.IsSecret() {
+				encrypted, err := security.EncryptSecret(value)
+				if err != nil {
+					return Preset{}, fmt.Errorf("failed to encrypt secret argument %s: %w", arg.Flag, err)
+				}
+				value = encrypted
+			}
+			key := overrideKey(arg)
+			preset.Values[key] = value
+			if arg.Disabled {
+				preset.Disabled[key] = true
+			}
+		}
+	}
+
+	file, err := loadPresetFile()
+	if err != nil {
+		return Preset{}, err
+	}
+	if i, _ := file.find(name); i >= 0 {
+		file.Presets[i] = preset
+	} else {
+		file.Presets = append(file.Presets, preset)
+	}
+	if err := savePresetFile(file); err != nil {
+		return Preset{}, err
+	}
+	logger.Runfile.Info(fmt.Sprintf("Saved preset %s with %d values", name, len(preset.Values)))
+	return preset, nil
+}
+
+// DeletePreset removes a preset and any schedule for it
+func DeletePreset(name string) error {
+	presetsMutex.Lock()
+	defer presetsMutex.Unlock()
+
+	file, err := loadPresetFile()
+	if err != nil {
+		return err
+	}
+	i, _ := file.find(name)
+	if i < 0 {
+		return fmt.Errorf("preset %s not found", name)
+	}
+	file.Presets = append(file.Presets[:i], file.Presets[i+1:]...)
+	file.Schedule = removeSchedules(file.Schedule, name)
+	if err := savePresetFile(file); err != nil {
+		return err
+	}
+	logger.Runfile.Info("Deleted preset " + name)
+	return nil
+}
+
+func removeSchedules(schedule []PresetSchedule, name string) []PresetSchedule {
+	kept := schedule[:0]
+	for _, entry := range schedule {
+		if !strings.EqualFold(entry.Preset, name) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// DiffPreset lists the args whose values would change when applying a preset
+func DiffPreset(name string) ([]PresetDiff, error) {
+	presetsMutex.Lock()
+	defer presetsMutex.Unlock()
+	runfileMutex.Lock()
+	defer runfileMutex.Unlock()
+
+	if CurrentRunfile == nil {
+		return nil, ErrRunfileNotLoaded{Msg: "runfile not loaded"}
+	}
+	file, err := loadPresetFile()
+	if err != nil {
+		return nil, err
+	}
+	_, preset := file.find(name)
+	if preset == nil {
+		return nil, fmt.Errorf("preset %s not found", name)
+	}
+
+	diffs := []PresetDiff{}
+	for _, args := range CurrentRunfile.Args {
+		for _, arg := range args {
+			key := overrideKey(arg)
+			stored, ok := preset.Values[key]
+			if !ok {
+				diffs = append(diffs, PresetDiff{Key: key, Current: displayValue(arg, arg.RuntimeValue), CurrentDisabled: arg.Disabled, PresetDisabled: arg.Disabled, NotInPreset: true})
+				continue
+			}
+			value := plainValue(arg, stored)
+			if value == arg.RuntimeValue && preset.Disabled[key] == arg.Disabled {
+				continue
+			}
+			diffs = append(diffs, PresetDiff{
+				Key:             key,
+				Current:         displayValue(arg, arg.RuntimeValue),
+				Preset:          displayValue(arg, value),
+				CurrentDisabled: arg.Disabled,
+				PresetDisabled:  preset.Disabled[key],
+			})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Key < diffs[j].Key })
+	return diffs, nil
+}
+
+// ApplyPreset sets all arg values of a preset, the result is validated before anything is changed
+func ApplyPreset(name string) error {
+	presetsMutex.Lock()
+	defer presetsMutex.Unlock()
+	return applyPresetLocked(name)
+}
+
+// applyPresetLocked must be called with presetsMutex held
+func applyPresetLocked(name string) error {
+	runfileMutex.Lock()
+	defer runfileMutex.Unlock()
+
+	if CurrentRunfile == nil {
+		return ErrRunfileNotLoaded{Msg: "runfile not loaded"}
+	}
+	file, err := loadPresetFile()
+	if err != nil {
+		return err
+	}
+	_, preset := file.find(name)
+	if preset == nil {
+		return fmt.Errorf("preset %s not found", name)
+	}
+	candidate, err := presetCandidateLocked(preset)
+	if err != nil {
+		return err
+	}
+
+	original := CurrentRunfile.Args
+	CurrentRunfile.Args = candidate.Args
+	if err := saveRunfileLocked(); err != nil {
+		CurrentRunfile.Args = original
+		return fmt.Errorf("failed to save runfile: %w", err)
+	}
+	logger.Runfile.Info("Applied preset " + preset.Name)
+	return nil
+}
+
+// presetCandidateLocked returns a copy of the loaded runfile with the preset applied, so an invalid preset leaves
+// the loaded runfile untouched. Must be called with runfileMutex held.
+func presetCandidateLocked(preset *Preset) (*RunFile, error) {
+	candidate := *CurrentRunfile
+	candidate.Args = make(map[string][]GameArg, len(CurrentRunfile.Args))
+	for category, args := range CurrentRunfile.Args {
+		candidate.Args[category] = append([]GameArg(nil), args...)
+		for i := range candidate.Args[category] {
+			arg := &candidate.Args[category][i]
+			key := overrideKey(*arg)
+			stored, ok := preset.Values[key]
+			if !ok {
+				logger.Runfile.Debug(fmt.Sprintf("preset %s has no value for %s, keeping the current one", preset.Name, key))
+				continue
+			}
+			arg.RuntimeValue = plainValue(*arg, stored)
+			arg.Disabled = preset.Disabled[key]
+		}
+	}
+	if err := candidate.Validate(); err != nil {
+		logger.Runfile.Error(fmt.Sprintf("preset %s is not valid for the current runfile: %v", preset.Name, err))
+		return nil, err
+	}
+	return &candidate, nil
+}
+
+// SchedulePreset applies a preset at the first server start after at, a zero time means the next start
+func SchedulePreset(name string, at time.Time) (PresetSchedule, error) {
+	presetsMutex.Lock()
+	defer presetsMutex.Unlock()
+
+	file, err := loadPresetFile()
+	if err != nil {
+		return PresetSchedule{}, err
+	}
+	_, preset := file.find(name)
+	if preset == nil {
+		return PresetSchedule{}, fmt.Errorf("preset %s not found", name)
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	entry := PresetSchedule{Preset: preset.Name, At: at}
+	file.Schedule = append(file.Schedule, entry)
+	sort.Slice(file.Schedule, func(i, j int) bool { return file.Schedule[i].At.Before(file.Schedule[j].At) })
+	if err := savePresetFile(file); err != nil {
+		return PresetSchedule{}, err
+	}
+	logger.Runfile.Info(fmt.Sprintf("Scheduled preset %s for the first server start after %s", preset.Name, at.Format(time.RFC3339)))
+	return entry, nil
+}
+
+// UnschedulePreset removes all pending schedules of a preset
+func UnschedulePreset(name string) error {
+	presetsMutex.Lock()
+	defer presetsMutex.Unlock()
+
+	file, err := loadPresetFile()
+	if err != nil {
+		return err
+	}
+	before := len(file.Schedule)
+	file.Schedule = removeSchedules(file.Schedule, name)
+	if len(file.Schedule) == before {
+		return fmt.Errorf("preset %s is not scheduled", name)
+	}
+	return savePresetFile(file)
+}
+
+// ApplyScheduledPresets applies the latest due scheduled preset and drops all due entries, called right before the server starts.
+// A preset that fails validation is dropped as well and the server starts with the current values.
+func ApplyScheduledPresets() {
+	presetsMutex.Lock()
+	defer presetsMutex.Unlock()
+
+	if CurrentRunfile == nil {
+		return
+	}
+	file, err := loadPresetFile()
+	if err != nil {
+		logger.Runfile.Warn("Failed to check scheduled presets: " + err.Error())
+		return
+	}
+
+	now := time.Now()
+	due, pending := file.dueSchedule(now)
+	if due == nil {
+		return
+	}
+	for i := range file.Schedule {
+		if entry := &file.Schedule[i]; entry != due && !entry.At.After(now) {
+			logger.Runfile.Info(fmt.Sprintf("Skipping scheduled preset %s, a later one is due as well", entry.Preset))
+		}
+	}
+
+	if err := applyPresetLocked(due.Preset); err != nil {
+		logger.Runfile.Error(fmt.Sprintf("Scheduled preset %s was not applied, starting with the current values: %v", due.Preset, err))
+	} else {
+		logger.Runfile.Info(fmt.Sprintf("Applied scheduled preset %s (due %s)", due.Preset, due.At.Format(time.RFC3339)))
+	}
+	file.Schedule = pending
+	if err := savePresetFile(file); err != nil {
+		logger.Runfile.Warn("Failed to update preset schedule: " + err.Error())
+	}
+}
+
+// dueSchedule returns the latest scheduled entry that is due at now, nil if none is, and the entries still pending
+func (file *presetFile) dueSchedule(now time.Time) (*PresetSchedule, []PresetSchedule) {
+	var due *PresetSchedule
+	var pending []PresetSchedule
+	for i, entry := range file.Schedule {
+		if entry.At.After(now) {
+			pending = append(pending, entry)
+			continue
+		}
+		due = &file.Schedule[i]
+	}
+	return due, pending
+}
+
+// BuildPreviewCommandArgs builds the command-line arguments the next start would use, with the scheduled preset
+// ApplyScheduledPresets would apply, but without applying it. Returns that presets name, empty if none is due.
+func BuildPreviewCommandArgs() ([]string, string, error) {
+	presetsMutex.Lock()
+	defer presetsMutex.Unlock()
+	runfileMutex.Lock()
+	defer runfileMutex.Unlock()
+
+	if CurrentRunfile == nil {
+		return nil, "", ErrRunfileNotLoaded{Msg: "no runfile is currently loaded"}
+	}
+	rf, presetName := CurrentRunfile, ""
+	if file, err := loadPresetFile(); err == nil {
+		if due, _ := file.dueSchedule(time.Now()); due != nil {
+			// like at the start, a preset that isn't valid anymore is dropped and the current values are used
+			if _, preset := file.find(due.Preset); preset != nil {
+				if candidate, err := presetCandidateLocked(preset); err == nil {
+					rf, presetName = candidate, preset.Name
+				}
+			}
+		}
+	}
+	args, err := rf.buildCommandArgs()
+	return args, presetName, err
+}
@@ -0,0 +1,506 @@
+// revisions.go
+package runfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+/*
+File Revision History
+- Every save of a declared file (file editor, key-level set, restore, template render) keeps a revision
+  with time, user and content hash under <runfiles folder>/history/<Identifier>/<file>/
+- Contents are stored once per hash, so unchanged saves and restores don't take extra space
+- Changes made outside SSUI are snapshotted the next time SSUI accesses the file, with source "external"
+- The history is bounded to maxFileRevisions per file, the oldest revisions and their contents are dropped first
+- Contents that hold the value of a secret arg are stored encrypted with the secrets key and marked as encrypted
+*/
+
+const maxFileRevisions = 50
+
+// Revision sources
+const (
+	RevisionSourceInitial  = "initial"  // first time SSUI saw the file
+	RevisionSourceEditor   = "editor"   // saved in the file editor
+	RevisionSourceKey      = "key"      // a single value was set
+	RevisionSourceRestore  = "restore"  // an older revision was restored
+	RevisionSourceTemplate = "template" // rendered from a runfile template
+	RevisionSourceExternal = "external" // changed outside of SSUI
+)
+
+// FileRevision is one stored version of a declared file
+type FileRevision struct {
+	ID        int       `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	User      string    `json:"user,omitempty"` // empty for external changes
+	Source    string    `json:"source"`
+	Note      string    `json:"note,omitempty"`
+	Hash      string    `json:"hash"`
+	Size      int       `json:"size"`
+	Encrypted bool      `json:"encrypted,omitempty"` // the content holds a secret and is stored encrypted
+}
+
+type revisionIndex struct {
+	NextID    int            `json:"next_id"`
+	Revisions []FileRevision `json:"revisions"` // oldest first
+}
+
+var revisionsMutex sync.Mutex
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// revisionsDir returns the history directory of a file. Names with unsafe characters get a hash of the
+// original name appended, so "a b.ini" and "a_b.ini" don't share one history.
+func revisionsDir(filename string) string {
+	name := unsafeFileNameChars.ReplaceAllString(filename, "_")
+	if name != filename {
+		name += "-" + hashContent([]byte(filename))[:12]
+	}
+	return filepath.Join(config.GetRunFilesFolder(), "history", config.GetRunfileIdentifier(), name)
+}
+
+func loadRevisionIndex(dir string) (*revisionIndex, error) {
+	index := &revisionIndex{NextID: 1}
+	data, err := os.ReadFile(filepath.Join(dir, "index.json"))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revision index: %w", err)
+	}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, fmt.Errorf("failed to parse revision index: %w", err)
+	}
+	return index, nil
+}
+
+func saveRevisionIndex(dir string, index *revisionIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize revision index: %w", err)
+	}
+	return writeFileAtomic(filepath.Join(dir, "index.json"), data)
+}
+
+func revisionContentPath(dir string, revision FileRevision) string {
+	if revision.Encrypted {
+		return filepath.Join(dir, revision.Hash+".rev.enc")
+	}
+	return filepath.Join(dir, revision.Hash+".rev")
+}
+
+// containsSecret reports whether content holds the value of a secret arg, those are registered with the logger
+func containsSecret(content []byte) bool {
+	return logger.Redact(string(content)) != string(content)
+}
+
+// addRevision stores content as a new revision unless it matches the latest one, revisionsMutex must be held
+func addRevision(filename string, content []byte, user, source, note string) (*FileRevision, error) {
+	dir := revisionsDir(filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create revision directory: %w", err)
+	}
+	index, err := loadRevisionIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	hash := hashContent(content)
+	if n := len(index.Revisions); n > 0 && index.Revisions[n-1].Hash == hash {
+		return &index.Revisions[n-1], nil
+	}
+
+	revision := FileRevision{
+		ID:        index.NextID,
+		Timestamp: time.Now(),
+		User:      user,
+		Source:    source,
+		Note:      note,
+		Hash:      hash,
+		Size:      len(content),
+		Encrypted: containsSecret(content),
+	}
+	contentPath := revisionContentPath(dir, revision)
+	if _, err := os.Stat(contentPath); os.IsNotExist(err) {
+		stored, mode := content, os.FileMode(0644)
+		if revision.Encrypted {
+			encrypted, err := security.EncryptSecret(string(content))
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt revision content: %w", err)
+			}
+			stored, mode = []byte(encrypted), 0600
+		}
+		if err := os.WriteFile(contentPath, stored, mode); err != nil {
+			return nil, fmt.Errorf("failed to store revision content: %w", err)
+		}
+	}
+	index.NextID++
+	index.Revisions = append(index.Revisions, revision)
+
+	if len(index.Revisions) > maxFileRevisions {
+		dropped := index.Revisions[:len(index.Revisions)-maxFileRevisions]
+		index.Revisions = index.Revisions[len(index.Revisions)-maxFileRevisions:]
+		kept := make(map[string]bool)
+		for _, r := range index.Revisions {
+			kept[revisionContentPath(dir, r)] = true
+		}
+		for _, r := range dropped {
+			if path := revisionContentPath(dir, r); !kept[path] {
+				os.Remove(path)
+			}
+		}
+	}
+	if err := saveRevisionIndex(dir, index); err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// captureFileLocked snapshots the file on disk if it differs from the latest revision, revisionsMutex must be held
+func captureFileLocked(file File) error {
+	content, err := os.ReadFile(file.Filepath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", file.Filename, err)
+	}
+	index, err := loadRevisionIndex(revisionsDir(file.Filename))
+	if err != nil {
+		return err
+	}
+	source := RevisionSourceExternal
+	if len(index.Revisions) == 0 {
+		source = RevisionSourceInitial
+	} else if index.Revisions[len(index.Revisions)-1].Hash == hashContent(content) {
+		return nil
+	}
+	revision, err := addRevision(file.Filename, content, "", source, "")
+	if err != nil {
+		return err
+	}
+	if source == RevisionSourceExternal {
+		logger.Runfile.Info(fmt.Sprintf("%s was changed outside of SSUI, stored it as revision %d", file.Filename, revision.ID))
+	}
+	return nil
+}
+
+// CaptureFileChanges snapshots a declared file if it was changed outside of SSUI since its latest revision
+func CaptureFileChanges(filename string) error {
+	file, err := findFile(filename)
+	if err != nil {
+		return err
+	}
+	revisionsMutex.Lock()
+	defer revisionsMutex.Unlock()
+	return captureFileLocked(file)
+}
+
+// WriteFileRevision captures outside changes, runs write and records content as a new revision of a declared file.
+// All of it happens under revisionsMutex, so a concurrent capture can't store the save as an outside change.
+func WriteFileRevision(filename string, content []byte, user, source, note string, write func() error) error {
+	file, err := findFile(filename)
+	if err != nil {
+		return err
+	}
+	revisionsMutex.Lock()
+	defer revisionsMutex.Unlock()
+	if err := captureFileLocked(file); err != nil {
+		logger.Runfile.Warn(err.Error())
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	if _, err := addRevision(filename, content, user, source, note); err != nil {
+		logger.Runfile.Warn(fmt.Sprintf("failed to record revision of %s: %v", filename, err))
+	}
+	return nil
+}
+
+// RecordFileRevision stores content that was just written to a declared file
+func RecordFileRevision(filename string, content []byte, user, source, note string) {
+	revisionsMutex.Lock()
+	defer revisionsMutex.Unlock()
+	if _, err := addRevision(filename, content, user, source, note); err != nil {
+		logger.Runfile.Warn(fmt.Sprintf("failed to record revision of %s: %v", filename, err))
+	}
+}
+
+// declaredFileAt returns the declared file at path, used where files are written by path
+func declaredFileAt(path string) (File, bool) {
+	if CurrentRunfile == nil {
+		return File{}, false
+	}
+	files, _ := resolveFiles(CurrentRunfile)
+	for _, file := range files {
+		if samePath(file.Filepath, path) {
+			return file, true
+		}
+	}
+	return File{}, false
+}
+
+// samePath reports whether two paths point to the same file, relative ones are relative to the SSUI directory
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+// ListFileRevisions returns the revisions of a declared file, newest first, after capturing outside changes
+func ListFileRevisions(filename string) ([]FileRevision, error) {
+	file, err := findFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	revisionsMutex.Lock()
+	defer revisionsMutex.Unlock()
+	if err := captureFileLocked(file); err != nil {
+		return nil, err
+	}
+	index, err := loadRevisionIndex(revisionsDir(filename))
+	if err != nil {
+		return nil, err
+	}
+	revisions := append([]FileRevision{}, index.Revisions...)
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].ID > revisions[j].ID })
+	return revisions, nil
+}
+
+// revisionContentLocked returns the content of a revision, revisionsMutex must be held
+func revisionContentLocked(filename string, id int) ([]byte, *FileRevision, error) {
+	dir := revisionsDir(filename)
+	index, err := loadRevisionIndex(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range index.Revisions {
+		if index.Revisions[i].ID == id {
+			content, err := os.ReadFile(revisionContentPath(dir, index.Revisions[i]))
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read revision %d of %s: %w", id, filename, err)
+			}
+			if index.Revisions[i].Encrypted {
+				plain, err := security.DecryptSecret(string(content))
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed to decrypt revision %d of %s: %w", id, filename, err)
+				}
+				content = []byte(plain)
+			}
+			return content, &index.Revisions[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("revision %d of %s not found", id, filename)
+}
+
+// DiffFileRevisions returns a unified diff from revision "from" to revision "to", 0 stands for the file as it is on disk now
+func DiffFileRevisions(filename string, from, to int) (string, error) {
+	file, err := findFile(filename)
+	if err != nil {
+		return "", err
+	}
+	revisionsMutex.Lock()
+	defer revisionsMutex.Unlock()
+	if err := captureFileLocked(file); err != nil {
+		return "", err
+	}
+
+	load := func(id int) ([]byte, string, error) {
+		if id == 0 {
+			content, err := os.ReadFile(file.Filepath)
+			if err != nil && !os.IsNotExist(err) {
+				return nil, "", fmt.Errorf("failed to read file %s: %w", filename, err)
+			}
+			return content, filename + " (current)", nil
+		}
+		content, revision, err := revisionContentLocked(filename, id)
+		if err != nil {
+			return nil, "", err
+		}
+		return content, fmt.Sprintf("%s (revision %d, %s)", filename, revision.ID, revision.Timestamp.Format(time.RFC3339)), nil
+	}
+	before, beforeName, err := load(from)
+	if err != nil {
+		return "", err
+	}
+	after, afterName, err := load(to)
+	if err != nil {
+		return "", err
+	}
+	return unifiedDiff(beforeName, afterName, before, after)
+}
+
+// RestoreFileRevision writes the content of a revision back to a declared file and records it as a new revision
+func RestoreFileRevision(filename string, id int, user string) (*FileRevision, error) {
+	file, err := findFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	revisionsMutex.Lock()
+	defer revisionsMutex.Unlock()
+	// an outside change would be lost otherwise
+	if err := captureFileLocked(file); err != nil {
+		return nil, err
+	}
+	content, _, err := revisionContentLocked(filename, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(file.Filepath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for %s: %w", filename, err)
+	}
+	if err := writeFileAtomic(file.Filepath, content); err != nil {
+		return nil, err
+	}
+	revision, err := addRevision(filename, content, user, RevisionSourceRestore, fmt.Sprintf("restored revision %d", id))
+	if err != nil {
+		return nil, err
+	}
+	logger.Runfile.Info(fmt.Sprintf("%s restored %s to revision %d", user, filename, id))
+	return revision, nil
+}
+
+// maxDiffCells bounds the line matrix of unifiedDiff to 8MB, unchanged lines at the start and end don't count
+const maxDiffCells = 2_000_000
+
+// unifiedDiff returns the differences between two texts in unified diff format with three lines of context, "" if they are equal
+func unifiedDiff(beforeName, afterName string, before, after []byte) (string, error) {
+	a, b := splitLines(before), splitLines(after)
+	if slices.Equal(a, b) {
+		return "", nil
+	}
+
+	// only the part between the common prefix and suffix needs the matrix
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := len(a)-suffix, len(b)-suffix
+	if (midA-prefix)*(midB-prefix) > maxDiffCells {
+		return "", fmt.Errorf("files are too large to diff")
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[prefix+i:midA] and b[prefix+j:midB]
+	lcs := make([][]int32, midA-prefix+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, midB-prefix+1)
+	}
+	for i := midA - prefix - 1; i >= 0; i-- {
+		for j := midB - prefix - 1; j >= 0; j-- {
+			if a[prefix+i] == b[prefix+j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type line struct {
+		op   byte // ' ', '-' or '+'
+		text string
+		i, j int // line numbers in a and b before this line
+	}
+	var lines []line
+	for i := range prefix {
+		lines = append(lines, line{' ', a[i], i, i})
+	}
+	i, j := prefix, prefix
+	for i < midA || j < midB {
+		switch {
+		case i < midA && j < midB && a[i] == b[j]:
+			lines = append(lines, line{' ', a[i], i, j})
+			i++
+			j++
+		case i < midA && (j == midB || lcs[i-prefix+1][j-prefix] >= lcs[i-prefix][j-prefix+1]):
+			lines = append(lines, line{'-', a[i], i, j})
+			i++
+		default:
+			lines = append(lines, line{'+', b[j], i, j})
+			j++
+		}
+	}
+	for ; i < len(a); i, j = i+1, j+1 {
+		lines = append(lines, line{' ', a[i], i, j})
+	}
+
+	const context = 3
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", beforeName, afterName)
+	for start := 0; start < len(lines); {
+		if lines[start].op == ' ' {
+			start++
+			continue
+		}
+		// a hunk runs until there are more than 2*context unchanged lines in a row
+		first := max(start-context, 0)
+		end := start
+		for k := start; k < len(lines); k++ {
+			if lines[k].op != ' ' {
+				end = k
+			} else if k-end > 2*context {
+				break
+			}
+		}
+		last := min(end+context, len(lines)-1)
+
+		aCount, bCount := 0, 0
+		for _, l := range lines[first : last+1] {
+			if l.op != '+' {
+				aCount++
+			}
+			if l.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(lines[first].i, aCount), hunkRange(lines[first].j, bCount))
+		for _, l := range lines[first : last+1] {
+			out.WriteByte(l.op)
+			out.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = last + 1
+	}
+	return out.String(), nil
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines splits text into lines that keep their line break
+func splitLines(text []byte) []string {
+	if len(text) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(text), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,114 @@
+// schema.go
+package runfile
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+/*
+Runfile JSON Schema
+- Generated from the Go types via reflection, so it can't drift from what LoadRunfile actually reads
+- Known value sets (os, special, file types, access list formats) are added as enums
+- Unknown properties are rejected, which catches typos in field names
+*/
+
+const schemaID = "https://steamserverui.github.io/runfiles/runfile.schema.json"
+
+// Known values for string fields, keyed by "<GoType>.<json name>"
+var schemaEnums = map[string][]string{
+	"GameArg.os":            {"", "linux", "windows"},
+	"GameArg.special":       knownSpecialValues,
+	"GameArg.type":          knownArgTypes,
+	"File.type":             {"json", "ini", "xml", "yaml", "text"},
+	"AccessListFile.format": {"lines", "comma", "json"},
+	"FileTemplate.type":     {"json", "ini", "xml", "yaml", "text"},
+	"FileTemplate.on_drift": knownTemplateDriftModes,
+	"WorkshopConfig.mode":   {"", "link", "copy"},
+}
+
+// Fields a runfile can't work without, keyed by Go type name
+var schemaRequired = map[string][]string{
+	"RunFile":        {"meta", "steam_app_id", "args"},
+	"Meta":           {"name"},
+	"GameArg":        {"flag"},
+	"File":           {"filename", "filepath", "type", "description"},
+	"AccessListFile": {"filepath", "format"},
+	"FileTemplate":   {"target", "type"},
+	"WorkshopConfig": {"app_id", "mods_dir"},
+}
+
+// knownSpecialValues are the special values BuildCommandArgs and the UI understand
+var knownSpecialValues = []string{"", "space_delimited", "dont_append_flag_just_value", "hide_in_ui"}
+
+// GenerateJSONSchema returns the JSON Schema (draft 2020-12) for the .ssui runfile format
+func GenerateJSONSchema() ([]byte, error) {
+	schema := schemaForType(reflect.TypeOf(RunFile{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = schemaID
+	schema["title"] = "SteamServerUI runfile"
+	// editors add "$schema" to get completion, don't reject it
+	schema["properties"].(map[string]any)["$schema"] = map[string]any{"type": "string"}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func schemaForType(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaForType(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaForType(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := jsonFieldName(field)
+			if name == "" {
+				continue
+			}
+			property := schemaForType(field.Type)
+			if values, ok := schemaEnums[t.Name()+"."+name]; ok {
+				property["enum"] = values
+			}
+			properties[name] = property
+		}
+		schema := map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if required, ok := schemaRequired[t.Name()]; ok {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]any{}
+	}
+}
+
+// jsonFieldName returns the JSON property name of a struct field, or "" if it isn't serialized
+func jsonFieldName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,117 @@
+// secrets.go
+package runfile
+
+import (
+	"fmt"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/core/security"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+/*
+Secret Arguments
+- Args of type secret are stored encrypted in the runfile (see security.EncryptSecret)
+- RuntimeValue always holds the plain value, Value the encrypted one once the runfile was saved
+- Plain values from older or hand edited runfiles are encrypted on load
+- A secret that can't be decrypted (e.g. after the secrets key changed) is cleared and marked NeedsReentry,
+  the runfile still loads, but the server doesn't start until the value was entered again
+- The plain values are registered with the logger, so they are masked in every log line
+*/
+
+// IsSecret reports whether the args value must never be shown
+func (arg GameArg) IsSecret() bool {
+	return arg.Type == ArgTypeSecret
+}
+
+// decryptSecretArgs fills the runtime values of secret args, returns true if any secret was stored in plain text
+func decryptSecretArgs(rf *RunFile) bool {
+	plainFound := false
+	for category := range rf.Args {
+		for i := range rf.Args[category] {
+			arg := &rf.Args[category][i]
+			if !arg.IsSecret() || arg.Value == "" {
+				continue
+			}
+			if !security.IsEncryptedSecret(arg.Value) {
+				plainFound = true
+				continue
+			}
+			plain, err := security.DecryptSecret(arg.Value)
+			if err != nil {
+				logger.Runfile.Error(fmt.Sprintf("failed to decrypt secret argument %s, it was cleared and has to be entered again: %v", arg.Flag, err))
+				arg.Value, arg.RuntimeValue, arg.NeedsReentry = "", "", true
+				continue
+			}
+			arg.RuntimeValue = plain
+		}
+	}
+	return plainFound
+}
+
+// encryptSecretArgs stores the runtime values of secret args encrypted in Value, keeping the existing ciphertext if the value didn't change
+func encryptSecretArgs(rf *RunFile) error {
+	for category := range rf.Args {
+		for i := range rf.Args[category] {
+			arg := &rf.Args[category][i]
+			if !arg.IsSecret() {
+				continue
+			}
+			if security.IsEncryptedSecret(arg.Value) {
+				if current, err := security.DecryptSecret(arg.Value); err == nil && current == arg.RuntimeValue {
+					continue
+				}
+			}
+			encrypted, err := security.EncryptSecret(arg.RuntimeValue)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt secret argument %s: %w", arg.Flag, err)
+			}
+			arg.Value = encrypted
+		}
+	}
+	return nil
+}
+
+// registerSecretRedactions hands the current secret values to the logger
+func registerSecretRedactions(rf *RunFile) {
+	var secrets []string
+	if rf != nil {
+		for _, args := range rf.Args {
+			for _, arg := range args {
+				if arg.IsSecret() && arg.RuntimeValue != "" {
+					secrets = append(secrets, arg.RuntimeValue)
+				}
+			}
+		}
+		// a secret taken from ${env:...} only becomes known once resolved
+		resolved, _ := resolveArgs(rf.getAllArgs())
+		for _, arg := range resolved {
+			if arg.IsSecret() && arg.RuntimeValue != "" {
+				secrets = append(secrets, arg.RuntimeValue)
+			}
+		}
+	}
+	logger.SetRedactions("runfile", secrets)
+}
+
+// MaskSecretArgs returns a copy of command line args with every secret value replaced, for logging the start command.
+// Unlike the logger redaction this also masks short secrets, since we know exactly which args they are.
+func MaskSecretArgs(args []string) []string {
+	secrets := make(map[string]bool)
+	if CurrentRunfile != nil {
+		resolved, _ := resolveArgs(CurrentRunfile.getAllArgs())
+		for _, arg := range resolved {
+			if arg.IsSecret() && arg.RuntimeValue != "" {
+				secrets[arg.RuntimeValue] = true
+			}
+		}
+	}
+	masked := make([]string, len(args))
+	for i, arg := range args {
+		if secrets[arg] {
+			masked[i] = logger.RedactedValue
+		} else {
+			masked[i] = arg
+		}
+	}
+	return masked
+}
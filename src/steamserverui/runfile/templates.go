@@ -0,0 +1,373 @@
+// templates.go
+package runfile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/SteamServerUI/SteamServerUI/v7/src/config"
+	"github.com/SteamServerUI/SteamServerUI/v7/src/logger"
+)
+
+/*
+Config File Templates
+- Runfiles can ship Go text/template templates for config files the gameserver reads, so game settings can be managed as args
+- Templates are rendered into the gameserver directory right before every start
+- The data has .Args (resolved values of the active args by flag), .Meta, .GameDir, .InstanceName and .OS, plus these functions:
+  arg "-flag" (error for unknown flags), enabled "-flag", default "fallback", quote, json, lower, upper, trim and bool
+- The hash of every rendered file is kept in run<Identifier>.templates.state.json. A target that changed since is drifted, someone edited it by hand
+  on_drift decides what happens then: "backup" (default) saves the edited file next to it before overwriting, "keep" leaves it alone, "overwrite" doesn't ask
+*/
+
+// FileTemplate is a config file rendered from the runfile args
+type FileTemplate struct {
+	Target       string `json:"target"`                  // output path relative to the gameserver directory
+	Type         string `json:"type"`                    // json, ini, xml, yaml or text
+	Description  string `json:"description,omitempty"`   // shown in the UI
+	Template     string `json:"template,omitempty"`      // inline template
+	TemplateFile string `json:"template_file,omitempty"` // template file relative to the runfiles folder, instead of an inline template
+	OnDrift      string `json:"on_drift,omitempty"`      // "backup" (default), "keep" or "overwrite"
+}
+
+// TemplateStatus describes the state of a rendered template target
+type TemplateStatus struct {
+	Target       string    `json:"target"`
+	Type         string    `json:"type"`
+	Description  string    `json:"description,omitempty"`
+	Exists       bool      `json:"exists"`
+	UpToDate     bool      `json:"up_to_date"` // the file matches what the template renders now
+	Drifted      bool      `json:"drifted"`    // the file was changed since SSUI rendered it
+	LastRendered time.Time `json:"last_rendered,omitempty"`
+	BackupPath   string    `json:"backup_path,omitempty"` // where a drifted file was saved, only set by RenderTemplates
+	Error        string    `json:"error,omitempty"`
+}
+
+type templateRecord struct {
+	Hash       string    `json:"hash"`
+	RenderedAt time.Time `json:"rendered_at"`
+}
+
+var knownTemplateDriftModes = []string{"", "backup", "keep", "overwrite"}
+
+type templateData struct {
+	Args         map[string]string
+	Meta         Meta
+	GameDir      string
+	InstanceName string
+	OS           string
+}
+
+func templateStatePath() string {
+	return filepath.Join(config.GetRunFilesFolder(), fmt.Sprintf("run%s.templates.state.json", config.GetRunfileIdentifier()))
+}
+
+func loadTemplateState() map[string]templateRecord {
+	state := make(map[string]templateRecord)
+	data, err := os.ReadFile(templateStatePath())
+	if err != nil {
+		return state
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		logger.Runfile.Warn("Template state is corrupt, every existing target will be treated as drifted: " + err.Error())
+	}
+	return state
+}
+
+func saveTemplateState(state map[string]templateRecord) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(templateStatePath(), data, 0644)
+}
+
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// templateIssues checks a template definition, templateDir is where template_file paths are resolved
+func templateIssues(t FileTemplate, templateDir string) []string {
+	var issues []string
+	if t.Target == "" {
+		issues = append(issues, "template target is required")
+	} else if escapesGameDir(t.Target) {
+		issues = append(issues, fmt.Sprintf("template target %s must be relative to the gameserver directory", t.Target))
+	}
+	if !slices.Contains(schemaEnums["FileTemplate.type"], t.Type) {
+		issues = append(issues, fmt.Sprintf("invalid template type %q for %s", t.Type, t.Target))
+	}
+	if !slices.Contains(knownTemplateDriftModes, t.OnDrift) {
+		issues = append(issues, fmt.Sprintf("invalid on_drift %q for %s, must be backup, keep or overwrite", t.OnDrift, t.Target))
+	}
+	if (t.Template == "") == (t.TemplateFile == "") {
+		issues = append(issues, fmt.Sprintf("template %s needs exactly one of template and template_file", t.Target))
+		return issues
+	}
+	if _, err := parseFileTemplate(t, templateDir, nil); err != nil {
+		issues = append(issues, err.Error())
+	}
+	return issues
+}
+
+func parseFileTemplate(t FileTemplate, templateDir string, r *argResolver) (*template.Template, error) {
+	text := t.Template
+	if t.TemplateFile != "" {
+		if escapesGameDir(t.TemplateFile) {
+			return nil, fmt.Errorf("template_file %s must be relative to the runfiles folder", t.TemplateFile)
+		}
+		data, err := os.ReadFile(filepath.Join(templateDir, t.TemplateFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template_file for %s: %w", t.Target, err)
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New(t.Target).Option("missingkey=error").Funcs(templateFuncs(r)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template for %s: %w", t.Target, err)
+	}
+	return tmpl, nil
+}
+
+// templateFuncs returns the functions available in templates, r may be nil when only parsing
+func templateFuncs(r *argResolver) template.FuncMap {
+	return template.FuncMap{
+		"arg": func(flag string) (string, error) {
+			if r == nil {
+				return "", nil
+			}
+			if _, ok := r.args[flag]; !ok {
+				return "", fmt.Errorf("unknown argument %s", flag)
+			}
+			if !r.isActive(flag) {
+				return "", nil
+			}
+			return r.args[flag].RuntimeValue, nil
+		},
+		"enabled": func(flag string) bool {
+			return r != nil && r.isSet(flag)
+		},
+		"default": func(fallback, value string) string {
+			if value == "" {
+				return fallback
+			}
+			return value
+		},
+		"quote": strconv.Quote,
+		"json": func(value any) (string, error) {
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		"trim":  strings.TrimSpace,
+		"bool": func(value string) bool {
+			parsed, err := strconv.ParseBool(value)
+			return err == nil && parsed
+		},
+	}
+}
+
+// renderAll renders every template of the loaded runfile, the results are in template order
+func renderAll() ([][]byte, []string, error) {
+	if CurrentRunfile == nil {
+		return nil, nil, ErrRunfileNotLoaded{Msg: "runfile not loaded"}
+	}
+	args, issues := resolveArgs(CurrentRunfile.getAllArgs())
+	if len(issues) > 0 {
+		return nil, nil, ErrValidation{Issues: issues}
+	}
+	r := newArgResolver(args)
+	data := templateData{
+		Args: make(map[string]string),
+		Meta: CurrentRunfile.Meta,
+		OS:   strings.ToLower(runtime.GOOS),
+	}
+	for flag, arg := range r.args {
+		if r.isActive(flag) {
+			data.Args[flag] = arg.RuntimeValue
+		}
+	}
+	data.GameDir, _ = ssuiVariable("game_dir")
+	data.InstanceName, _ = ssuiVariable("instance_name")
+
+	rendered := make([][]byte, len(CurrentRunfile.Templates))
+	errs := make([]string, len(CurrentRunfile.Templates))
+	var failed []string
+	for i, t := range CurrentRunfile.Templates {
+		tmpl, err := parseFileTemplate(t, config.GetRunFilesFolder(), r)
+		if err == nil {
+			var out bytes.Buffer
+			if err = tmpl.Execute(&out, data); err == nil {
+				rendered[i] = out.Bytes()
+				continue
+			}
+			err = fmt.Errorf("failed to render template for %s: %w", t.Target, err)
+		}
+		errs[i] = err.Error()
+		failed = append(failed, err.Error())
+	}
+	if len(failed) > 0 {
+		return rendered, errs, ErrValidation{Issues: failed}
+	}
+	return rendered, errs, nil
+}
+
+// GetTemplateStatus reports for every template whether its target is up to date or was edited by hand
+func GetTemplateStatus() []TemplateStatus {
+	runfileMutex.Lock()
+	defer runfileMutex.Unlock()
+
+	if CurrentRunfile == nil {
+		return nil
+	}
+	rendered, errs, _ := renderAll()
+	state := loadTemplateState()
+	statuses := make([]TemplateStatus, 0, len(CurrentRunfile.Templates))
+	for i, t := range CurrentRunfile.Templates {
+		status := TemplateStatus{Target: t.Target, Type: t.Type, Description: t.Description}
+		if errs != nil {
+			status.Error = errs[i]
+		}
+		record, recorded := state[t.Target]
+		status.LastRendered = record.RenderedAt
+		if current, err := os.ReadFile(filepath.Join(config.GetRunfileIdentifier(), t.Target)); err == nil {
+			status.Exists = true
+			status.Drifted = recorded && hashContent(current) != record.Hash
+			status.UpToDate = rendered != nil && rendered[i] != nil && bytes.Equal(current, rendered[i])
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// RenderTemplates renders all templates into the gameserver directory, this runs before every server start.
+// Nothing is written if any template fails to render.
+func RenderTemplates() ([]TemplateStatus, error) {
+	runfileMutex.Lock()
+	defer runfileMutex.Unlock()
+
+	if CurrentRunfile == nil || len(CurrentRunfile.Templates) == 0 {
+		return nil, nil
+	}
+	rendered, _, err := renderAll()
+	if err != nil {
+		return nil, err
+	}
+
+	state := loadTemplateState()
+	statuses := make([]TemplateStatus, 0, len(CurrentRunfile.Templates))
+	// targets written before a failure must be recorded, or the next run reports them as edited by hand
+	fail := func(err error) ([]TemplateStatus, error) {
+		saveTemplateStateLogged(state)
+		return statuses, err
+	}
+	for i, t := range CurrentRunfile.Templates {
+		status := TemplateStatus{Target: t.Target, Type: t.Type, Description: t.Description}
+		path := filepath.Join(config.GetRunfileIdentifier(), t.Target)
+		content := rendered[i]
+
+		if current, err := os.ReadFile(path); err == nil {
+			status.Exists = true
+			record, recorded := state[t.Target]
+			if bytes.Equal(current, content) {
+				status.UpToDate = true
+				if !recorded || record.Hash != hashContent(content) {
+					state[t.Target] = templateRecord{Hash: hashContent(content), RenderedAt: time.Now()}
+				}
+				statuses = append(statuses, status)
+				continue
+			}
+			// a file that was never rendered by SSUI counts as drifted too, it may hold settings someone cares about
+			status.Drifted = !recorded || hashContent(current) != record.Hash
+			if status.Drifted {
+				switch t.OnDrift {
+				case "keep":
+					logger.Runfile.Warn(fmt.Sprintf("%s was edited by hand and on_drift is keep, it is not rendered from the runfile", path))
+					status.LastRendered = record.RenderedAt
+					statuses = append(statuses, status)
+					continue
+				case "overwrite":
+					logger.Runfile.Warn(fmt.Sprintf("%s was edited by hand, overwriting it with the rendered template", path))
+				default:
+					status.BackupPath = fmt.Sprintf("%s.ssui-%s.bak", path, time.Now().Format("2006-01-02_15-04-05"))
+					if err := os.WriteFile(status.BackupPath, current, 0644); err != nil {
+						return fail(fmt.Errorf("failed to back up drifted %s: %w", path, err))
+					}
+					logger.Runfile.Warn(fmt.Sprintf("%s was edited by hand, saved it as %s before rendering the template", path, status.BackupPath))
+				}
+			}
+		}
+
+		file, declared := declaredFileAt(path)
+		if declared {
+			revisionsMutex.Lock()
+			if err := captureFileLocked(file); err != nil {
+				logger.Runfile.Warn(err.Error())
+			}
+			revisionsMutex.Unlock()
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fail(fmt.Errorf("failed to create directory for %s: %w", path, err))
+		}
+		tmpPath := path + ".tmp"
+		if err := os.WriteFile(tmpPath, content, 0644); err != nil {
+			return fail(fmt.Errorf("failed to write %s: %w", path, err))
+		}
+		if err := os.Rename(tmpPath, path); err != nil {
+			os.Remove(tmpPath)
+			return fail(fmt.Errorf("failed to replace %s: %w", path, err))
+		}
+		if declared {
+			RecordFileRevision(file.Filename, content, "SSUI", RevisionSourceTemplate, "rendered from template")
+		}
+		record := templateRecord{Hash: hashContent(content), RenderedAt: time.Now()}
+		state[t.Target] = record
+		status.Exists, status.UpToDate, status.LastRendered = true, true, record.RenderedAt
+		statuses = append(statuses, status)
+		logger.Runfile.Debug("Rendered template " + path)
+	}
+
+	saveTemplateStateLogged(state)
+	return statuses, nil
+}
+
+func saveTemplateStateLogged(state map[string]templateRecord) {
+	if err := saveTemplateState(state); err != nil {
+		logger.Runfile.Warn("Failed to save template state, drift detection may report false positives: " + err.Error())
+	}
+}
+
+// PreviewTemplate renders one template without writing it, registered secrets are redacted
+func PreviewTemplate(target string) (string, error) {
+	runfileMutex.Lock()
+	defer runfileMutex.Unlock()
+
+	if CurrentRunfile == nil {
+		return "", ErrRunfileNotLoaded{Msg: "runfile not loaded"}
+	}
+	index := slices.IndexFunc(CurrentRunfile.Templates, func(t FileTemplate) bool { return t.Target == target })
+	if index < 0 {
+		return "", fmt.Errorf("template %s not found", target)
+	}
+	rendered, errs, _ := renderAll()
+	if errs != nil && errs[index] != "" {
+		return "", fmt.Errorf("%s", errs[index])
+	}
+	if rendered == nil {
+		return "", fmt.Errorf("template %s could not be rendered", target)
+	}
+	return logger.Redact(string(rendered[index])), nil
+}
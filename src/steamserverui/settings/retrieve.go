@@ -100,6 +100,13 @@ func HandleRetrieveSettings(w http.ResponseWriter, r *http.Request) {
 			Value:       config.GetAuthTokenLifetime(),
 			Min:         intPtr(0),
 		},
+		{
+			Name:        "GalleryVerificationMode",
+			Type:        "string",
+			Group:       "Security Settings",
+			Description: "How runfile and plugin gallery downloads are verified. verify checks the digests and signatures a manifest provides, require refuses anything without a valid signature from a trusted publisher. Once trusted publisher keys are configured, and for plugins always, downloads are verified as in require.",
+			Value:       config.GetGalleryVerificationMode(),
+		},
 		{
 			Name:        "IsBepInExEnabled",
 			Type:        "bool",
@@ -128,6 +135,13 @@ func HandleRetrieveSettings(w http.ResponseWriter, r *http.Request) {
 			Description: "Allows automatis SSUI version updates to happen automatically at restart",
 			Value:       config.GetIsUpdateEnabled(),
 		},
+		{
+			Name:        "IsRunfileUpdateCheckEnabled",
+			Type:        "bool",
+			Group:       "Update Settings",
+			Description: "Periodically checks the runfile gallery for a newer version of the installed runfile and notifies about it",
+			Value:       config.GetIsRunfileUpdateCheckEnabled(),
+		},
 		{
 			Name:        "AllowPrereleaseUpdates",
 			Type:        "bool",
@@ -240,6 +254,34 @@ func HandleRetrieveSettings(w http.ResponseWriter, r *http.Request) {
 			Description: "Error channel ID",
 			Value:       config.GetErrorChannelID(),
 		},
+		{
+			Name:        "ExceptionStartPattern",
+			Type:        "string",
+			Group:       "Detection Settings",
+			Description: "Regex matching the first line of a gameserver exception. Following lines are grouped into the same exception event.",
+			Value:       config.GetExceptionStartPattern(),
+		},
+		{
+			Name:        "ExceptionContinuationPattern",
+			Type:        "string",
+			Group:       "Detection Settings",
+			Description: "Regex matching stack trace lines that continue an exception started by ExceptionStartPattern.",
+			Value:       config.GetExceptionContinuationPattern(),
+		},
+		{
+			Name:        "ExceptionFlushTimeout",
+			Type:        "string",
+			Group:       "Detection Settings",
+			Description: "How long to wait for further stack trace lines before an exception is reported. Supports go style timeframes like 2s.",
+			Value:       config.GetExceptionFlushTimeout().String(),
+		},
+		{
+			Name:        "ExceptionAlertCooldown",
+			Type:        "string",
+			Group:       "Detection Settings",
+			Description: "Identical exceptions are alerted once per cooldown and counted in between, must be greater than 0. Supports go style timeframes like 10m0s.",
+			Value:       config.GetExceptionAlertCooldown().String(),
+		},
 		{
 			Name:        "BackupsStoreDir",
 			Type:        "string",
@@ -90,6 +90,12 @@ var setterMap = map[string]setterFunc{
 		}
 		return fmt.Errorf("invalid type for GameBranch: expected string")
 	},
+	"GalleryVerificationMode": func(v interface{}) error {
+		if str, ok := v.(string); ok {
+			return config.SetGalleryVerificationMode(str)
+		}
+		return fmt.Errorf("invalid type for GalleryVerificationMode: expected string")
+	},
 	"Users": func(v interface{}) error {
 		if m, ok := v.(map[string]interface{}); ok {
 			users := make(map[string]string)
@@ -166,6 +172,12 @@ var setterMap = map[string]setterFunc{
 		}
 		return fmt.Errorf("invalid type for IsUpdateEnabled: expected bool")
 	},
+	"IsRunfileUpdateCheckEnabled": func(v interface{}) error {
+		if b, ok := v.(bool); ok {
+			return config.SetIsRunfileUpdateCheckEnabled(b)
+		}
+		return fmt.Errorf("invalid type for IsRunfileUpdateCheckEnabled: expected bool")
+	},
 	"IsSSCMEnabled": func(v interface{}) error {
 		if b, ok := v.(bool); ok {
 			return config.SetIsSSCMEnabled(b)
@@ -202,6 +214,38 @@ var setterMap = map[string]setterFunc{
 		}
 		return fmt.Errorf("invalid type for BackupsStoreDir: expected string")
 	},
+	"ExceptionStartPattern": func(v interface{}) error {
+		if str, ok := v.(string); ok {
+			return config.SetExceptionStartPattern(str)
+		}
+		return fmt.Errorf("invalid type for ExceptionStartPattern: expected string")
+	},
+	"ExceptionContinuationPattern": func(v interface{}) error {
+		if str, ok := v.(string); ok {
+			return config.SetExceptionContinuationPattern(str)
+		}
+		return fmt.Errorf("invalid type for ExceptionContinuationPattern: expected string")
+	},
+	"ExceptionFlushTimeout": func(v interface{}) error {
+		if str, ok := v.(string); ok {
+			timeout, err := time.ParseDuration(str)
+			if err != nil {
+				return err
+			}
+			return config.SetExceptionFlushTimeout(timeout)
+		}
+		return fmt.Errorf("invalid type for ExceptionFlushTimeout: expected string")
+	},
+	"ExceptionAlertCooldown": func(v interface{}) error {
+		if str, ok := v.(string); ok {
+			cooldown, err := time.ParseDuration(str)
+			if err != nil {
+				return err
+			}
+			return config.SetExceptionAlertCooldown(cooldown)
+		}
+		return fmt.Errorf("invalid type for ExceptionAlertCooldown: expected string")
+	},
 	"BackupLoopActive": func(v interface{}) error {
 		if b, ok := v.(bool); ok {
 			return config.SetBackupLoopActive(b)